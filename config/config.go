@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
@@ -14,6 +15,27 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	Port       string
+
+	// ストレージの種類（"mysql" または "memory"）
+	StorageDriver string
+
+	// 起動時に未適用のマイグレーションを適用するか
+	MigrateOnStart bool
+
+	// セッショントークンの署名鍵と有効期限（時間）
+	SessionSecret   string
+	SessionTTLHours int
+
+	// 問題・問題セットを管理するオペレーターのキー（未設定時は管理用エンドポイントを使えない）
+	AdminAPIKey string
+
+	// 難易度別の1問あたりのデフォルト制限時間（秒）
+	TimerEasySeconds   int
+	TimerMediumSeconds int
+	TimerHardSeconds   int
+
+	// 早押しの遅延補正で受信時刻から差し引く時間の上限（ミリ秒）
+	BuzzMaxCompensationMs int
 }
 
 func LoadConfig() *Config {
@@ -29,6 +51,20 @@ func LoadConfig() *Config {
 		DBPassword: getEnv("DB_PASSWORD", "password"),
 		DBName:     getEnv("DB_NAME", "quivra"),
 		Port:       getEnv("PORT", "8080"),
+
+		StorageDriver:  getEnv("STORAGE_DRIVER", "mysql"),
+		MigrateOnStart: getEnvBool("MIGRATE_ON_START", true),
+
+		SessionSecret:   getEnv("SESSION_SECRET", ""),
+		SessionTTLHours: getEnvInt("SESSION_TTL_HOURS", 24),
+
+		AdminAPIKey: getEnv("ADMIN_API_KEY", ""),
+
+		TimerEasySeconds:   getEnvInt("TIMER_EASY_SECONDS", 15),
+		TimerMediumSeconds: getEnvInt("TIMER_MEDIUM_SECONDS", 20),
+		TimerHardSeconds:   getEnvInt("TIMER_HARD_SECONDS", 30),
+
+		BuzzMaxCompensationMs: getEnvInt("BUZZ_MAX_COMPENSATION_MS", 250),
 	}
 }
 
@@ -38,3 +74,31 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid value for %s: %q, using default %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid value for %s: %q, using default %t", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
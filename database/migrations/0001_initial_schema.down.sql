@@ -0,0 +1,6 @@
+-- 外部キーの依存関係の逆順に削除する
+DROP TABLE IF EXISTS buzz_queue;
+DROP TABLE IF EXISTS game_sessions;
+DROP TABLE IF EXISTS questions;
+DROP TABLE IF EXISTS players;
+DROP TABLE IF EXISTS rooms;
@@ -1,4 +1,6 @@
 -- Quivra Database Schema
+-- 以前の初期化スクリプト（migrations.sql）と同じスキーマ。既存のデータベースでは何もせず適用済みとして記録され、
+-- 以降の変更は 0002 以降のマイグレーションで適用する。インデックスはテーブル定義に含めているため、既存のデータベースに対して実行しても失敗しない
 
 -- 1. rooms テーブル
 CREATE TABLE IF NOT EXISTS rooms (
@@ -7,7 +9,9 @@ CREATE TABLE IF NOT EXISTS rooms (
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     status ENUM('waiting', 'playing', 'finished') DEFAULT 'waiting',
     is_public BOOLEAN DEFAULT TRUE,
-    created_by VARCHAR(36) NOT NULL
+    created_by VARCHAR(36) NOT NULL,
+    INDEX idx_rooms_is_public (is_public),
+    INDEX idx_rooms_created_by (created_by)
 );
 
 -- 2. players テーブル
@@ -18,6 +22,8 @@ CREATE TABLE IF NOT EXISTS players (
     score INT DEFAULT 0,
     joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     is_admin BOOLEAN DEFAULT FALSE,
+    INDEX idx_players_room_id (room_id),
+    INDEX idx_players_is_admin (is_admin),
     FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE
 );
 
@@ -28,7 +34,9 @@ CREATE TABLE IF NOT EXISTS questions (
     answer VARCHAR(255) NOT NULL,
     category VARCHAR(50) DEFAULT 'general',
     difficulty ENUM('easy', 'medium', 'hard') DEFAULT 'medium',
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_questions_category (category),
+    INDEX idx_questions_difficulty (difficulty)
 );
 
 -- 4. game_sessions テーブル
@@ -40,6 +48,8 @@ CREATE TABLE IF NOT EXISTS game_sessions (
     ended_at TIMESTAMP NULL,
     status ENUM('waiting', 'question', 'buzzed', 'answered', 'finished') DEFAULT 'waiting',
     buzzed_player_id VARCHAR(36) NULL,
+    INDEX idx_game_sessions_room_id (room_id),
+    INDEX idx_game_sessions_status (status),
     FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
     FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE SET NULL,
     FOREIGN KEY (buzzed_player_id) REFERENCES players(id) ON DELETE SET NULL
@@ -52,18 +62,8 @@ CREATE TABLE IF NOT EXISTS buzz_queue (
     player_id VARCHAR(36) NOT NULL,
     buzzed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     is_active BOOLEAN DEFAULT TRUE,
+    INDEX idx_buzz_queue_room_id (room_id),
+    INDEX idx_buzz_queue_is_active (is_active),
     FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
     FOREIGN KEY (player_id) REFERENCES players(id) ON DELETE CASCADE
 );
-
--- インデックスの作成（存在しない場合のみ作成）
-CREATE INDEX idx_players_room_id ON players(room_id);
-CREATE INDEX idx_players_is_admin ON players(is_admin);
-CREATE INDEX idx_questions_category ON questions(category);
-CREATE INDEX idx_questions_difficulty ON questions(difficulty);
-CREATE INDEX idx_game_sessions_room_id ON game_sessions(room_id);
-CREATE INDEX idx_game_sessions_status ON game_sessions(status);
-CREATE INDEX idx_rooms_is_public ON rooms(is_public);
-CREATE INDEX idx_rooms_created_by ON rooms(created_by);
-CREATE INDEX idx_buzz_queue_room_id ON buzz_queue(room_id);
-CREATE INDEX idx_buzz_queue_is_active ON buzz_queue(is_active);
@@ -0,0 +1,14 @@
+ALTER TABLE game_sessions
+    DROP FOREIGN KEY fk_game_sessions_match_id,
+    DROP INDEX idx_game_sessions_match_id,
+    DROP COLUMN question_number,
+    DROP COLUMN match_id;
+
+DROP TABLE IF EXISTS matches;
+DROP TABLE IF EXISTS question_aliases;
+
+ALTER TABLE questions
+    DROP COLUMN answer_tolerance;
+
+ALTER TABLE rooms
+    DROP COLUMN settings;
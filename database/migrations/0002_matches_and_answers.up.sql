@@ -0,0 +1,36 @@
+-- ルーム設定（採点ルール・タイマー・観戦など）
+ALTER TABLE rooms
+    ADD COLUMN settings JSON NULL;
+
+-- 答えの表記ゆれの許容編集距離（NULL は答えの長さから自動で決める）
+ALTER TABLE questions
+    ADD COLUMN answer_tolerance INT NULL;
+
+-- question_aliases テーブル（正解として扱う別解）
+CREATE TABLE IF NOT EXISTS question_aliases (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    question_id INT NOT NULL,
+    alias VARCHAR(255) NOT NULL,
+    UNIQUE KEY uq_question_aliases (question_id, alias),
+    FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE
+);
+
+-- matches テーブル（複数問で構成される1試合）
+CREATE TABLE IF NOT EXISTS matches (
+    id VARCHAR(36) PRIMARY KEY,
+    room_id VARCHAR(10) NOT NULL,
+    total_questions INT NOT NULL DEFAULT 10,
+    current_number INT NOT NULL DEFAULT 0,
+    status ENUM('playing', 'finished') DEFAULT 'playing',
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    ended_at TIMESTAMP NULL,
+    INDEX idx_matches_room_id (room_id),
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE
+);
+
+-- ゲームセッションはマッチ内の1問ごとに作成する
+ALTER TABLE game_sessions
+    ADD COLUMN match_id VARCHAR(36) NULL AFTER room_id,
+    ADD COLUMN question_number INT NOT NULL DEFAULT 0 AFTER match_id,
+    ADD INDEX idx_game_sessions_match_id (match_id),
+    ADD CONSTRAINT fk_game_sessions_match_id FOREIGN KEY (match_id) REFERENCES matches(id) ON DELETE CASCADE;
@@ -0,0 +1,13 @@
+-- サンプル問題を削除（別解は外部キーのカスケードで削除される）
+DELETE FROM questions WHERE question IN (
+    '日本の首都はどこですか？',
+    '1+1はいくつですか？',
+    '光の速度は秒速何メートルですか？',
+    '日本の最高峰は何ですか？',
+    '円周率の最初の3桁は？',
+    '水の化学式は？',
+    '日本の国花は？',
+    '1年は何日ですか？',
+    '太陽系の惑星の数は？',
+    '日本の元号で現在のものは？'
+);
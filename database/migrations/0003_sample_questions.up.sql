@@ -0,0 +1,23 @@
+-- サンプル問題データ
+-- 以前の初期化スクリプトで投入済みのデータベースでも重複しないよう、未登録の問題だけを追加する
+INSERT INTO questions (question, answer, category, difficulty)
+SELECT * FROM (
+    SELECT '日本の首都はどこですか？' AS question, '東京' AS answer, 'geography' AS category, 'easy' AS difficulty
+    UNION ALL SELECT '1+1はいくつですか？', '2', 'math', 'easy'
+    UNION ALL SELECT '光の速度は秒速何メートルですか？', '299792458', 'science', 'hard'
+    UNION ALL SELECT '日本の最高峰は何ですか？', '富士山', 'geography', 'medium'
+    UNION ALL SELECT '円周率の最初の3桁は？', '3.14', 'math', 'medium'
+    UNION ALL SELECT '水の化学式は？', 'H2O', 'science', 'easy'
+    UNION ALL SELECT '日本の国花は？', '桜', 'culture', 'easy'
+    UNION ALL SELECT '1年は何日ですか？', '365', 'general', 'easy'
+    UNION ALL SELECT '太陽系の惑星の数は？', '8', 'science', 'medium'
+    UNION ALL SELECT '日本の元号で現在のものは？', '令和', 'culture', 'easy'
+) AS samples
+WHERE NOT EXISTS (SELECT 1 FROM questions q WHERE q.question = samples.question);
+
+-- サンプル別解データ
+INSERT IGNORE INTO question_aliases (question_id, alias)
+SELECT id, 'Tokyo' FROM questions WHERE answer = '東京'
+UNION ALL SELECT id, '東京都' FROM questions WHERE answer = '東京'
+UNION ALL SELECT id, 'ふじさん' FROM questions WHERE answer = '富士山'
+UNION ALL SELECT id, 'さくら' FROM questions WHERE answer = '桜';
@@ -0,0 +1,6 @@
+ALTER TABLE players
+    DROP FOREIGN KEY fk_players_team_id,
+    DROP INDEX idx_players_team_id,
+    DROP COLUMN team_id;
+
+DROP TABLE IF EXISTS teams;
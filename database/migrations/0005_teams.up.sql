@@ -0,0 +1,15 @@
+-- teams テーブル（チーム戦用のルーム内チーム）
+CREATE TABLE IF NOT EXISTS teams (
+    id VARCHAR(36) PRIMARY KEY,
+    room_id VARCHAR(10) NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_teams_room_name (room_id, name),
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE
+);
+
+-- プレイヤーの所属チーム
+ALTER TABLE players
+    ADD COLUMN team_id VARCHAR(36) NULL,
+    ADD INDEX idx_players_team_id (team_id),
+    ADD CONSTRAINT fk_players_team_id FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE SET NULL;
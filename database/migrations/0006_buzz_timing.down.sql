@@ -0,0 +1,7 @@
+ALTER TABLE buzz_queue
+    DROP INDEX idx_buzz_queue_pressed_at,
+    DROP COLUMN rtt_ms,
+    DROP COLUMN client_sent_at,
+    DROP COLUMN client_pressed_at,
+    DROP COLUMN pressed_at,
+    MODIFY buzzed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
@@ -0,0 +1,13 @@
+-- 早押しの遅延補正：補正後の押下時刻で並べ、判定の根拠として生の時刻も残す
+ALTER TABLE buzz_queue
+    MODIFY buzzed_at TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3),
+    ADD COLUMN pressed_at TIMESTAMP(3) NULL,
+    ADD COLUMN client_pressed_at BIGINT NULL,
+    ADD COLUMN client_sent_at BIGINT NULL,
+    ADD COLUMN rtt_ms INT NOT NULL DEFAULT 0;
+
+UPDATE buzz_queue SET pressed_at = buzzed_at WHERE pressed_at IS NULL;
+
+ALTER TABLE buzz_queue
+    MODIFY pressed_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    ADD INDEX idx_buzz_queue_pressed_at (room_id, pressed_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS match_questions;
+DROP TABLE IF EXISTS question_pack_items;
+DROP TABLE IF EXISTS question_packs;
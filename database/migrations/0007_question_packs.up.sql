@@ -0,0 +1,31 @@
+-- question_packs テーブル（主催者が用意する問題セット）
+CREATE TABLE IF NOT EXISTS question_packs (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    shuffle BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- question_pack_items テーブル（問題セットに収録する問題と並び順）
+CREATE TABLE IF NOT EXISTS question_pack_items (
+    pack_id INT NOT NULL,
+    position INT NOT NULL,
+    question_id INT NOT NULL,
+    PRIMARY KEY (pack_id, position),
+    UNIQUE KEY uq_question_pack_items (pack_id, question_id),
+    FOREIGN KEY (pack_id) REFERENCES question_packs(id) ON DELETE CASCADE,
+    FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE
+);
+
+-- match_questions テーブル（マッチ開始時に決めた出題順。同じマッチで同じ問題は出題しない）
+CREATE TABLE IF NOT EXISTS match_questions (
+    match_id VARCHAR(36) NOT NULL,
+    position INT NOT NULL,
+    question_id INT NOT NULL,
+    PRIMARY KEY (match_id, position),
+    UNIQUE KEY uq_match_questions (match_id, question_id),
+    FOREIGN KEY (match_id) REFERENCES matches(id) ON DELETE CASCADE,
+    FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE
+);
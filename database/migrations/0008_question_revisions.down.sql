@@ -0,0 +1,10 @@
+ALTER TABLE game_sessions
+    DROP COLUMN question_revision;
+
+DROP TABLE IF EXISTS question_revisions;
+
+ALTER TABLE questions
+    DROP INDEX idx_questions_deleted_at,
+    DROP COLUMN deleted_at,
+    DROP COLUMN updated_at,
+    DROP COLUMN revision;
@@ -0,0 +1,26 @@
+-- 問題の編集履歴と論理削除：出題済みの問題を編集しても、終了したゲームセッションの問題文・正解は変わらない
+ALTER TABLE questions
+    ADD COLUMN revision INT NOT NULL DEFAULT 1,
+    ADD COLUMN updated_at TIMESTAMP NULL,
+    ADD COLUMN deleted_at TIMESTAMP NULL,
+    ADD INDEX idx_questions_deleted_at (deleted_at);
+
+-- question_revisions テーブル（編集で置き換えられた過去の版。最新版は questions に残る）
+CREATE TABLE IF NOT EXISTS question_revisions (
+    question_id INT NOT NULL,
+    revision INT NOT NULL,
+    question TEXT NOT NULL,
+    answer VARCHAR(255) NOT NULL,
+    answer_tolerance INT NULL,
+    aliases JSON NOT NULL,
+    category VARCHAR(50) DEFAULT 'general',
+    difficulty ENUM('easy', 'medium', 'hard') DEFAULT 'medium',
+    created_at TIMESTAMP NOT NULL,
+    replaced_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (question_id, revision),
+    FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE
+);
+
+-- 出題したときの問題の版
+ALTER TABLE game_sessions
+    ADD COLUMN question_revision INT NULL AFTER question_id;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS question_tags;
+
+ALTER TABLE questions
+    DROP INDEX idx_questions_created_at,
+    DROP INDEX ft_questions_text;
@@ -0,0 +1,13 @@
+-- 問題一覧の検索：問題文・答えの全文検索（日本語は分かち書きがないため ngram パーサーを使う）とタグ
+ALTER TABLE questions
+    ADD FULLTEXT INDEX ft_questions_text (question, answer) WITH PARSER ngram,
+    ADD INDEX idx_questions_created_at (created_at, id);
+
+-- question_tags テーブル（問題の分類用タグ。版管理の対象外）
+CREATE TABLE IF NOT EXISTS question_tags (
+    question_id INT NOT NULL,
+    tag VARCHAR(50) NOT NULL,
+    PRIMARY KEY (question_id, tag),
+    INDEX idx_question_tags_tag (tag),
+    FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE
+);
@@ -0,0 +1,5 @@
+DELETE FROM player_sessions WHERE role = 'spectator';
+
+ALTER TABLE player_sessions
+    DROP COLUMN role,
+    MODIFY player_id VARCHAR(36) NOT NULL;
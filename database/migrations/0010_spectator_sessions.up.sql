@@ -0,0 +1,4 @@
+-- 観戦者のセッショントークン：プレイヤーとして登録しないため player_id を持たない
+ALTER TABLE player_sessions
+    MODIFY player_id VARCHAR(36) NULL,
+    ADD COLUMN role ENUM('player', 'spectator') NOT NULL DEFAULT 'player';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS room_bans;
+
+ALTER TABLE players
+    DROP COLUMN muted;
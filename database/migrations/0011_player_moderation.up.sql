@@ -0,0 +1,13 @@
+-- ミュート中のプレイヤー（早押し・回答できない）
+ALTER TABLE players
+    ADD COLUMN muted BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- ルームへの参加禁止（ルームが存在する間有効。名前は小文字に正規化して保存）
+CREATE TABLE IF NOT EXISTS room_bans (
+    room_id VARCHAR(10) NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    player_id VARCHAR(36) NULL, -- プレイヤーを指定して禁止した場合（プレイヤーは削除されるため外部キーにしない）
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (room_id, name),
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+ALTER TABLE questions
+    DROP INDEX ft_questions_question;
@@ -0,0 +1,3 @@
+-- 正解を見られない利用者の検索は問題文だけに照合するため、問題文のみの全文索引を追加する
+ALTER TABLE questions
+    ADD FULLTEXT INDEX ft_questions_question (question) WITH PARSER ngram;
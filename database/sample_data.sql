@@ -1,12 +0,0 @@
--- サンプル問題データ
-INSERT INTO questions (question, answer, category, difficulty) VALUES
-('日本の首都はどこですか？', '東京', 'geography', 'easy'),
-('1+1はいくつですか？', '2', 'math', 'easy'),
-('光の速度は秒速何メートルですか？', '299792458', 'science', 'hard'),
-('日本の最高峰は何ですか？', '富士山', 'geography', 'medium'),
-('円周率の最初の3桁は？', '3.14', 'math', 'medium'),
-('水の化学式は？', 'H2O', 'science', 'easy'),
-('日本の国花は？', '桜', 'culture', 'easy'),
-('1年は何日ですか？', '365', 'general', 'easy'),
-('太陽系の惑星の数は？', '8', 'science', 'medium'),
-('日本の元号で現在のものは？', '令和', 'culture', 'easy');
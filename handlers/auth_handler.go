@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"quivra-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthHandler struct {
+	authService *services.AuthService
+}
+
+func NewAuthHandler(authService *services.AuthService) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+	}
+}
+
+// RevokeSession 自分のセッショントークンを失効させる（ログアウト）
+func (ah *AuthHandler) RevokeSession(c *gin.Context) {
+	token := bearerToken(c)
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "session token is required"})
+		return
+	}
+
+	if _, err := ah.authService.Authenticate(token); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ah.authService.RevokeToken(token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "セッションを無効化しました",
+	})
+}
+
+// RequireOperator オペレーターのキー（Authorization: Bearer）を要求する
+// 正解を返す・問題を書き換えるエンドポイントに付ける（ルームの管理者トークンでは通らない）
+func RequireOperator(authService *services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authService.IsOperator(bearerToken(c)) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "operator key required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// bearerToken Authorization: Bearer ヘッダーからトークンを取り出す
+func bearerToken(c *gin.Context) string {
+	token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !found {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"quivra-backend/models"
+	"quivra-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PlayerModerator キック・参加禁止・ミュートを行い、接続中のクライアントに反映する（WebSocket ハンドラーが実装）
+type PlayerModerator interface {
+	KickPlayer(roomID, playerID string) (*models.Player, error)
+	BanPlayer(roomID, playerID string) (*models.Player, error)
+	BanName(roomID, name string) (*models.Player, error)
+	MutePlayer(roomID, playerID string, muted bool) (*models.Player, error)
+}
+
+// KickPlayer プレイヤーをルームから外す（ルーム管理者のみ）
+func (rh *RoomHandler) KickPlayer(c *gin.Context) {
+	roomID := c.Param("roomId")
+	if !rh.authorizeRoomAdmin(c, roomID) {
+		return
+	}
+
+	player, err := rh.moderator.KickPlayer(roomID, c.Param("playerId"))
+	if err != nil {
+		respondModerationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"player":  player,
+		"message": "プレイヤーをルームから外しました",
+	})
+}
+
+// BanPlayer プレイヤーの名前を参加禁止にしてルームから外す（ルーム管理者のみ）
+func (rh *RoomHandler) BanPlayer(c *gin.Context) {
+	roomID := c.Param("roomId")
+	if !rh.authorizeRoomAdmin(c, roomID) {
+		return
+	}
+
+	player, err := rh.moderator.BanPlayer(roomID, c.Param("playerId"))
+	if err != nil {
+		respondModerationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"player":  player,
+		"message": "プレイヤーを参加禁止にしました",
+	})
+}
+
+// BanName 名前を参加禁止にする。参加中のプレイヤーがいればルームから外す（ルーム管理者のみ）
+func (rh *RoomHandler) BanName(c *gin.Context) {
+	roomID := c.Param("roomId")
+	if !rh.authorizeRoomAdmin(c, roomID) {
+		return
+	}
+
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	player, err := rh.moderator.BanName(roomID, req.Name)
+	if err != nil {
+		respondModerationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"name":    req.Name,
+		"player":  player, // 参加中のプレイヤーがいなければ null
+		"message": "名前を参加禁止にしました",
+	})
+}
+
+// GetRoomBans ルームの参加禁止の一覧取得（ルーム管理者のみ）
+func (rh *RoomHandler) GetRoomBans(c *gin.Context) {
+	roomID := c.Param("roomId")
+	if !rh.authorizeRoomAdmin(c, roomID) {
+		return
+	}
+
+	bans, err := rh.roomService.GetRoomBans(roomID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bans": bans,
+	})
+}
+
+// MutePlayer プレイヤーのミュートを切り替える（ルーム管理者のみ。ボディ省略時はミュート）
+func (rh *RoomHandler) MutePlayer(c *gin.Context) {
+	roomID := c.Param("roomId")
+	if !rh.authorizeRoomAdmin(c, roomID) {
+		return
+	}
+
+	var req struct {
+		Muted *bool `json:"muted"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	muted := req.Muted == nil || *req.Muted
+
+	player, err := rh.moderator.MutePlayer(roomID, c.Param("playerId"), muted)
+	if err != nil {
+		respondModerationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"player": player,
+	})
+}
+
+// respondModerationError キック・参加禁止・ミュートのエラーをステータスコードに変換
+func respondModerationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrPlayerNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCannotModerateAdmin):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidBanTarget):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"quivra-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PackHandler struct {
+	packService *services.PackService
+}
+
+func NewPackHandler(packService *services.PackService) *PackHandler {
+	return &PackHandler{packService: packService}
+}
+
+// packRequest 問題セットの作成・更新リクエスト
+type packRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Shuffle     bool   `json:"shuffle"`
+	QuestionIDs []int  `json:"question_ids" binding:"required"`
+}
+
+// CreatePack 問題セット作成
+func (ph *PackHandler) CreatePack(c *gin.Context) {
+	var req packRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pack, err := ph.packService.CreatePack(req.Name, req.Description, req.Shuffle, req.QuestionIDs)
+	if err != nil {
+		respondPackError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pack":    pack,
+		"message": "問題セットが作成されました",
+	})
+}
+
+// GetPacks 問題セット一覧取得
+func (ph *PackHandler) GetPacks(c *gin.Context) {
+	packs, err := ph.packService.GetPacks()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"packs": packs})
+}
+
+// GetPack 問題セット取得
+func (ph *PackHandler) GetPack(c *gin.Context) {
+	id, ok := packID(c)
+	if !ok {
+		return
+	}
+
+	pack, err := ph.packService.GetPack(id)
+	if err != nil {
+		respondPackError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pack)
+}
+
+// UpdatePack 問題セット更新（収録する問題は丸ごと置き換え）
+func (ph *PackHandler) UpdatePack(c *gin.Context) {
+	id, ok := packID(c)
+	if !ok {
+		return
+	}
+
+	var req packRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pack, err := ph.packService.UpdatePack(id, req.Name, req.Description, req.Shuffle, req.QuestionIDs)
+	if err != nil {
+		respondPackError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pack":    pack,
+		"message": "問題セットが更新されました",
+	})
+}
+
+// DeletePack 問題セット削除
+func (ph *PackHandler) DeletePack(c *gin.Context) {
+	id, ok := packID(c)
+	if !ok {
+		return
+	}
+
+	if err := ph.packService.DeletePack(id); err != nil {
+		respondPackError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "問題セットが削除されました"})
+}
+
+// packID パスパラメータから問題セットIDを取得（不正な場合は400を返す）
+func packID(c *gin.Context) (int, bool) {
+	var id int
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pack ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+// respondPackError 問題セットのエラーをHTTPステータスに変換
+func respondPackError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrPackNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidPack):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,9 +1,16 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"path/filepath"
+	"strings"
 
+	"quivra-backend/models"
 	"quivra-backend/services"
 
 	"github.com/gin-gonic/gin"
@@ -11,19 +18,26 @@ import (
 
 type QuestionHandler struct {
 	questionService *services.QuestionService
+	authService     *services.AuthService
 }
 
-func NewQuestionHandler(questionService *services.QuestionService) *QuestionHandler {
-	return &QuestionHandler{questionService: questionService}
+func NewQuestionHandler(questionService *services.QuestionService, authService *services.AuthService) *QuestionHandler {
+	return &QuestionHandler{
+		questionService: questionService,
+		authService:     authService,
+	}
 }
 
 // CreateQuestion 問題作成
 func (qh *QuestionHandler) CreateQuestion(c *gin.Context) {
 	var req struct {
-		Question   string `json:"question" binding:"required"`
-		Answer     string `json:"answer" binding:"required"`
-		Category   string `json:"category"`
-		Difficulty string `json:"difficulty"`
+		Question        string   `json:"question" binding:"required"`
+		Answer          string   `json:"answer" binding:"required"`
+		AnswerTolerance *int     `json:"answer_tolerance"`
+		Aliases         []string `json:"aliases"`
+		Tags            []string `json:"tags"`
+		Category        string   `json:"category"`
+		Difficulty      string   `json:"difficulty"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -31,60 +45,399 @@ func (qh *QuestionHandler) CreateQuestion(c *gin.Context) {
 		return
 	}
 
-	// デフォルト値の設定
-	if req.Category == "" {
-		req.Category = "general"
-	}
-	if req.Difficulty == "" {
-		req.Difficulty = "medium"
-	}
-
-	question, err := qh.questionService.CreateQuestion(req.Question, req.Answer, req.Category, req.Difficulty)
+	// カテゴリ・難易度を省略した場合は general / medium になる
+	question, err := qh.questionService.CreateQuestion(req.Question, req.Answer, req.Category, req.Difficulty, req.AnswerTolerance, req.Aliases, req.Tags)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondQuestionError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"id":      question.ID,
+		"aliases": question.Aliases,
+		"tags":    question.Tags,
 		"message": "問題が作成されました",
 	})
 }
 
-// GetQuestions 問題一覧取得
+// GetQuestions 問題一覧取得（検索・絞り込み・カーソルによるページ送り）
+// 正解・別解はオペレーターのキーを付けた場合のみ返す
 func (qh *QuestionHandler) GetQuestions(c *gin.Context) {
-	category := c.Query("category")
-	difficulty := c.Query("difficulty")
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		if _, err := fmt.Sscanf(value, "%d", &limit); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be an integer"})
+			return
+		}
+	}
 
-	questions, err := qh.questionService.GetQuestions(category, difficulty)
+	// 正解を見られない利用者には、検索語を答えに照合させない（結果の有無から答えを探れてしまうため）
+	canViewAnswers := qh.canViewAnswers(c)
+	page, err := qh.questionService.ListQuestions(services.QuestionListParams{
+		Search:        c.Query("q"),
+		SearchAnswers: canViewAnswers,
+		Categories:    queryList(c, "category"),
+		Difficulties:  queryList(c, "difficulty"),
+		Tags:          queryList(c, "tag"),
+		Sort:          c.Query("sort"),
+		Order:         c.Query("order"),
+		Cursor:        c.Query("cursor"),
+		Limit:         limit,
+	})
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidQuestionQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"questions": questions})
+	var questions interface{} = page.Questions
+	if !canViewAnswers {
+		public := make([]models.PublicQuestion, len(page.Questions))
+		for i := range page.Questions {
+			public[i] = page.Questions[i].Public()
+		}
+		questions = public
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"questions":   questions,
+		"total":       page.Total,
+		"next_cursor": page.NextCursor,
+		"limit":       page.Limit,
+	})
 }
 
-// GetQuestion 問題取得
+// canViewAnswers Authorization ヘッダーがオペレーターのキーか
+// （キーがない・違う場合もエラーにはせず、正解を伏せて返す。ルームの管理者トークンは誰でも取得できるため対象外）
+func (qh *QuestionHandler) canViewAnswers(c *gin.Context) bool {
+	return qh.authService.IsOperator(bearerToken(c))
+}
+
+// queryList 繰り返し指定またはカンマ区切りのクエリパラメータを値の一覧にする
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, value := range c.QueryArray(key) {
+		values = append(values, strings.Split(value, ",")...)
+	}
+	return values
+}
+
+// GetQuestion 問題取得（正解・別解はオペレーターのキーを付けた場合のみ返す）
 func (qh *QuestionHandler) GetQuestion(c *gin.Context) {
-	questionID := c.Param("id")
-	if questionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "question ID is required"})
+	id, ok := questionID(c)
+	if !ok {
+		return
+	}
+
+	question, err := qh.questionService.GetQuestion(id)
+	if err != nil {
+		respondQuestionError(c, err)
+		return
+	}
+
+	if !qh.canViewAnswers(c) {
+		c.JSON(http.StatusOK, question.Public())
+		return
+	}
+	c.JSON(http.StatusOK, question)
+}
+
+// SetAliases 問題の別解を置き換え
+func (qh *QuestionHandler) SetAliases(c *gin.Context) {
+	id, ok := questionID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Aliases []string `json:"aliases"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// IDをintに変換
+	aliases, err := qh.questionService.SetAliases(id, req.Aliases)
+	if err != nil {
+		respondQuestionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":      id,
+		"aliases": aliases,
+		"message": "別解が更新されました",
+	})
+}
+
+// ReplaceQuestion 問題の置き換え（PUT。省略した項目はデフォルト値に戻る）
+func (qh *QuestionHandler) ReplaceQuestion(c *gin.Context) {
+	id, ok := questionID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Question        string   `json:"question" binding:"required"`
+		Answer          string   `json:"answer" binding:"required"`
+		AnswerTolerance *int     `json:"answer_tolerance"`
+		Aliases         []string `json:"aliases"`
+		Tags            []string `json:"tags"`
+		Category        string   `json:"category"`
+		Difficulty      string   `json:"difficulty"`
+		Revision        *int     `json:"revision"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	aliases, tags := req.Aliases, req.Tags
+	question, err := qh.questionService.UpdateQuestion(id, services.QuestionUpdate{
+		Question:             &req.Question,
+		Answer:               &req.Answer,
+		Category:             &req.Category,
+		Difficulty:           &req.Difficulty,
+		AnswerTolerance:      req.AnswerTolerance,
+		ClearAnswerTolerance: req.AnswerTolerance == nil,
+		Aliases:              &aliases,
+		Tags:                 &tags,
+		Revision:             req.Revision,
+	})
+	if err != nil {
+		respondQuestionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"question": question,
+		"message":  "問題が更新されました",
+	})
+}
+
+// UpdateQuestion 問題の部分更新（PATCH。指定した項目のみ変更し、answer_tolerance は null で自動に戻す）
+func (qh *QuestionHandler) UpdateQuestion(c *gin.Context) {
+	id, ok := questionID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Question        *string         `json:"question"`
+		Answer          *string         `json:"answer"`
+		AnswerTolerance json.RawMessage `json:"answer_tolerance"`
+		Aliases         *[]string       `json:"aliases"`
+		Tags            *[]string       `json:"tags"`
+		Category        *string         `json:"category"`
+		Difficulty      *string         `json:"difficulty"`
+		Revision        *int            `json:"revision"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	update := services.QuestionUpdate{
+		Question:   req.Question,
+		Answer:     req.Answer,
+		Aliases:    req.Aliases,
+		Tags:       req.Tags,
+		Category:   req.Category,
+		Difficulty: req.Difficulty,
+		Revision:   req.Revision,
+	}
+	if string(req.AnswerTolerance) == "null" {
+		update.ClearAnswerTolerance = true
+	} else if len(req.AnswerTolerance) > 0 {
+		var tolerance int
+		if err := json.Unmarshal(req.AnswerTolerance, &tolerance); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "answer_tolerance must be an integer or null"})
+			return
+		}
+		update.AnswerTolerance = &tolerance
+	}
+
+	question, err := qh.questionService.UpdateQuestion(id, update)
+	if err != nil {
+		respondQuestionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"question": question,
+		"message":  "問題が更新されました",
+	})
+}
+
+// DeleteQuestion 問題の論理削除
+func (qh *QuestionHandler) DeleteQuestion(c *gin.Context) {
+	id, ok := questionID(c)
+	if !ok {
+		return
+	}
+
+	if err := qh.questionService.DeleteQuestion(id); err != nil {
+		respondQuestionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "問題が削除されました"})
+}
+
+// RestoreQuestion 論理削除した問題を元に戻す
+func (qh *QuestionHandler) RestoreQuestion(c *gin.Context) {
+	id, ok := questionID(c)
+	if !ok {
+		return
+	}
+
+	question, err := qh.questionService.RestoreQuestion(id)
+	if err != nil {
+		respondQuestionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"question": question,
+		"message":  "問題が復元されました",
+	})
+}
+
+// GetQuestionRevisions 問題の編集履歴取得（古い順。最後が現在の版）
+func (qh *QuestionHandler) GetQuestionRevisions(c *gin.Context) {
+	id, ok := questionID(c)
+	if !ok {
+		return
+	}
+
+	revisions, err := qh.questionService.GetQuestionRevisions(id)
+	if err != nil {
+		respondQuestionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// questionID パスパラメータから問題IDを取得（不正な場合は400を返す）
+func questionID(c *gin.Context) (int, bool) {
 	var id int
-	if _, err := fmt.Sscanf(questionID, "%d", &id); err != nil {
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid question ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+// respondQuestionError 問題のエラーをHTTPステータスに変換
+func respondQuestionError(c *gin.Context, err error) {
+	var validationErr *services.QuestionValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "fields": validationErr.Fields})
+	case errors.Is(err, services.ErrQuestionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrQuestionRevisionConflict), errors.Is(err, services.ErrQuestionNotDeleted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// maxImportBodyBytes インポートで受け付けるファイルの最大サイズ
+const maxImportBodyBytes = 10 << 20
+
+// questionFormatContentTypes エクスポート時の Content-Type
+var questionFormatContentTypes = map[string]string{
+	services.QuestionFormatCSV:   "text/csv; charset=utf-8",
+	services.QuestionFormatJSON:  "application/json; charset=utf-8",
+	services.QuestionFormatJSONL: "application/x-ndjson; charset=utf-8",
+}
+
+// ImportQuestions 問題の一括インポート（CSV / JSON / JSONL）
+// ファイルはリクエストボディそのもの、または multipart の file フィールドで受け取る
+func (qh *QuestionHandler) ImportQuestions(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBodyBytes)
+
+	format := c.Query("format")
+	var body io.Reader = c.Request.Body
+	if c.ContentType() == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer file.Close()
+		body = file
+		if format == "" {
+			format = filepath.Ext(header.Filename)
+		}
+	} else if format == "" {
+		format = formatFromContentType(c.ContentType())
+	}
+
+	report, err := qh.questionService.ImportQuestions(body, format, c.Query("dry_run") == "true")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file must be at most %d bytes", maxImportBodyBytes)})
+		case errors.Is(err, services.ErrInvalidImport) && report != nil:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "report": report})
+		case errors.Is(err, services.ErrInvalidImport), errors.Is(err, services.ErrUnsupportedFormat):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	question, err := qh.questionService.GetQuestion(id)
+	message := "問題がインポートされました"
+	if report.DryRun {
+		message = "検証のみ行いました（登録していません）"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"report":  report,
+		"message": message,
+	})
+}
+
+// ExportQuestions 問題のエクスポート（カテゴリ・難易度で絞り込み可能）
+func (qh *QuestionHandler) ExportQuestions(c *gin.Context) {
+	format, err := services.ParseQuestionFormat(c.DefaultQuery("format", services.QuestionFormatJSON))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, question)
+	var buf bytes.Buffer
+	if _, err := qh.questionService.ExportQuestions(&buf, format, c.Query("category"), c.Query("difficulty")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="questions.%s"`, format))
+	c.Data(http.StatusOK, questionFormatContentTypes[format], buf.Bytes())
+}
+
+// formatFromContentType Content-Type からインポートするファイル形式を推定
+func formatFromContentType(contentType string) string {
+	switch contentType {
+	case "text/csv":
+		return services.QuestionFormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return services.QuestionFormatJSONL
+	case "application/json":
+		return services.QuestionFormatJSON
+	default:
+		return ""
+	}
 }
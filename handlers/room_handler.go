@@ -1,27 +1,43 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 
+	"quivra-backend/models"
 	"quivra-backend/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 type RoomHandler struct {
-	roomService *services.RoomService
+	roomService   *services.RoomService
+	authService   *services.AuthService
+	buzzService   *services.BuzzService
+	questionTimer *services.QuestionTimer
+	scoring       *services.ScoringService
+	moderator     PlayerModerator
 }
 
-func NewRoomHandler(roomService *services.RoomService) *RoomHandler {
-	return &RoomHandler{roomService: roomService}
+func NewRoomHandler(roomService *services.RoomService, authService *services.AuthService, buzzService *services.BuzzService, questionTimer *services.QuestionTimer, scoring *services.ScoringService, moderator PlayerModerator) *RoomHandler {
+	return &RoomHandler{
+		roomService:   roomService,
+		authService:   authService,
+		buzzService:   buzzService,
+		questionTimer: questionTimer,
+		scoring:       scoring,
+		moderator:     moderator,
+	}
 }
 
 // CreateRoom ルーム作成
 func (rh *RoomHandler) CreateRoom(c *gin.Context) {
 	var req struct {
-		Name        string `json:"name" binding:"required"`
-		IsPublic    bool   `json:"is_public"`
-		CreatorName string `json:"creator_name" binding:"required"`
+		Name        string              `json:"name" binding:"required"`
+		IsPublic    bool                `json:"is_public"`
+		CreatorName string              `json:"creator_name" binding:"required"`
+		Settings    models.RoomSettings `json:"settings"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -29,15 +45,30 @@ func (rh *RoomHandler) CreateRoom(c *gin.Context) {
 		return
 	}
 
-	room, err := rh.roomService.CreateRoom(req.Name, req.IsPublic, req.CreatorName)
+	room, err := rh.roomService.CreateRoom(req.Name, req.IsPublic, req.CreatorName, req.Settings)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidRoomSettings) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// 作成者（管理者）のセッショントークンを発行
+	token, expiresAt, err := rh.authService.IssueToken(room.ID, room.CreatedBy)
+	if err != nil {
+		log.Printf("Error issuing session token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue session token"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"roomId":  room.ID,
-		"message": "ルームが作成されました",
+		"roomId":    room.ID,
+		"playerId":  room.CreatedBy,
+		"token":     token,
+		"expiresAt": expiresAt,
+		"message":   "ルームが作成されました",
 	})
 }
 
@@ -58,7 +89,7 @@ func (rh *RoomHandler) GetRoom(c *gin.Context) {
 	c.JSON(http.StatusOK, room)
 }
 
-// JoinRoom ルーム参加（WebSocket経由で実装予定）
+// JoinRoom ルーム参加（発行したトークンで WebSocket に接続する）
 func (rh *RoomHandler) JoinRoom(c *gin.Context) {
 	var req struct {
 		RoomID     string `json:"roomId" binding:"required"`
@@ -70,15 +101,81 @@ func (rh *RoomHandler) JoinRoom(c *gin.Context) {
 		return
 	}
 
+	// 以前のトークン（失効済みでもよい）が付いていれば、参加禁止されたプレイヤーが名前を変えて参加し直すのを拒否する
+	if roomID, playerID, err := rh.authService.PreviousPlayer(bearerToken(c)); err == nil && roomID == req.RoomID {
+		if err := rh.roomService.CheckPlayerBanned(req.RoomID, playerID); err != nil {
+			if errors.Is(err, services.ErrPlayerBanned) {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	player, err := rh.roomService.AddPlayer(req.RoomID, req.PlayerName)
 	if err != nil {
+		if errors.Is(err, services.ErrPlayerNameTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrPlayerBanned) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	token, expiresAt, err := rh.authService.IssueToken(req.RoomID, player.ID)
+	if err != nil {
+		log.Printf("Error issuing session token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue session token"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"playerId": player.ID,
-		"message":  "ルームに参加しました",
+		"playerId":  player.ID,
+		"roomId":    req.RoomID,
+		"token":     token,
+		"expiresAt": expiresAt,
+		"message":   "ルームに参加しました",
+	})
+}
+
+// SpectateRoom ルームの観戦（プレイヤーとしては登録せず、発行したトークンで WebSocket に接続する）
+func (rh *RoomHandler) SpectateRoom(c *gin.Context) {
+	var req struct {
+		RoomID string `json:"roomId" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := rh.roomService.CheckSpectatable(req.RoomID); err != nil {
+		if errors.Is(err, services.ErrSpectatingDisabled) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, expiresAt, err := rh.authService.IssueSpectatorToken(req.RoomID)
+	if err != nil {
+		log.Printf("Error issuing spectator token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue session token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"roomId":    req.RoomID,
+		"role":      models.SessionRoleSpectator,
+		"token":     token,
+		"expiresAt": expiresAt,
+		"message":   "ルームの観戦を開始しました",
 	})
 }
 
@@ -114,6 +211,72 @@ func (rh *RoomHandler) GetRoomRanking(c *gin.Context) {
 	})
 }
 
+// GetTeamRanking チームのランキング（メンバー付き）取得
+func (rh *RoomHandler) GetTeamRanking(c *gin.Context) {
+	roomID := c.Param("roomId")
+	if roomID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "roomId is required"})
+		return
+	}
+
+	teams, err := rh.roomService.GetTeamRanking(roomID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"teams": teams,
+	})
+}
+
+// GetBuzzAudit 早押しの記録（生の時刻と補正後の時刻）取得（ルーム管理者のみ）
+func (rh *RoomHandler) GetBuzzAudit(c *gin.Context) {
+	roomID := c.Param("roomId")
+	if !rh.authorizeRoomAdmin(c, roomID) {
+		return
+	}
+
+	entries, err := rh.buzzService.GetBuzzAudit(roomID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"buzzes": entries,
+	})
+}
+
+// GetDisplayKey ビッグスクリーン表示用の接続キー取得（ルーム管理者のみ）
+func (rh *RoomHandler) GetDisplayKey(c *gin.Context) {
+	roomID := c.Param("roomId")
+	if !rh.authorizeRoomAdmin(c, roomID) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"roomId":     roomID,
+		"displayKey": rh.authService.DisplayKey(roomID),
+	})
+}
+
+// authorizeRoomAdmin Authorization ヘッダーのセッショントークンがルームの管理者のものか確認する
+func (rh *RoomHandler) authorizeRoomAdmin(c *gin.Context, roomID string) bool {
+	claims, err := rh.authService.Authenticate(bearerToken(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return false
+	}
+
+	isAdmin, err := rh.roomService.IsPlayerAdmin(roomID, claims.PlayerID)
+	if claims.RoomID != roomID || err != nil || !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin privileges required"})
+		return false
+	}
+	return true
+}
+
 // ResetAllData 全データリセット（管理者向け）
 func (rh *RoomHandler) ResetAllData(c *gin.Context) {
 	// 管理者権限チェック（簡単な認証としてAPIキーを使用）
@@ -123,6 +286,11 @@ func (rh *RoomHandler) ResetAllData(c *gin.Context) {
 		return
 	}
 
+	// 実行中のタイマーを全て停止し、メモリ上の回答キュー・早押し制限・連続正解数・誤答数を破棄
+	rh.questionTimer.CancelAll()
+	rh.buzzService.Reset()
+	rh.scoring.Reset()
+
 	// 全データをリセット
 	err := rh.roomService.ResetAllData()
 	if err != nil {
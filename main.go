@@ -1,11 +1,19 @@
 package main
 
 import (
+	"crypto/rand"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"time"
 
 	"quivra-backend/config"
 	"quivra-backend/database"
 	"quivra-backend/handlers"
+	"quivra-backend/repository"
+	"quivra-backend/repository/memory"
+	"quivra-backend/repository/mysql"
 	"quivra-backend/services"
 	"quivra-backend/websocket"
 
@@ -16,32 +24,75 @@ func main() {
 	// 設定を読み込み
 	cfg := config.LoadConfig()
 
-	// データベース接続
-	db, err := database.NewDB()
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+	// サブコマンド: マイグレーションのみ実行して終了
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	// サブコマンド: 問題の一括インポート・エクスポートを実行して終了
+	if len(os.Args) > 1 && os.Args[1] == "import-questions" {
+		if err := runImportQuestions(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Question import failed: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export-questions" {
+		if err := runExportQuestions(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Question export failed: %v", err)
+		}
+		return
 	}
-	defer db.Close()
 
-	// データベースはDockerコンテナの初期化時に自動でセットアップされます
+	// ストレージ接続
+	store, err := openStore(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open storage: %v", err)
+	}
+	defer store.Close()
 
 	// サービスを初期化
-	roomService := services.NewRoomService(db)
-	questionService := services.NewQuestionService(db)
-	gameService := services.NewGameService(db)
-	buzzManager := services.NewBuzzManager()
-	buzzQueueService := services.NewBuzzQueueService(db)
+	roomService := services.NewRoomService(store)
+	questionService := services.NewQuestionService(store)
+	packService := services.NewPackService(store)
+	gameService := services.NewGameService(store)
+	buzzService := services.NewBuzzService(store, time.Duration(cfg.BuzzMaxCompensationMs)*time.Millisecond)
+	go buzzService.Run()
+	defer buzzService.Close()
+
+	// インメモリストレージは起動のたびに空になるため、サンプル問題を投入する
+	if cfg.StorageDriver == "memory" {
+		if err := roomService.SeedSampleData(); err != nil {
+			log.Fatalf("Failed to seed sample data: %v", err)
+		}
+	}
+	authService := services.NewAuthService(store, sessionSecret(cfg), time.Duration(cfg.SessionTTLHours)*time.Hour, cfg.AdminAPIKey)
+	if cfg.AdminAPIKey == "" {
+		log.Println("ADMIN_API_KEY is not set; question and pack management endpoints are disabled")
+	}
+	presenceTracker := services.NewPresenceTracker()
+	scoringService := services.NewScoringService()
+	questionTimer := services.NewQuestionTimer(map[string]time.Duration{
+		"easy":   time.Duration(cfg.TimerEasySeconds) * time.Second,
+		"medium": time.Duration(cfg.TimerMediumSeconds) * time.Second,
+		"hard":   time.Duration(cfg.TimerHardSeconds) * time.Second,
+	})
 
 	// WebSocket Hubを初期化
 	hub := websocket.NewHub()
 	go hub.Run()
 
 	// WebSocketハンドラーを初期化
-	wsHandler := websocket.NewWSHandler(hub, roomService, questionService, gameService, buzzManager, buzzQueueService)
+	wsHandler := websocket.NewWSHandler(hub, roomService, questionService, gameService, buzzService, questionTimer, authService, presenceTracker, scoringService)
 
 	// HTTPハンドラーを初期化
-	roomHandler := handlers.NewRoomHandler(roomService)
-	questionHandler := handlers.NewQuestionHandler(questionService)
+	roomHandler := handlers.NewRoomHandler(roomService, authService, buzzService, questionTimer, scoringService, wsHandler)
+	questionHandler := handlers.NewQuestionHandler(questionService, authService)
+	packHandler := handlers.NewPackHandler(packService)
+	authHandler := handlers.NewAuthHandler(authService)
+	requireOperator := handlers.RequireOperator(authService)
 
 	// Ginルーターを設定
 	router := gin.Default()
@@ -49,7 +100,7 @@ func main() {
 	// CORS設定
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		if c.Request.Method == "OPTIONS" {
@@ -68,19 +119,47 @@ func main() {
 		api.GET("/rooms", roomHandler.GetPublicRooms)
 		api.GET("/rooms/:roomId", roomHandler.GetRoom)
 		api.GET("/rooms/:roomId/ranking", roomHandler.GetRoomRanking)
+		api.GET("/rooms/:roomId/teams", roomHandler.GetTeamRanking)
+		api.GET("/rooms/:roomId/buzz-audit", roomHandler.GetBuzzAudit)
+		api.GET("/rooms/:roomId/display-key", roomHandler.GetDisplayKey)
+		api.POST("/rooms/:roomId/players/:playerId/kick", roomHandler.KickPlayer)
+		api.POST("/rooms/:roomId/players/:playerId/ban", roomHandler.BanPlayer)
+		api.POST("/rooms/:roomId/players/:playerId/mute", roomHandler.MutePlayer)
+		api.POST("/rooms/:roomId/bans", roomHandler.BanName)
+		api.GET("/rooms/:roomId/bans", roomHandler.GetRoomBans)
 		api.POST("/rooms/join", roomHandler.JoinRoom)
+		api.POST("/rooms/spectate", roomHandler.SpectateRoom)
+
+		// セッション関連
+		api.POST("/sessions/revoke", authHandler.RevokeSession)
 
 		// 管理者向けエンドポイント
 		api.POST("/admin/reset", roomHandler.ResetAllData)
 
 		// 問題関連
-		api.POST("/questions", questionHandler.CreateQuestion)
+		api.POST("/questions", requireOperator, questionHandler.CreateQuestion)
 		api.GET("/questions", questionHandler.GetQuestions)
+		api.POST("/questions/import", requireOperator, questionHandler.ImportQuestions)
+		api.GET("/questions/export", requireOperator, questionHandler.ExportQuestions)
 		api.GET("/questions/:id", questionHandler.GetQuestion)
+		api.PUT("/questions/:id", requireOperator, questionHandler.ReplaceQuestion)
+		api.PATCH("/questions/:id", requireOperator, questionHandler.UpdateQuestion)
+		api.DELETE("/questions/:id", requireOperator, questionHandler.DeleteQuestion)
+		api.POST("/questions/:id/restore", requireOperator, questionHandler.RestoreQuestion)
+		api.GET("/questions/:id/revisions", requireOperator, questionHandler.GetQuestionRevisions)
+		api.PUT("/questions/:id/aliases", requireOperator, questionHandler.SetAliases)
+
+		// 問題セット関連
+		api.POST("/packs", requireOperator, packHandler.CreatePack)
+		api.GET("/packs", packHandler.GetPacks)
+		api.GET("/packs/:id", packHandler.GetPack)
+		api.PUT("/packs/:id", requireOperator, packHandler.UpdatePack)
+		api.DELETE("/packs/:id", requireOperator, packHandler.DeletePack)
 	}
 
 	// WebSocket エンドポイント
 	router.GET("/ws", wsHandler.HandleWebSocket)
+	router.GET("/ws/display", wsHandler.HandleDisplay)
 
 	// サーバーを起動
 	log.Printf("Server starting on port %s", cfg.Port)
@@ -88,3 +167,120 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// openStore 設定に応じてストレージを選択
+func openStore(cfg *config.Config) (repository.Store, error) {
+	switch cfg.StorageDriver {
+	case "mysql":
+		db, err := database.NewDB()
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to database: %w", err)
+		}
+		if cfg.MigrateOnStart {
+			if err := migrateUp(db); err != nil {
+				db.Close()
+				return nil, err
+			}
+		}
+		return mysql.NewStore(db), nil
+	case "memory":
+		log.Println("Using in-memory storage (data is lost on restart)")
+		return memory.NewStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", cfg.StorageDriver)
+	}
+}
+
+// sessionSecret トークンの署名鍵を取得（未設定の場合は起動ごとにランダム生成）
+func sessionSecret(cfg *config.Config) []byte {
+	if cfg.SessionSecret != "" {
+		return []byte(cfg.SessionSecret)
+	}
+
+	log.Println("SESSION_SECRET is not set; using a random key (session tokens are invalidated on restart)")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalf("Failed to generate session secret: %v", err)
+	}
+	return secret
+}
+
+// migrateUp 未適用のマイグレーションを適用
+func migrateUp(db *database.DB) error {
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up()
+	if err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	if len(applied) == 0 {
+		log.Println("Database schema is up to date")
+	}
+	return nil
+}
+
+// runMigrate migrate サブコマンド（up / down [N] / status）
+func runMigrate(args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	db, err := database.NewDB()
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	switch command {
+	case "up":
+		return migrateUp(db)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps: %s", args[1])
+			}
+		}
+		migrator, err := database.NewMigrator(db)
+		if err != nil {
+			return err
+		}
+		reverted, err := migrator.Down(steps)
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			log.Println("No migrations to revert")
+		}
+		return nil
+
+	case "status":
+		migrator, err := database.NewMigrator(db)
+		if err != nil {
+			return err
+		}
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format(time.RFC3339)
+			}
+			if status.ChecksumMismatch {
+				state += " (checksum mismatch)"
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, state)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate command: %s (use up, down [N] or status)", command)
+	}
+}
@@ -5,13 +5,88 @@ import (
 )
 
 type Room struct {
-	ID        string    `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	Status    string    `json:"status" db:"status"`
-	IsPublic  bool      `json:"is_public" db:"is_public"`
-	CreatedBy string    `json:"created_by" db:"created_by"`
-	Players   []Player  `json:"players,omitempty"`
+	ID        string       `json:"id" db:"id"`
+	Name      string       `json:"name" db:"name"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+	Status    string       `json:"status" db:"status"`
+	IsPublic  bool         `json:"is_public" db:"is_public"`
+	CreatedBy string       `json:"created_by" db:"created_by"`
+	Settings  RoomSettings `json:"settings" db:"settings"`
+	Players   []Player     `json:"players,omitempty"`
+}
+
+// RoomSettings ルーム作成時に指定するゲーム設定
+type RoomSettings struct {
+	Timer      TimerSettings     `json:"timer"`
+	Teams      TeamSettings      `json:"teams"`
+	Scoring    ScoringSettings   `json:"scoring"`
+	Lockout    LockoutSettings   `json:"lockout"`
+	Spectators SpectatorSettings `json:"spectators"`
+}
+
+// TimerSettings 1問あたりの制限時間（秒）。0の場合はサーバーのデフォルト値を使用
+type TimerSettings struct {
+	Disabled bool `json:"disabled,omitempty"`
+	Easy     int  `json:"easy,omitempty"`
+	Medium   int  `json:"medium,omitempty"`
+	Hard     int  `json:"hard,omitempty"`
+}
+
+// SpectatorSettings 観戦の設定
+type SpectatorSettings struct {
+	Disabled bool `json:"disabled,omitempty"`
+	Max      int  `json:"max,omitempty"` // 同時に観戦できる接続数。0の場合はサーバーのデフォルト値を使用
+}
+
+// TeamSettings チーム戦の設定
+type TeamSettings struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// OneBuzzerPerTeam 回答キューに同じチームのメンバーは1人までしか入れない
+	OneBuzzerPerTeam bool `json:"one_buzzer_per_team,omitempty"`
+}
+
+// 得点モード
+const (
+	ScoringModeFirstCorrect = "first_correct" // 最初の正解者のみ得点し、次の問題へ進む
+	ScoringModeAllCorrect   = "all_correct"   // 制限時間内の正解者全員が得点する（1人1回まで）
+)
+
+// ScoringSettings 得点ルール。0・未指定の項目はサーバーのデフォルト値を使用
+type ScoringSettings struct {
+	Mode   string `json:"mode,omitempty"` // first_correct（デフォルト）/ all_correct
+	Easy   int    `json:"easy,omitempty"`
+	Medium int    `json:"medium,omitempty"`
+	Hard   int    `json:"hard,omitempty"`
+	// TimeBonus 回答までの時間に応じたボーナス。null でデフォルト、空配列でボーナスなし
+	TimeBonus []TimeBonusStep `json:"time_bonus"`
+	// WrongPenalty 誤答1回あたりの減点
+	WrongPenalty int `json:"wrong_penalty,omitempty"`
+	// StreakBonus 連続正解1回あたりの加点（2問連続で1回分）。StreakBonusMax で上限を指定
+	StreakBonus    int `json:"streak_bonus,omitempty"`
+	StreakBonusMax int `json:"streak_bonus_max,omitempty"`
+	// LockoutAfterMisses マッチ中にこの回数誤答すると、そのマッチでは回答できない
+	LockoutAfterMisses int `json:"lockout_after_misses,omitempty"`
+}
+
+// TimeBonusStep 出題から Within 秒以内に正解すると Bonus 点を加算（条件を満たす最初の段を適用）
+type TimeBonusStep struct {
+	Within int `json:"within"`
+	Bonus  int `json:"bonus"`
+}
+
+// 誤答後の早押し制限の種類
+const (
+	LockoutModeNone      = ""          // 制限なし（すぐに押し直せる）
+	LockoutModeQuestion  = "question"  // 同じ問題では押し直せない
+	LockoutModeSeconds   = "seconds"   // Seconds 秒間押せない
+	LockoutModeQuestions = "questions" // 同じ問題と、続く Questions 問で押せない
+)
+
+// LockoutSettings 誤答したプレイヤーの早押し制限
+type LockoutSettings struct {
+	Mode      string `json:"mode,omitempty"`
+	Seconds   int    `json:"seconds,omitempty"`
+	Questions int    `json:"questions,omitempty"`
 }
 
 type Player struct {
@@ -21,33 +96,160 @@ type Player struct {
 	Score    int       `json:"score" db:"score"`
 	JoinedAt time.Time `json:"joined_at" db:"joined_at"`
 	IsAdmin  bool      `json:"is_admin" db:"is_admin"`
+	TeamID   *string   `json:"team_id" db:"team_id"`
+	Muted    bool      `json:"muted" db:"muted"`          // 管理者によるミュート中（早押し・回答できない）
+	Presence string    `json:"presence,omitempty" db:"-"` // online / away / offline（WebSocket の接続状態から付与）
+}
+
+// RoomBan ルームへの参加禁止（ルームが存在する間有効）
+type RoomBan struct {
+	RoomID    string    `json:"room_id" db:"room_id"`
+	Name      string    `json:"name" db:"name"`           // 小文字に正規化したプレイヤー名
+	PlayerID  *string   `json:"player_id" db:"player_id"` // プレイヤーを指定して禁止した場合
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type Team struct {
+	ID        string    `json:"id" db:"id"`
+	RoomID    string    `json:"room_id" db:"room_id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
 type Question struct {
-	ID         int       `json:"id" db:"id"`
-	Question   string    `json:"question" db:"question"`
-	Answer     string    `json:"answer" db:"answer"`
-	Category   string    `json:"category" db:"category"`
-	Difficulty string    `json:"difficulty" db:"difficulty"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	ID              int        `json:"id" db:"id"`
+	Question        string     `json:"question" db:"question"`
+	Answer          string     `json:"answer" db:"answer"`
+	AnswerTolerance *int       `json:"answer_tolerance" db:"answer_tolerance"`
+	Aliases         []string   `json:"aliases"`
+	Category        string     `json:"category" db:"category"`
+	Difficulty      string     `json:"difficulty" db:"difficulty"`
+	Tags            []string   `json:"tags"`                   // 分類用のタグ（版管理の対象外）
+	Revision        int        `json:"revision" db:"revision"` // 編集のたびに1つ増える版番号
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty" db:"deleted_at"` // 論理削除した日時（削除していなければ nil）
 }
 
-type GameSession struct {
+// PublicQuestion 正解（答え・別解・許容編集距離）を含まない問題
+type PublicQuestion struct {
+	ID         int        `json:"id"`
+	Question   string     `json:"question"`
+	Category   string     `json:"category"`
+	Difficulty string     `json:"difficulty"`
+	Tags       []string   `json:"tags"`
+	Revision   int        `json:"revision"`
+	CreatedAt  time.Time  `json:"created_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Public 正解を取り除いた問題を返す
+func (q *Question) Public() PublicQuestion {
+	return PublicQuestion{
+		ID:         q.ID,
+		Question:   q.Question,
+		Category:   q.Category,
+		Difficulty: q.Difficulty,
+		Tags:       q.Tags,
+		Revision:   q.Revision,
+		CreatedAt:  q.CreatedAt,
+		DeletedAt:  q.DeletedAt,
+	}
+}
+
+// QuestionAnswer 問題の正解（答え・別解・許容編集距離）
+type QuestionAnswer struct {
+	Answer          string   `json:"answer"`
+	AnswerTolerance *int     `json:"answer_tolerance"`
+	Aliases         []string `json:"aliases"`
+}
+
+// Solution 問題の正解を返す
+func (q *Question) Solution() QuestionAnswer {
+	return QuestionAnswer{
+		Answer:          q.Answer,
+		AnswerTolerance: q.AnswerTolerance,
+		Aliases:         q.Aliases,
+	}
+}
+
+// QuestionRevision 問題のある版の内容
+type QuestionRevision struct {
+	QuestionID      int        `json:"question_id" db:"question_id"`
+	Revision        int        `json:"revision" db:"revision"`
+	Question        string     `json:"question" db:"question"`
+	Answer          string     `json:"answer" db:"answer"`
+	AnswerTolerance *int       `json:"answer_tolerance" db:"answer_tolerance"`
+	Aliases         []string   `json:"aliases" db:"aliases"`
+	Category        string     `json:"category" db:"category"`
+	Difficulty      string     `json:"difficulty" db:"difficulty"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`             // この版になった日時
+	ReplacedAt      *time.Time `json:"replaced_at,omitempty" db:"replaced_at"` // 次の版に置き換えられた日時（最新版は nil）
+}
+
+// QuestionPack 主催者が用意する問題セット（出題順は登録順、または shuffle でシャッフル）
+type QuestionPack struct {
+	ID          int       `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	Shuffle     bool      `json:"shuffle" db:"shuffle"`
+	QuestionIDs []int     `json:"question_ids"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type Match struct {
 	ID             string     `json:"id" db:"id"`
 	RoomID         string     `json:"room_id" db:"room_id"`
-	QuestionID     *int       `json:"question_id" db:"question_id"`
+	TotalQuestions int        `json:"total_questions" db:"total_questions"`
+	CurrentNumber  int        `json:"current_number" db:"current_number"`
+	Status         string     `json:"status" db:"status"`
 	StartedAt      time.Time  `json:"started_at" db:"started_at"`
 	EndedAt        *time.Time `json:"ended_at" db:"ended_at"`
-	Status         string     `json:"status" db:"status"`
-	BuzzedPlayerID *string    `json:"buzzed_player_id" db:"buzzed_player_id"`
+	QuestionIDs    []int      `json:"-"` // マッチ開始時に決めた出題順（作成時のみ設定）
+}
+
+type GameSession struct {
+	ID               string     `json:"id" db:"id"`
+	RoomID           string     `json:"room_id" db:"room_id"`
+	MatchID          *string    `json:"match_id" db:"match_id"`
+	QuestionNumber   int        `json:"question_number" db:"question_number"`
+	QuestionID       *int       `json:"question_id" db:"question_id"`
+	QuestionRevision *int       `json:"question_revision" db:"question_revision"` // 出題したときの問題の版
+	StartedAt        time.Time  `json:"started_at" db:"started_at"`
+	EndedAt          *time.Time `json:"ended_at" db:"ended_at"`
+	Status           string     `json:"status" db:"status"`
+	BuzzedPlayerID   *string    `json:"buzzed_player_id" db:"buzzed_player_id"`
+}
+
+// GameSessionChange 状態の遷移と同時に更新する項目（nil は変更しない）
+type GameSessionChange struct {
+	QuestionID       *int
+	QuestionRevision *int
+	BuzzedPlayerID   *string
+	StartedAt        *time.Time
+	EndedAt          *time.Time
 }
 
 type BuzzQueue struct {
-	ID       string    `json:"id" db:"id"`
-	RoomID   string    `json:"room_id" db:"room_id"`
-	PlayerID string    `json:"player_id" db:"player_id"`
-	BuzzedAt time.Time `json:"buzzed_at" db:"buzzed_at"`
-	IsActive bool      `json:"is_active" db:"is_active"`
+	ID        string    `json:"id" db:"id"`
+	RoomID    string    `json:"room_id" db:"room_id"`
+	PlayerID  string    `json:"player_id" db:"player_id"`
+	BuzzedAt  time.Time `json:"buzzed_at" db:"buzzed_at"`   // サーバーが受信した時刻
+	PressedAt time.Time `json:"pressed_at" db:"pressed_at"` // 遅延補正後の押下時刻（キューはこの順）
+	IsActive  bool      `json:"is_active" db:"is_active"`
+
+	// 遅延補正の根拠（異議があった場合の確認用）
+	ClientPressedAt *int64 `json:"client_pressed_at" db:"client_pressed_at"` // クライアントの時計での押下時刻（Unix ミリ秒）
+	ClientSentAt    *int64 `json:"client_sent_at" db:"client_sent_at"`       // クライアントの時計での送信時刻（Unix ミリ秒）
+	RTTMillis       int    `json:"rtt_ms" db:"rtt_ms"`                       // 受信時点の接続の往復遅延の推定値
+}
+
+// BuzzAuditEntry 早押しの記録（生の時刻と補正後の時刻）
+type BuzzAuditEntry struct {
+	BuzzQueue
+	Name               string `json:"name"`
+	CompensationMillis int64  `json:"compensation_ms"` // 受信時刻から差し引いた時間
 }
 
 type RoomRanking struct {
@@ -56,3 +258,49 @@ type RoomRanking struct {
 	Score    int    `json:"score"`
 	Rank     int    `json:"rank"`
 }
+
+// TeamRanking チームのランキング（スコアはメンバーの合計）
+type TeamRanking struct {
+	TeamID  string        `json:"team_id"`
+	Name    string        `json:"name"`
+	Score   int           `json:"score"`
+	Rank    int           `json:"rank"`
+	Members []RoomRanking `json:"members"`
+}
+
+type MatchQuestionResult struct {
+	QuestionNumber int     `json:"question_number"`
+	QuestionID     *int    `json:"question_id"`
+	Question       string  `json:"question"`
+	CorrectAnswer  string  `json:"correct_answer"`
+	Status         string  `json:"status"`
+	AnsweredBy     *string `json:"answered_by"`
+	AnsweredByName string  `json:"answered_by_name,omitempty"`
+}
+
+type MatchSummary struct {
+	MatchID        string                `json:"match_id"`
+	RoomID         string                `json:"room_id"`
+	TotalQuestions int                   `json:"total_questions"`
+	AskedQuestions int                   `json:"asked_questions"`
+	Results        []MatchQuestionResult `json:"results"`
+	Ranking        []RoomRanking         `json:"ranking"`
+	TeamRanking    []TeamRanking         `json:"team_ranking,omitempty"`
+}
+
+// セッショントークンの種類
+const (
+	SessionRolePlayer    = "player"
+	SessionRoleSpectator = "spectator" // 観戦者（プレイヤーとして登録しない）
+)
+
+// PlayerSession プレイヤー・観戦者に発行したセッショントークンの記録（失効管理用）
+type PlayerSession struct {
+	ID        string     `json:"id" db:"id"`
+	PlayerID  string     `json:"player_id" db:"player_id"` // 観戦者の場合は空
+	RoomID    string     `json:"room_id" db:"room_id"`
+	Role      string     `json:"role" db:"role"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at" db:"revoked_at"`
+}
@@ -6,6 +6,7 @@ import "time"
 type WSMessage struct {
 	Event string      `json:"event"`
 	Data  interface{} `json:"data"`
+	Seq   int64       `json:"seq,omitempty"` // ルーム宛てイベントの通し番号（再接続時の再送に使用）
 }
 
 // クライアント → サーバー イベント
@@ -15,7 +16,9 @@ type JoinRoomData struct {
 }
 
 type BuzzInData struct {
-	RoomID string `json:"roomId"`
+	RoomID    string `json:"roomId"`
+	PressedAt *int64 `json:"pressedAt,omitempty"` // クライアントの時計でボタンを押した時刻（Unix ミリ秒）
+	SentAt    *int64 `json:"sentAt,omitempty"`    // クライアントの時計で送信した時刻（Unix ミリ秒）
 }
 
 type SubmitAnswerData struct {
@@ -23,16 +26,197 @@ type SubmitAnswerData struct {
 	Answer string `json:"answer"`
 }
 
-type StartGameData struct {
+type SetPresenceData struct {
 	RoomID string `json:"roomId"`
+	Status string `json:"status"` // online / away
+}
+
+type StartGameData struct {
+	RoomID        string         `json:"roomId"`
+	QuestionCount int            `json:"questionCount"`
+	PackID        *int           `json:"packId,omitempty"`        // 出題する問題セット（省略時は問題バンク全体）
+	Categories    []string       `json:"categories,omitempty"`    // 出題するカテゴリ（省略時はすべて）
+	DifficultyMix map[string]int `json:"difficultyMix,omitempty"` // 難易度ごとの出題の比率（例: {"easy": 3, "medium": 5, "hard": 2}）
+}
+
+type NextQuestionData struct {
+	RoomID string `json:"room_id"`
+}
+
+type DeleteRoomData struct {
+	RoomID string `json:"room_id"`
+}
+
+type CreateTeamData struct {
+	RoomID string `json:"room_id"`
+	Name   string `json:"name"`
+}
+
+type DeleteTeamData struct {
+	RoomID string `json:"room_id"`
+	TeamID string `json:"team_id"`
+}
+
+type AssignTeamData struct {
+	RoomID   string  `json:"room_id"`
+	PlayerID string  `json:"player_id"`
+	TeamID   *string `json:"team_id"` // null で無所属
+}
+
+type AutoBalanceTeamsData struct {
+	RoomID    string `json:"room_id"`
+	TeamCount int    `json:"team_count"` // 既存のチーム数より多ければ不足分を作成
+}
+
+type KickPlayerData struct {
+	RoomID   string `json:"room_id"`
+	PlayerID string `json:"player_id"`
+}
+
+type BanPlayerData struct {
+	RoomID   string `json:"room_id"`
+	PlayerID string `json:"player_id"` // player_id と name のどちらかを指定
+	Name     string `json:"name"`      // 参加していない名前も禁止できる
+}
+
+type MutePlayerData struct {
+	RoomID   string `json:"room_id"`
+	PlayerID string `json:"player_id"`
+	Muted    *bool  `json:"muted"` // 省略時は true（false でミュート解除）
 }
 
 // サーバー → クライアント イベント
 type RoomUpdatedData struct {
-	Players         []Player  `json:"players"`
-	GameState       string    `json:"gameState"`
-	CurrentQuestion *Question `json:"currentQuestion,omitempty"`
-	CanBuzz         bool      `json:"canBuzz"`
+	Players         []Player        `json:"players"`
+	GameState       string          `json:"gameState"`
+	CurrentQuestion *PublicQuestion `json:"currentQuestion,omitempty"`
+	CurrentAnswer   *QuestionAnswer `json:"currentAnswer,omitempty"` // 管理者に送る場合のみ
+	CanBuzz         bool            `json:"canBuzz"`
+	BuzzState       string          `json:"buzzState"` // 早押しの状態（idle / open / buzzed / judging / closed）
+	QuestionNumber  int             `json:"questionNumber,omitempty"`
+	TotalQuestions  int             `json:"totalQuestions,omitempty"`
+	TimeRemaining   int             `json:"timeRemaining,omitempty"`
+	Spectators      int             `json:"spectators"`      // 観戦中の接続数
+	Teams           []TeamRanking   `json:"teams,omitempty"` // チーム戦のときのみ
+}
+
+// Redacted 正解を伏せたプレイヤー向けのルーム状態を返す
+func (d RoomUpdatedData) Redacted() RoomUpdatedData {
+	d.CurrentAnswer = nil
+	return d
+}
+
+// QueueEntry 回答キューの1件（プレイヤー名付き）
+type QueueEntry struct {
+	PlayerID  string    `json:"player_id"`
+	Name      string    `json:"name"`
+	BuzzedAt  time.Time `json:"buzzed_at"`
+	PressedAt time.Time `json:"pressed_at"` // 遅延補正後の押下時刻
+}
+
+// display-cue の演出の種類
+const (
+	DisplayCueQuestion    = "question"    // 新しい問題の出題
+	DisplayCueReveal      = "reveal"      // 正解の公開
+	DisplayCueLeaderboard = "leaderboard" // ランキングの表示
+)
+
+// DisplayStateData 表示用接続に送るルームの状態（room-updated に残り時間と早押しの順位を加えたもの）
+type DisplayStateData struct {
+	Room        RoomUpdatedData    `json:"room"`
+	RemainingMs int64              `json:"remainingMs,omitempty"` // 制限時間の残り（ミリ秒）
+	BuzzOrder   []DisplayBuzzEntry `json:"buzzOrder"`
+}
+
+// DisplayBuzzEntry 回答キューの順位と、先頭のプレイヤーとの差（遅延補正後の押下時刻で比較）
+type DisplayBuzzEntry struct {
+	QueueEntry
+	Position int   `json:"position"`
+	BehindMs int64 `json:"behindMs"`
+}
+
+// DisplayCueData 表示用接続に送る演出のきっかけ
+type DisplayCueData struct {
+	Cue           string        `json:"cue"`
+	QuestionID    int           `json:"questionId,omitempty"`
+	CorrectAnswer string        `json:"correctAnswer,omitempty"` // reveal のみ
+	PlayerID      string        `json:"playerId,omitempty"`      // reveal の正解者（時間切れでは空）
+	Ranking       []RoomRanking `json:"ranking,omitempty"`       // leaderboard のみ
+	Final         bool          `json:"final,omitempty"`         // マッチ・ゲーム終了時の leaderboard
+}
+
+// プレイヤーをルームから外した理由（player-removed・kicked の action）
+const (
+	ModerationKick = "kick"
+	ModerationBan  = "ban"
+)
+
+// PlayerRemovedData 管理者がプレイヤーをルームから外した（ルーム全体に送信）
+type PlayerRemovedData struct {
+	PlayerID string `json:"player_id"`
+	Name     string `json:"name"`
+	Action   string `json:"action"`
+}
+
+// KickedData ルームから外されたプレイヤーの接続に送る最後のイベント（送信後に切断する）
+type KickedData struct {
+	Action  string `json:"action"`
+	Message string `json:"message"`
+}
+
+// PlayerMutedData 管理者がプレイヤーのミュートを切り替えた
+type PlayerMutedData struct {
+	PlayerID string `json:"player_id"`
+	Muted    bool   `json:"muted"`
+}
+
+// StateSnapshotData 再送できないほど切断が長かった場合に送る現在の状態
+type StateSnapshotData struct {
+	Seq   int64           `json:"seq"`
+	Room  RoomUpdatedData `json:"room"`
+	Queue []QueueEntry    `json:"queue"`
+}
+
+// ResumedData 取りこぼしたイベントの再送完了
+type ResumedData struct {
+	Replayed int   `json:"replayed"`
+	Seq      int64 `json:"seq"`
+}
+
+type PresenceUpdatedData struct {
+	PlayerID string `json:"playerId"`
+	Status   string `json:"status"`
+}
+
+type TimerTickData struct {
+	QuestionID int `json:"questionId"`
+	Remaining  int `json:"remaining"`
+	Total      int `json:"total"`
+}
+
+type TimeUpData struct {
+	QuestionID    int    `json:"questionId"`
+	CorrectAnswer string `json:"correctAnswer"`
+}
+
+// buzz-rejected の理由
+const (
+	BuzzRejectNotAccepting    = "not_accepting"     // 早押しを受け付けていない
+	BuzzRejectLockedOut       = "locked_out"        // 誤答による一時的な制限中
+	BuzzRejectTooManyMisses   = "too_many_misses"   // 誤答回数の上限に達した（マッチ終了まで）
+	BuzzRejectAlreadyAnswered = "already_answered"  // 全員正解モードで回答済み
+	BuzzRejectAlreadyInQueue  = "already_in_queue"  // 既に回答キューにいる
+	BuzzRejectTeammateInQueue = "teammate_in_queue" // チームメイトが回答キューにいる
+	BuzzRejectMuted           = "muted"             // 管理者にミュートされている
+	BuzzRejectInternalError   = "internal_error"
+)
+
+// BuzzRejectedData 早押しを受け付けなかった理由（本人にのみ送信）
+type BuzzRejectedData struct {
+	Reason             string `json:"reason"`
+	Message            string `json:"message"`
+	RetryAfter         int    `json:"retryAfter,omitempty"`         // 秒単位の制限中の残り時間
+	QuestionsRemaining int    `json:"questionsRemaining,omitempty"` // 制限が解除されるまでの問題数
 }
 
 type BuzzResultData struct {
@@ -41,15 +225,19 @@ type BuzzResultData struct {
 }
 
 type QuestionResultData struct {
-	Correct       bool   `json:"correct"`
-	CorrectAnswer string `json:"correctAnswer"`
-	Points        int    `json:"points"`
+	Correct         bool    `json:"correct"`
+	CorrectAnswer   string  `json:"correctAnswer"`
+	Points          int     `json:"points"`
+	PlayerID        string  `json:"playerId"`
+	SubmittedAnswer string  `json:"submittedAnswer"`
+	Confidence      float64 `json:"confidence"`
+	Streak          int     `json:"streak,omitempty"` // 連続正解数
 }
 
-// 早押し状態管理
-type BuzzState struct {
-	CanBuzz    bool      `json:"canBuzz"`
-	BuzzedBy   string    `json:"buzzedBy"`
-	BuzzedAt   time.Time `json:"buzzedAt"`
-	QuestionID int       `json:"questionId"`
+// BuzzStateChangedData 早押しの状態の変化（idle / open / buzzed / judging / closed）
+type BuzzStateChangedData struct {
+	State      string `json:"state"`
+	Previous   string `json:"previous"`
+	QuestionID int    `json:"questionId,omitempty"`
+	Answerer   string `json:"answerer,omitempty"` // 回答権のあるキューの先頭のプレイヤー（buzzed・judging のみ）
 }
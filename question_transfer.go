@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"quivra-backend/config"
+	"quivra-backend/services"
+)
+
+// runImportQuestions import-questions サブコマンド：CSV / JSON / JSONL の問題ファイルを一括登録する
+// ファイルを省略するか "-" を指定すると標準入力から読み込む。結果は JSON で標準出力に書き出す
+func runImportQuestions(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("import-questions", flag.ContinueOnError)
+	format := flags.String("format", "", "ファイル形式（csv / json / jsonl。省略時は拡張子から判定）")
+	dryRun := flags.Bool("dry-run", false, "検証のみ行い、登録しない")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var input io.Reader = os.Stdin
+	if path := flags.Arg(0); path != "" && path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+		if *format == "" {
+			*format = filepath.Ext(path)
+		}
+	}
+	if *format == "" {
+		return fmt.Errorf("-format is required when reading from standard input")
+	}
+
+	store, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	report, err := services.NewQuestionService(store).ImportQuestions(input, *format, *dryRun)
+	if report != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if encodeErr := encoder.Encode(report); encodeErr != nil {
+			return encodeErr
+		}
+	}
+	if errors.Is(err, services.ErrInvalidImport) && report != nil {
+		return fmt.Errorf("%d invalid rows; nothing was imported", len(report.Errors))
+	}
+	return err
+}
+
+// runExportQuestions export-questions サブコマンド：問題を CSV / JSON / JSONL で書き出す
+func runExportQuestions(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("export-questions", flag.ContinueOnError)
+	format := flags.String("format", services.QuestionFormatJSON, "ファイル形式（csv / json / jsonl）")
+	category := flags.String("category", "", "カテゴリで絞り込む")
+	difficulty := flags.String("difficulty", "", "難易度で絞り込む")
+	output := flags.String("o", "", "出力先ファイル（省略時は標準出力）")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	count, err := services.NewQuestionService(store).ExportQuestions(out, *format, *category, *difficulty)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d questions\n", count)
+	return nil
+}
@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+
+	"quivra-backend/models"
+)
+
+type buzzQueueRepository struct {
+	s *Store
+}
+
+// AddToQueue 回答キューに追加
+func (r *buzzQueueRepository) AddToQueue(entry *models.BuzzQueue) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored := *entry
+	r.s.buzzQueue = append(r.s.buzzQueue, &stored)
+	return nil
+}
+
+// GetQueue ルームの回答キューを取得（補正後の押下時刻順）
+func (r *buzzQueueRepository) GetQueue(roomID string) ([]models.BuzzQueue, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var queue []models.BuzzQueue
+	for _, buzz := range r.s.buzzQueue {
+		if buzz.RoomID == roomID && buzz.IsActive {
+			queue = append(queue, *buzz)
+		}
+	}
+	sortByPressedAt(queue)
+	return queue, nil
+}
+
+// GetNextPlayer 次の回答者を取得
+func (r *buzzQueueRepository) GetNextPlayer(roomID string) (*models.BuzzQueue, error) {
+	queue, err := r.GetQueue(roomID)
+	if err != nil {
+		return nil, err
+	}
+	if len(queue) == 0 {
+		return nil, fmt.Errorf("no players in queue")
+	}
+	return &queue[0], nil
+}
+
+// RemoveFromQueue プレイヤーをキューから削除
+func (r *buzzQueueRepository) RemoveFromQueue(roomID, playerID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, buzz := range r.s.buzzQueue {
+		if buzz.RoomID == roomID && buzz.PlayerID == playerID {
+			buzz.IsActive = false
+		}
+	}
+	return nil
+}
+
+// ClearQueue ルームのキューをクリア
+func (r *buzzQueueRepository) ClearQueue(roomID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, buzz := range r.s.buzzQueue {
+		if buzz.RoomID == roomID {
+			buzz.IsActive = false
+		}
+	}
+	return nil
+}
+
+// IsPlayerInQueue プレイヤーがキューにいるかチェック
+func (r *buzzQueueRepository) IsPlayerInQueue(roomID, playerID string) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, buzz := range r.s.buzzQueue {
+		if buzz.RoomID == roomID && buzz.PlayerID == playerID && buzz.IsActive {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetBuzzHistory キューから外れたものも含めたルームの早押しの記録（受信順）
+func (r *buzzQueueRepository) GetBuzzHistory(roomID string) ([]models.BuzzQueue, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var history []models.BuzzQueue
+	for _, buzz := range r.s.buzzQueue {
+		if buzz.RoomID == roomID {
+			history = append(history, *buzz)
+		}
+	}
+	return history, nil
+}
+
+// sortByPressedAt 補正後の押下時刻順（同時刻は受信順）に並べる
+func sortByPressedAt(queue []models.BuzzQueue) {
+	sort.SliceStable(queue, func(i, j int) bool {
+		if !queue[i].PressedAt.Equal(queue[j].PressedAt) {
+			return queue[i].PressedAt.Before(queue[j].PressedAt)
+		}
+		return queue[i].BuzzedAt.Before(queue[j].BuzzedAt)
+	})
+}
@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"time"
+
+	"quivra-backend/models"
+	"quivra-backend/repository"
+)
+
+type packRepository struct {
+	s *Store
+}
+
+// CreatePack 問題セットと収録する問題を登録
+func (r *packRepository) CreatePack(pack *models.QuestionPack) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored := copyPack(pack)
+	stored.ID = r.s.nextPackID
+	stored.CreatedAt = time.Now()
+	stored.UpdatedAt = stored.CreatedAt
+	r.s.nextPackID++
+	r.s.packs = append(r.s.packs, stored)
+
+	pack.ID = stored.ID
+	pack.CreatedAt = stored.CreatedAt
+	pack.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+// GetPacks 問題セット一覧を取得（新しい順）
+func (r *packRepository) GetPacks() ([]models.QuestionPack, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	packs := []models.QuestionPack{}
+	for i := len(r.s.packs) - 1; i >= 0; i-- {
+		packs = append(packs, *copyPack(r.s.packs[i]))
+	}
+	return packs, nil
+}
+
+// GetPack 問題セットを取得
+func (r *packRepository) GetPack(id int) (*models.QuestionPack, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	pack := r.s.findPack(id)
+	if pack == nil {
+		return nil, repository.ErrPackNotFound
+	}
+	return copyPack(pack), nil
+}
+
+// UpdatePack 問題セットの内容と収録する問題を置き換える
+func (r *packRepository) UpdatePack(pack *models.QuestionPack) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored := r.s.findPack(pack.ID)
+	if stored == nil {
+		return repository.ErrPackNotFound
+	}
+	stored.Name = pack.Name
+	stored.Description = pack.Description
+	stored.Shuffle = pack.Shuffle
+	stored.QuestionIDs = append([]int{}, pack.QuestionIDs...)
+	stored.UpdatedAt = time.Now()
+
+	pack.CreatedAt = stored.CreatedAt
+	pack.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+// DeletePack 問題セットを削除
+func (r *packRepository) DeletePack(id int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for i, pack := range r.s.packs {
+		if pack.ID == id {
+			r.s.packs = append(r.s.packs[:i], r.s.packs[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrPackNotFound
+}
+
+// copyPack 呼び出し側の変更がストアに影響しないよう問題セットを複製
+func copyPack(pack *models.QuestionPack) *models.QuestionPack {
+	copied := *pack
+	copied.QuestionIDs = append([]int{}, pack.QuestionIDs...)
+	return &copied
+}
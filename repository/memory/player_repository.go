@@ -0,0 +1,167 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"quivra-backend/models"
+)
+
+type playerRepository struct {
+	s *Store
+}
+
+// CreatePlayer プレイヤーを追加
+func (r *playerRepository) CreatePlayer(player *models.Player) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, exists := r.s.rooms[player.RoomID]; !exists {
+		return fmt.Errorf("failed to add player: room not found")
+	}
+	if r.s.findPlayer(player.ID) != nil {
+		return fmt.Errorf("failed to add player: duplicate player ID")
+	}
+
+	stored := *player
+	stored.JoinedAt = time.Now()
+	r.s.players = append(r.s.players, &stored)
+	player.JoinedAt = stored.JoinedAt
+	return nil
+}
+
+// GetPlayer ルーム内のプレイヤーを取得
+func (r *playerRepository) GetPlayer(roomID, playerID string) (*models.Player, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	player := r.s.findPlayer(playerID)
+	if player == nil || player.RoomID != roomID {
+		return nil, fmt.Errorf("player not found")
+	}
+
+	result := *player
+	return &result, nil
+}
+
+// GetRoomPlayers ルームのプレイヤー一覧を取得（参加順）
+func (r *playerRepository) GetRoomPlayers(roomID string) ([]models.Player, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var players []models.Player
+	for _, player := range r.s.players {
+		if player.RoomID == roomID {
+			players = append(players, *player)
+		}
+	}
+	return players, nil
+}
+
+// UpdatePlayerScore プレイヤーのスコアを更新
+func (r *playerRepository) UpdatePlayerScore(playerID string, score int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if player := r.s.findPlayer(playerID); player != nil {
+		player.Score = score
+	}
+	return nil
+}
+
+// GetRoomRanking ルームのランキングを取得（同点は参加順）
+func (r *playerRepository) GetRoomRanking(roomID string) ([]models.RoomRanking, error) {
+	players, err := r.GetRoomPlayers(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(players, func(i, j int) bool {
+		return players[i].Score > players[j].Score
+	})
+
+	var rankings []models.RoomRanking
+	for i, player := range players {
+		rankings = append(rankings, models.RoomRanking{
+			PlayerID: player.ID,
+			Name:     player.Name,
+			Score:    player.Score,
+			Rank:     i + 1,
+		})
+	}
+	return rankings, nil
+}
+
+// SetPlayerTeam プレイヤーの所属チームを変更
+func (r *playerRepository) SetPlayerTeam(roomID, playerID string, teamID *string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	player := r.s.findPlayer(playerID)
+	if player == nil || player.RoomID != roomID {
+		return fmt.Errorf("player not found")
+	}
+
+	if teamID == nil {
+		player.TeamID = nil
+		return nil
+	}
+	id := *teamID
+	player.TeamID = &id
+	return nil
+}
+
+// SetPlayerMuted プレイヤーのミュートを切り替える
+func (r *playerRepository) SetPlayerMuted(roomID, playerID string, muted bool) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	player := r.s.findPlayer(playerID)
+	if player == nil || player.RoomID != roomID {
+		return fmt.Errorf("player not found")
+	}
+	player.Muted = muted
+	return nil
+}
+
+// DeletePlayer プレイヤーを削除
+func (r *playerRepository) DeletePlayer(roomID, playerID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	player := r.s.findPlayer(playerID)
+	if player == nil || player.RoomID != roomID {
+		return fmt.Errorf("player not found")
+	}
+
+	players := r.s.players[:0]
+	for _, stored := range r.s.players {
+		if stored.ID != playerID {
+			players = append(players, stored)
+		}
+	}
+	r.s.players = players
+
+	// MySQL の ON DELETE CASCADE / SET NULL と同様に関連データを更新
+	queue := r.s.buzzQueue[:0]
+	for _, buzz := range r.s.buzzQueue {
+		if buzz.PlayerID != playerID {
+			queue = append(queue, buzz)
+		}
+	}
+	r.s.buzzQueue = queue
+
+	for _, session := range r.s.sessions {
+		if session.BuzzedPlayerID != nil && *session.BuzzedPlayerID == playerID {
+			session.BuzzedPlayerID = nil
+		}
+	}
+
+	for id, session := range r.s.playerSessions {
+		if session.PlayerID == playerID {
+			delete(r.s.playerSessions, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"fmt"
+	"time"
+
+	"quivra-backend/models"
+)
+
+type playerSessionRepository struct {
+	s *Store
+}
+
+// CreatePlayerSession セッションを記録
+func (r *playerSessionRepository) CreatePlayerSession(session *models.PlayerSession) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if !r.s.sessionOwnerExists(session) {
+		return fmt.Errorf("failed to create player session: player not found")
+	}
+
+	stored := *session
+	stored.CreatedAt = time.Now()
+	r.s.playerSessions[stored.ID] = &stored
+	session.CreatedAt = stored.CreatedAt
+	return nil
+}
+
+// GetPlayerSession セッションを取得
+func (r *playerSessionRepository) GetPlayerSession(sessionID string) (*models.PlayerSession, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	session, exists := r.s.playerSessions[sessionID]
+	if !exists || !r.s.sessionOwnerExists(session) {
+		return nil, fmt.Errorf("player session not found")
+	}
+
+	result := *session
+	return &result, nil
+}
+
+// sessionOwnerExists セッションのプレイヤー（観戦者の場合はルーム）が存在するか（ロック取得済みであること）
+// MySQL の外部キーによる連動削除と同じく、削除されたプレイヤー・ルームのセッションは無効にする
+func (s *Store) sessionOwnerExists(session *models.PlayerSession) bool {
+	if session.Role == models.SessionRoleSpectator {
+		_, exists := s.rooms[session.RoomID]
+		return exists
+	}
+	return s.findPlayer(session.PlayerID) != nil
+}
+
+// RevokePlayerSession セッションを失効させる
+func (r *playerSessionRepository) RevokePlayerSession(sessionID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if session, exists := r.s.playerSessions[sessionID]; exists && session.RevokedAt == nil {
+		now := time.Now()
+		session.RevokedAt = &now
+	}
+	return nil
+}
+
+// RevokePlayerSessions プレイヤーのセッションをすべて失効させる
+func (r *playerSessionRepository) RevokePlayerSessions(playerID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	for _, session := range r.s.playerSessions {
+		if session.PlayerID == playerID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+		}
+	}
+	return nil
+}
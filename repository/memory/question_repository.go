@@ -0,0 +1,352 @@
+package memory
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"quivra-backend/models"
+	"quivra-backend/repository"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+type questionRepository struct {
+	s *Store
+}
+
+// CreateQuestion 問題と別解を登録
+func (r *questionRepository) CreateQuestion(question *models.Question) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored := copyQuestion(question)
+	stored.ID = r.s.nextQuestionID
+	stored.Revision = 1
+	stored.CreatedAt = time.Now()
+	r.s.nextQuestionID++
+	r.s.questions = append(r.s.questions, stored)
+
+	question.ID = stored.ID
+	question.Revision = stored.Revision
+	question.CreatedAt = stored.CreatedAt
+	return nil
+}
+
+// CreateQuestions 複数の問題と別解をまとめて登録
+func (r *questionRepository) CreateQuestions(questions []*models.Question) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	for _, question := range questions {
+		stored := copyQuestion(question)
+		stored.ID = r.s.nextQuestionID
+		stored.Revision = 1
+		stored.CreatedAt = now
+		r.s.nextQuestionID++
+		r.s.questions = append(r.s.questions, stored)
+
+		question.ID = stored.ID
+		question.Revision = stored.Revision
+		question.CreatedAt = stored.CreatedAt
+	}
+	return nil
+}
+
+// UpdateQuestion 現在の版を履歴に残し、問題と別解を置き換えて版を1つ進める
+func (r *questionRepository) UpdateQuestion(question *models.Question) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	current := r.s.findQuestion(question.ID)
+	if current == nil || current.DeletedAt != nil {
+		return repository.ErrQuestionNotFound
+	}
+	if current.Revision != question.Revision {
+		return repository.ErrQuestionRevisionConflict
+	}
+
+	now := time.Now()
+	validFrom := current.CreatedAt
+	if current.UpdatedAt != nil {
+		validFrom = *current.UpdatedAt
+	}
+	replaced := copyQuestion(current)
+	r.s.revisions = append(r.s.revisions, &models.QuestionRevision{
+		QuestionID:      replaced.ID,
+		Revision:        replaced.Revision,
+		Question:        replaced.Question,
+		Answer:          replaced.Answer,
+		AnswerTolerance: replaced.AnswerTolerance,
+		Aliases:         replaced.Aliases,
+		Category:        replaced.Category,
+		Difficulty:      replaced.Difficulty,
+		CreatedAt:       validFrom,
+		ReplacedAt:      &now,
+	})
+
+	updated := copyQuestion(question)
+	current.Question = updated.Question
+	current.Answer = updated.Answer
+	current.AnswerTolerance = updated.AnswerTolerance
+	current.Aliases = updated.Aliases
+	current.Category = updated.Category
+	current.Difficulty = updated.Difficulty
+	current.Tags = updated.Tags
+	current.Revision++
+	current.UpdatedAt = &now
+
+	question.Revision = current.Revision
+	question.CreatedAt = current.CreatedAt
+	question.UpdatedAt = &now
+	return nil
+}
+
+// SetQuestionTags 問題のタグを置き換える
+func (r *questionRepository) SetQuestionTags(questionID int, tags []string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	question := r.s.findQuestion(questionID)
+	if question == nil {
+		return repository.ErrQuestionNotFound
+	}
+	question.Tags = append([]string{}, tags...)
+	return nil
+}
+
+// SearchQuestions 条件に合う問題を1ページ分取得し、条件に合う全件数も返す
+func (r *questionRepository) SearchQuestions(query repository.QuestionQuery) ([]models.Question, int, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	terms := make([]string, len(query.Terms))
+	for i, term := range query.Terms {
+		terms[i] = foldText(term)
+	}
+
+	var matched []*models.Question
+	for _, question := range r.s.questions {
+		if question.DeletedAt == nil && matchesQuery(question, query, terms) {
+			matched = append(matched, question)
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return questionBefore(matched[i], matched[j], query.Sort, query.Descending)
+	})
+
+	questions := []models.Question{}
+	for _, question := range matched {
+		if len(questions) == query.Limit {
+			break
+		}
+		if query.After != nil && !afterCursor(question, query) {
+			continue
+		}
+		questions = append(questions, *copyQuestion(question))
+	}
+	return questions, len(matched), nil
+}
+
+// DeleteQuestion 問題を論理削除
+func (r *questionRepository) DeleteQuestion(id int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	question := r.s.findQuestion(id)
+	if question == nil || question.DeletedAt != nil {
+		return repository.ErrQuestionNotFound
+	}
+	now := time.Now()
+	question.DeletedAt = &now
+	return nil
+}
+
+// RestoreQuestion 論理削除した問題を元に戻す
+func (r *questionRepository) RestoreQuestion(id int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	question := r.s.findQuestion(id)
+	if question == nil || question.DeletedAt == nil {
+		return repository.ErrQuestionNotFound
+	}
+	question.DeletedAt = nil
+	return nil
+}
+
+// GetQuestionRevisions 置き換えられた過去の版を古い順に取得
+func (r *questionRepository) GetQuestionRevisions(questionID int) ([]models.QuestionRevision, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	revisions := []models.QuestionRevision{}
+	for _, revision := range r.s.revisions {
+		if revision.QuestionID == questionID {
+			revisions = append(revisions, copyRevision(revision))
+		}
+	}
+	return revisions, nil
+}
+
+// GetQuestions 問題一覧を取得（新しい順）
+func (r *questionRepository) GetQuestions(category, difficulty string) ([]models.Question, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var questions []models.Question
+	for i := len(r.s.questions) - 1; i >= 0; i-- {
+		question := r.s.questions[i]
+		if question.DeletedAt == nil && matchesFilter(question, category, difficulty) {
+			questions = append(questions, *copyQuestion(question))
+		}
+	}
+	return questions, nil
+}
+
+// GetQuestion 問題を取得
+func (r *questionRepository) GetQuestion(id int) (*models.Question, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	question := r.s.findQuestion(id)
+	if question == nil {
+		return nil, repository.ErrQuestionNotFound
+	}
+	return copyQuestion(question), nil
+}
+
+// GetRandomQuestion ランダムな問題を取得
+func (r *questionRepository) GetRandomQuestion(category, difficulty string) (*models.Question, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var candidates []*models.Question
+	for _, question := range r.s.questions {
+		if question.DeletedAt == nil && matchesFilter(question, category, difficulty) {
+			candidates = append(candidates, question)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no questions found")
+	}
+
+	return copyQuestion(candidates[rand.Intn(len(candidates))]), nil
+}
+
+// matchesQuery 検索条件に一致するか（terms は foldText 済みの検索語）
+func matchesQuery(question *models.Question, query repository.QuestionQuery, terms []string) bool {
+	if len(query.Categories) > 0 && !containsString(query.Categories, question.Category) {
+		return false
+	}
+	if len(query.Difficulties) > 0 && !containsString(query.Difficulties, question.Difficulty) {
+		return false
+	}
+	for _, tag := range query.Tags {
+		if !containsString(question.Tags, tag) {
+			return false
+		}
+	}
+
+	text := question.Question
+	if query.SearchAnswers {
+		text += "\n" + question.Answer
+	}
+	text = foldText(text)
+	for _, term := range terms {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// foldText 検索用に全角/半角と大文字/小文字の違いをなくす
+func foldText(text string) string {
+	return strings.ToLower(norm.NFKC.String(text))
+}
+
+// questionSortKey 並び順のキー（QuestionSortID では使わない）
+func questionSortKey(question *models.Question, sortBy string) time.Time {
+	if sortBy == repository.QuestionSortUpdatedAt && question.UpdatedAt != nil {
+		return *question.UpdatedAt
+	}
+	return question.CreatedAt
+}
+
+// questionBefore 並び順で a が b より前か（キーが同じ場合はIDで決める）
+func questionBefore(a, b *models.Question, sortBy string, descending bool) bool {
+	if sortBy != repository.QuestionSortID {
+		keyA, keyB := questionSortKey(a, sortBy), questionSortKey(b, sortBy)
+		if !keyA.Equal(keyB) {
+			return keyA.Before(keyB) != descending
+		}
+	}
+	return a.ID != b.ID && (a.ID < b.ID) != descending
+}
+
+// afterCursor 一覧の位置より後の問題か
+func afterCursor(question *models.Question, query repository.QuestionQuery) bool {
+	cursor := &models.Question{ID: query.After.ID, CreatedAt: query.After.At}
+	if query.Sort == repository.QuestionSortUpdatedAt {
+		at := query.After.At
+		cursor.UpdatedAt = &at
+	}
+	return questionBefore(cursor, question, query.Sort, query.Descending)
+}
+
+// containsString values に value が含まれるか
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesFilter カテゴリ・難易度の絞り込み条件に一致するか
+func matchesFilter(question *models.Question, category, difficulty string) bool {
+	if category != "" && question.Category != category {
+		return false
+	}
+	if difficulty != "" && question.Difficulty != difficulty {
+		return false
+	}
+	return true
+}
+
+// copyQuestion 呼び出し側の変更がストアに影響しないよう問題を複製
+func copyQuestion(question *models.Question) *models.Question {
+	copied := *question
+	copied.Aliases = append([]string{}, question.Aliases...)
+	copied.Tags = append([]string{}, question.Tags...)
+	if question.AnswerTolerance != nil {
+		tolerance := *question.AnswerTolerance
+		copied.AnswerTolerance = &tolerance
+	}
+	if question.UpdatedAt != nil {
+		updatedAt := *question.UpdatedAt
+		copied.UpdatedAt = &updatedAt
+	}
+	if question.DeletedAt != nil {
+		deletedAt := *question.DeletedAt
+		copied.DeletedAt = &deletedAt
+	}
+	return &copied
+}
+
+// copyRevision 呼び出し側の変更がストアに影響しないよう問題の版を複製
+func copyRevision(revision *models.QuestionRevision) models.QuestionRevision {
+	copied := *revision
+	copied.Aliases = append([]string{}, revision.Aliases...)
+	if revision.AnswerTolerance != nil {
+		tolerance := *revision.AnswerTolerance
+		copied.AnswerTolerance = &tolerance
+	}
+	return copied
+}
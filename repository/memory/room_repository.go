@@ -0,0 +1,195 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"quivra-backend/models"
+)
+
+type roomRepository struct {
+	s *Store
+}
+
+// CreateRoom ルームを作成
+func (r *roomRepository) CreateRoom(room *models.Room) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, exists := r.s.rooms[room.ID]; exists {
+		return fmt.Errorf("failed to create room: duplicate room ID")
+	}
+
+	stored := *room
+	stored.CreatedAt = time.Now()
+	stored.Players = nil
+	r.s.rooms[room.ID] = &stored
+	room.CreatedAt = stored.CreatedAt
+	return nil
+}
+
+// GetRoom ルーム情報を取得
+func (r *roomRepository) GetRoom(roomID string) (*models.Room, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	room, exists := r.s.rooms[roomID]
+	if !exists {
+		return nil, fmt.Errorf("room not found")
+	}
+
+	result := *room
+	return &result, nil
+}
+
+// GetPublicRooms 公開ルーム一覧を取得
+func (r *roomRepository) GetPublicRooms() ([]models.Room, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var rooms []models.Room
+	for _, room := range r.s.rooms {
+		if room.IsPublic && room.Status == "waiting" {
+			rooms = append(rooms, *room)
+		}
+	}
+
+	sort.SliceStable(rooms, func(i, j int) bool {
+		return rooms[i].CreatedAt.After(rooms[j].CreatedAt)
+	})
+	return rooms, nil
+}
+
+// UpdateRoomStatus ルームの状態を更新
+func (r *roomRepository) UpdateRoomStatus(roomID, status string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if room, exists := r.s.rooms[roomID]; exists {
+		room.Status = status
+	}
+	return nil
+}
+
+// DeleteRoom ルームと関連データを削除
+func (r *roomRepository) DeleteRoom(roomID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, exists := r.s.rooms[roomID]; !exists {
+		return fmt.Errorf("room not found")
+	}
+	delete(r.s.rooms, roomID)
+
+	// MySQL の ON DELETE CASCADE と同様に関連データを削除
+	players := r.s.players[:0]
+	for _, player := range r.s.players {
+		if player.RoomID != roomID {
+			players = append(players, player)
+		}
+	}
+	r.s.players = players
+
+	teams := r.s.teams[:0]
+	for _, team := range r.s.teams {
+		if team.RoomID != roomID {
+			teams = append(teams, team)
+		}
+	}
+	r.s.teams = teams
+
+	matches := r.s.matches[:0]
+	for _, match := range r.s.matches {
+		if match.RoomID != roomID {
+			matches = append(matches, match)
+		}
+	}
+	r.s.matches = matches
+
+	sessions := r.s.sessions[:0]
+	for _, session := range r.s.sessions {
+		if session.RoomID != roomID {
+			sessions = append(sessions, session)
+		}
+	}
+	r.s.sessions = sessions
+
+	queue := r.s.buzzQueue[:0]
+	for _, buzz := range r.s.buzzQueue {
+		if buzz.RoomID != roomID {
+			queue = append(queue, buzz)
+		}
+	}
+	r.s.buzzQueue = queue
+
+	for id, session := range r.s.playerSessions {
+		if session.RoomID == roomID {
+			delete(r.s.playerSessions, id)
+		}
+	}
+
+	bans := r.s.bans[:0]
+	for _, ban := range r.s.bans {
+		if ban.RoomID != roomID {
+			bans = append(bans, ban)
+		}
+	}
+	r.s.bans = bans
+
+	return nil
+}
+
+// AddBan 参加禁止を登録（同じ名前が既に禁止されていれば何もしない）
+func (r *roomRepository) AddBan(ban *models.RoomBan) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, exists := r.s.rooms[ban.RoomID]; !exists {
+		return fmt.Errorf("failed to add ban: room not found")
+	}
+	if r.s.findBan(ban.RoomID, ban.Name) != nil {
+		return nil
+	}
+
+	stored := *ban
+	stored.CreatedAt = time.Now()
+	r.s.bans = append(r.s.bans, &stored)
+	ban.CreatedAt = stored.CreatedAt
+	return nil
+}
+
+// IsNameBanned 名前がルームへの参加を禁止されているか
+func (r *roomRepository) IsNameBanned(roomID, name string) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	return r.s.findBan(roomID, name) != nil, nil
+}
+
+// IsPlayerBanned プレイヤーを指定した参加禁止があるか
+func (r *roomRepository) IsPlayerBanned(roomID, playerID string) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, ban := range r.s.bans {
+		if ban.RoomID == roomID && ban.PlayerID != nil && *ban.PlayerID == playerID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetRoomBans ルームの参加禁止を登録順に取得
+func (r *roomRepository) GetRoomBans(roomID string) ([]models.RoomBan, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	bans := []models.RoomBan{}
+	for _, ban := range r.s.bans {
+		if ban.RoomID == roomID {
+			bans = append(bans, *ban)
+		}
+	}
+	return bans, nil
+}
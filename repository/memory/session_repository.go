@@ -0,0 +1,241 @@
+package memory
+
+import (
+	"fmt"
+	"time"
+
+	"quivra-backend/models"
+	"quivra-backend/repository"
+)
+
+type sessionRepository struct {
+	s *Store
+}
+
+// CreateMatch マッチと出題順を登録
+func (r *sessionRepository) CreateMatch(match *models.Match) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored := *match
+	stored.QuestionIDs = append([]int{}, match.QuestionIDs...)
+	stored.StartedAt = time.Now()
+	r.s.matches = append(r.s.matches, &stored)
+	match.StartedAt = stored.StartedAt
+	return nil
+}
+
+// GetActiveMatch 進行中のマッチを取得
+func (r *sessionRepository) GetActiveMatch(roomID string) (*models.Match, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for i := len(r.s.matches) - 1; i >= 0; i-- {
+		match := r.s.matches[i]
+		if match.RoomID == roomID && match.Status == "playing" {
+			result := *match
+			return &result, nil
+		}
+	}
+	return nil, fmt.Errorf("no active match found")
+}
+
+// AdvanceMatch マッチを次の問題番号に進める
+func (r *sessionRepository) AdvanceMatch(matchID string) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	match := r.s.findMatch(matchID)
+	if match == nil || match.Status != "playing" || match.CurrentNumber >= match.TotalQuestions {
+		return 0, repository.ErrNoRemainingQuestions
+	}
+
+	match.CurrentNumber++
+	return match.CurrentNumber, nil
+}
+
+// FinishMatch マッチを終了
+func (r *sessionRepository) FinishMatch(matchID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	for _, session := range r.s.sessions {
+		if session.MatchID != nil && *session.MatchID == matchID && isActiveSessionStatus(session.Status) {
+			session.Status = "finished"
+			session.EndedAt = &now
+		}
+	}
+
+	if match := r.s.findMatch(matchID); match != nil {
+		match.Status = "finished"
+		match.EndedAt = &now
+	}
+	return nil
+}
+
+// GetMatchSummary マッチの結果一覧を取得
+func (r *sessionRepository) GetMatchSummary(matchID string) (*models.MatchSummary, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	match := r.s.findMatch(matchID)
+	if match == nil {
+		return nil, fmt.Errorf("match not found")
+	}
+
+	summary := &models.MatchSummary{
+		MatchID:        match.ID,
+		RoomID:         match.RoomID,
+		TotalQuestions: match.TotalQuestions,
+	}
+
+	for _, session := range r.s.sessions {
+		if session.MatchID == nil || *session.MatchID != matchID {
+			continue
+		}
+
+		result := models.MatchQuestionResult{
+			QuestionNumber: session.QuestionNumber,
+			Status:         session.Status,
+		}
+		if session.QuestionID != nil {
+			qid := *session.QuestionID
+			result.QuestionID = &qid
+			// 出題後に問題が編集されていれば、出題したときの版の問題文・正解を表示する
+			if revision := r.s.findRevision(qid, session.QuestionRevision); revision != nil {
+				result.Question = revision.Question
+				result.CorrectAnswer = revision.Answer
+			} else if question := r.s.findQuestion(qid); question != nil {
+				result.Question = question.Question
+				result.CorrectAnswer = question.Answer
+			}
+		}
+		// 正解者がいる問題のみ回答者を表示
+		if session.BuzzedPlayerID != nil && session.Status == "answered" {
+			pid := *session.BuzzedPlayerID
+			result.AnsweredBy = &pid
+			if player := r.s.findPlayer(pid); player != nil {
+				result.AnsweredByName = player.Name
+			}
+		}
+		summary.Results = append(summary.Results, result)
+	}
+	summary.AskedQuestions = len(summary.Results)
+
+	return summary, nil
+}
+
+// GetMatchQuestionID マッチの number 問目に出題する問題のIDを取得
+func (r *sessionRepository) GetMatchQuestionID(matchID string, number int) (int, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	match := r.s.findMatch(matchID)
+	if match == nil || number < 1 || number > len(match.QuestionIDs) {
+		return 0, fmt.Errorf("match question not found")
+	}
+	return match.QuestionIDs[number-1], nil
+}
+
+// CreateGameSession ゲームセッションを作成
+func (r *sessionRepository) CreateGameSession(session *models.GameSession) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored := *session
+	stored.StartedAt = time.Now()
+	r.s.sessions = append(r.s.sessions, &stored)
+	session.StartedAt = stored.StartedAt
+	return nil
+}
+
+// UpdateGameSessionStatus 現在の状態が from の場合のみ状態を更新する
+func (r *sessionRepository) UpdateGameSessionStatus(sessionID, from, to string, change models.GameSessionChange) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	session := r.s.findSession(sessionID)
+	if session == nil || session.Status != from {
+		return repository.ErrSessionStatusChanged
+	}
+
+	session.Status = to
+	if change.QuestionID != nil {
+		qid := *change.QuestionID
+		session.QuestionID = &qid
+	}
+	if change.QuestionRevision != nil {
+		revision := *change.QuestionRevision
+		session.QuestionRevision = &revision
+	}
+	if change.BuzzedPlayerID != nil {
+		pid := *change.BuzzedPlayerID
+		session.BuzzedPlayerID = &pid
+	}
+	if change.StartedAt != nil {
+		session.StartedAt = *change.StartedAt
+	}
+	if change.EndedAt != nil {
+		endedAt := *change.EndedAt
+		session.EndedAt = &endedAt
+	}
+	return nil
+}
+
+// GetGameSession ゲームセッションを取得
+func (r *sessionRepository) GetGameSession(sessionID string) (*models.GameSession, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	session := r.s.findSession(sessionID)
+	if session == nil {
+		return nil, fmt.Errorf("game session not found")
+	}
+	return copySession(session), nil
+}
+
+// GetActiveGameSession アクティブなゲームセッションを取得
+func (r *sessionRepository) GetActiveGameSession(roomID string) (*models.GameSession, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for i := len(r.s.sessions) - 1; i >= 0; i-- {
+		session := r.s.sessions[i]
+		if session.RoomID == roomID && isActiveSessionStatus(session.Status) {
+			return copySession(session), nil
+		}
+	}
+	return nil, fmt.Errorf("no active game session found")
+}
+
+// isActiveSessionStatus 回答受付中の状態か
+func isActiveSessionStatus(status string) bool {
+	return status == "waiting" || status == "question" || status == "buzzed"
+}
+
+// copySession 呼び出し側の変更がストアに影響しないようセッションを複製
+func copySession(session *models.GameSession) *models.GameSession {
+	copied := *session
+	if session.MatchID != nil {
+		mid := *session.MatchID
+		copied.MatchID = &mid
+	}
+	if session.QuestionID != nil {
+		qid := *session.QuestionID
+		copied.QuestionID = &qid
+	}
+	if session.QuestionRevision != nil {
+		revision := *session.QuestionRevision
+		copied.QuestionRevision = &revision
+	}
+	if session.EndedAt != nil {
+		endedAt := *session.EndedAt
+		copied.EndedAt = &endedAt
+	}
+	if session.BuzzedPlayerID != nil {
+		pid := *session.BuzzedPlayerID
+		copied.BuzzedPlayerID = &pid
+	}
+	return &copied
+}
@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"sync"
+
+	"quivra-backend/models"
+	"quivra-backend/repository"
+)
+
+var _ repository.Store = (*Store)(nil)
+
+// Store プロセス内のメモリにデータを保持するストレージ（ローカル開発・デモ用）
+// 全リポジトリで1つのロックを共有し、ルーム削除時の連動削除などを一貫して行う
+type Store struct {
+	mu sync.RWMutex
+
+	rooms          map[string]*models.Room
+	players        []*models.Player // 参加順
+	teams          []*models.Team   // 作成順
+	questions      []*models.Question
+	nextQuestionID int
+	revisions      []*models.QuestionRevision // 置き換えられた問題の版
+	packs          []*models.QuestionPack     // 作成順
+	nextPackID     int
+	matches        []*models.Match
+	sessions       []*models.GameSession // 作成順
+	buzzQueue      []*models.BuzzQueue   // 押した順
+	playerSessions map[string]*models.PlayerSession
+	bans           []*models.RoomBan // 登録順
+}
+
+func NewStore() *Store {
+	return &Store{
+		rooms:          make(map[string]*models.Room),
+		nextQuestionID: 1,
+		nextPackID:     1,
+		playerSessions: make(map[string]*models.PlayerSession),
+	}
+}
+
+func (s *Store) Rooms() repository.RoomRepository {
+	return &roomRepository{s: s}
+}
+
+func (s *Store) Players() repository.PlayerRepository {
+	return &playerRepository{s: s}
+}
+
+func (s *Store) Teams() repository.TeamRepository {
+	return &teamRepository{s: s}
+}
+
+func (s *Store) Questions() repository.QuestionRepository {
+	return &questionRepository{s: s}
+}
+
+func (s *Store) Packs() repository.PackRepository {
+	return &packRepository{s: s}
+}
+
+func (s *Store) Sessions() repository.SessionRepository {
+	return &sessionRepository{s: s}
+}
+
+func (s *Store) BuzzQueue() repository.BuzzQueueRepository {
+	return &buzzQueueRepository{s: s}
+}
+
+func (s *Store) PlayerSessions() repository.PlayerSessionRepository {
+	return &playerSessionRepository{s: s}
+}
+
+// ResetAll 全データを削除
+func (s *Store) ResetAll() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rooms = make(map[string]*models.Room)
+	s.players = nil
+	s.teams = nil
+	s.questions = nil
+	s.nextQuestionID = 1
+	s.revisions = nil
+	s.packs = nil
+	s.nextPackID = 1
+	s.matches = nil
+	s.sessions = nil
+	s.buzzQueue = nil
+	s.playerSessions = make(map[string]*models.PlayerSession)
+	s.bans = nil
+	return nil
+}
+
+func (s *Store) Close() error {
+	return nil
+}
+
+// findPlayer IDでプレイヤーを検索（ロック取得済みであること）
+func (s *Store) findPlayer(playerID string) *models.Player {
+	for _, player := range s.players {
+		if player.ID == playerID {
+			return player
+		}
+	}
+	return nil
+}
+
+// findBan ルームの参加禁止を名前で検索（ロック取得済みであること）
+func (s *Store) findBan(roomID, name string) *models.RoomBan {
+	for _, ban := range s.bans {
+		if ban.RoomID == roomID && ban.Name == name {
+			return ban
+		}
+	}
+	return nil
+}
+
+// findQuestion IDで問題を検索（ロック取得済みであること）
+func (s *Store) findQuestion(id int) *models.Question {
+	for _, question := range s.questions {
+		if question.ID == id {
+			return question
+		}
+	}
+	return nil
+}
+
+// findPack IDで問題セットを検索（ロック取得済みであること）
+func (s *Store) findPack(id int) *models.QuestionPack {
+	for _, pack := range s.packs {
+		if pack.ID == id {
+			return pack
+		}
+	}
+	return nil
+}
+
+// findRevision 問題の過去の版を検索（最新版や版が不明な場合は nil。ロック取得済みであること）
+func (s *Store) findRevision(questionID int, revision *int) *models.QuestionRevision {
+	if revision == nil {
+		return nil
+	}
+	for _, stored := range s.revisions {
+		if stored.QuestionID == questionID && stored.Revision == *revision {
+			return stored
+		}
+	}
+	return nil
+}
+
+// findMatch IDでマッチを検索（ロック取得済みであること）
+func (s *Store) findMatch(matchID string) *models.Match {
+	for _, match := range s.matches {
+		if match.ID == matchID {
+			return match
+		}
+	}
+	return nil
+}
+
+// findSession IDでゲームセッションを検索（ロック取得済みであること）
+func (s *Store) findSession(sessionID string) *models.GameSession {
+	for _, session := range s.sessions {
+		if session.ID == sessionID {
+			return session
+		}
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"quivra-backend/repository"
+	"quivra-backend/repository/repositorytest"
+)
+
+func TestStore(t *testing.T) {
+	repositorytest.TestStore(t, func(t *testing.T) repository.Store {
+		return NewStore()
+	})
+}
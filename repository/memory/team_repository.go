@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"fmt"
+	"time"
+
+	"quivra-backend/models"
+)
+
+type teamRepository struct {
+	s *Store
+}
+
+// CreateTeam チームを作成
+func (r *teamRepository) CreateTeam(team *models.Team) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, exists := r.s.rooms[team.RoomID]; !exists {
+		return fmt.Errorf("failed to create team: room not found")
+	}
+	for _, existing := range r.s.teams {
+		if existing.RoomID == team.RoomID && existing.Name == team.Name {
+			return fmt.Errorf("failed to create team: duplicate team name")
+		}
+	}
+
+	stored := *team
+	stored.CreatedAt = time.Now()
+	r.s.teams = append(r.s.teams, &stored)
+	team.CreatedAt = stored.CreatedAt
+	return nil
+}
+
+// GetTeam ルーム内のチームを取得
+func (r *teamRepository) GetTeam(roomID, teamID string) (*models.Team, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, team := range r.s.teams {
+		if team.ID == teamID && team.RoomID == roomID {
+			result := *team
+			return &result, nil
+		}
+	}
+	return nil, fmt.Errorf("team not found")
+}
+
+// GetRoomTeams ルームのチーム一覧を取得（作成順）
+func (r *teamRepository) GetRoomTeams(roomID string) ([]models.Team, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var teams []models.Team
+	for _, team := range r.s.teams {
+		if team.RoomID == roomID {
+			teams = append(teams, *team)
+		}
+	}
+	return teams, nil
+}
+
+// DeleteTeam チームを削除し、所属プレイヤーをチームから外す
+func (r *teamRepository) DeleteTeam(roomID, teamID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	index := -1
+	for i, team := range r.s.teams {
+		if team.ID == teamID && team.RoomID == roomID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("team not found")
+	}
+	r.s.teams = append(r.s.teams[:index], r.s.teams[index+1:]...)
+
+	for _, player := range r.s.players {
+		if player.TeamID != nil && *player.TeamID == teamID {
+			player.TeamID = nil
+		}
+	}
+	return nil
+}
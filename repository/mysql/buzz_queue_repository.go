@@ -0,0 +1,117 @@
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+
+	"quivra-backend/database"
+	"quivra-backend/models"
+)
+
+type BuzzQueueRepository struct {
+	db *database.DB
+}
+
+const buzzQueueColumns = `id, room_id, player_id, buzzed_at, pressed_at, is_active, client_pressed_at, client_sent_at, rtt_ms`
+
+// AddToQueue 回答キューに追加
+func (r *BuzzQueueRepository) AddToQueue(entry *models.BuzzQueue) error {
+	query := `INSERT INTO buzz_queue (` + buzzQueueColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, entry.ID, entry.RoomID, entry.PlayerID, entry.BuzzedAt, entry.PressedAt, entry.IsActive, entry.ClientPressedAt, entry.ClientSentAt, entry.RTTMillis)
+	if err != nil {
+		return fmt.Errorf("failed to add to queue: %w", err)
+	}
+	return nil
+}
+
+// GetQueue ルームの回答キューを取得（補正後の押下時刻順）
+func (r *BuzzQueueRepository) GetQueue(roomID string) ([]models.BuzzQueue, error) {
+	query := `SELECT ` + buzzQueueColumns + ` FROM buzz_queue WHERE room_id = ? AND is_active = TRUE ORDER BY pressed_at ASC, buzzed_at ASC`
+	return r.queryBuzzes(query, roomID)
+}
+
+// GetNextPlayer 次の回答者を取得
+func (r *BuzzQueueRepository) GetNextPlayer(roomID string) (*models.BuzzQueue, error) {
+	query := `SELECT ` + buzzQueueColumns + ` FROM buzz_queue WHERE room_id = ? AND is_active = TRUE ORDER BY pressed_at ASC, buzzed_at ASC LIMIT 1`
+	buzz, err := scanBuzz(r.db.QueryRow(query, roomID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no players in queue")
+		}
+		return nil, fmt.Errorf("failed to get next player: %w", err)
+	}
+	return buzz, nil
+}
+
+// RemoveFromQueue プレイヤーをキューから削除
+func (r *BuzzQueueRepository) RemoveFromQueue(roomID, playerID string) error {
+	query := `UPDATE buzz_queue SET is_active = FALSE WHERE room_id = ? AND player_id = ?`
+	_, err := r.db.Exec(query, roomID, playerID)
+	if err != nil {
+		return fmt.Errorf("failed to remove from queue: %w", err)
+	}
+	return nil
+}
+
+// ClearQueue ルームのキューをクリア
+func (r *BuzzQueueRepository) ClearQueue(roomID string) error {
+	query := `UPDATE buzz_queue SET is_active = FALSE WHERE room_id = ?`
+	_, err := r.db.Exec(query, roomID)
+	if err != nil {
+		return fmt.Errorf("failed to clear queue: %w", err)
+	}
+	return nil
+}
+
+// IsPlayerInQueue プレイヤーがキューにいるかチェック
+func (r *BuzzQueueRepository) IsPlayerInQueue(roomID, playerID string) (bool, error) {
+	query := `SELECT COUNT(*) FROM buzz_queue WHERE room_id = ? AND player_id = ? AND is_active = TRUE`
+	var count int
+	err := r.db.QueryRow(query, roomID, playerID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check queue: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetBuzzHistory キューから外れたものも含めたルームの早押しの記録（受信順）
+func (r *BuzzQueueRepository) GetBuzzHistory(roomID string) ([]models.BuzzQueue, error) {
+	query := `SELECT ` + buzzQueueColumns + ` FROM buzz_queue WHERE room_id = ? ORDER BY buzzed_at ASC`
+	return r.queryBuzzes(query, roomID)
+}
+
+func (r *BuzzQueueRepository) queryBuzzes(query string, args ...interface{}) ([]models.BuzzQueue, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query queue: %w", err)
+	}
+	defer rows.Close()
+
+	var queue []models.BuzzQueue
+	for rows.Next() {
+		buzz, err := scanBuzz(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan queue: %w", err)
+		}
+		queue = append(queue, *buzz)
+	}
+
+	return queue, nil
+}
+
+// scanBuzz buzzQueueColumns の順に1行を読み込む
+func scanBuzz(row interface{ Scan(...interface{}) error }) (*models.BuzzQueue, error) {
+	var buzz models.BuzzQueue
+	var clientPressedAt, clientSentAt sql.NullInt64
+	err := row.Scan(&buzz.ID, &buzz.RoomID, &buzz.PlayerID, &buzz.BuzzedAt, &buzz.PressedAt, &buzz.IsActive, &clientPressedAt, &clientSentAt, &buzz.RTTMillis)
+	if err != nil {
+		return nil, err
+	}
+	if clientPressedAt.Valid {
+		buzz.ClientPressedAt = &clientPressedAt.Int64
+	}
+	if clientSentAt.Valid {
+		buzz.ClientSentAt = &clientSentAt.Int64
+	}
+	return &buzz, nil
+}
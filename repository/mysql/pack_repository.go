@@ -0,0 +1,172 @@
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+
+	"quivra-backend/database"
+	"quivra-backend/models"
+	"quivra-backend/repository"
+)
+
+type PackRepository struct {
+	db *database.DB
+}
+
+// CreatePack 問題セットと収録する問題を登録
+func (r *PackRepository) CreatePack(pack *models.QuestionPack) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO question_packs (name, description, shuffle) VALUES (?, ?, ?)`
+	result, err := tx.Exec(query, pack.Name, pack.Description, pack.Shuffle)
+	if err != nil {
+		return fmt.Errorf("failed to create question pack: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get question pack ID: %w", err)
+	}
+
+	if err := insertPackItems(tx, int(id), pack.QuestionIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit question pack: %w", err)
+	}
+
+	pack.ID = int(id)
+	return nil
+}
+
+// GetPacks 問題セット一覧を取得（新しい順）
+func (r *PackRepository) GetPacks() ([]models.QuestionPack, error) {
+	query := `SELECT id, name, COALESCE(description, ''), shuffle, created_at, updated_at FROM question_packs ORDER BY created_at DESC, id DESC`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query question packs: %w", err)
+	}
+	defer rows.Close()
+
+	packs := []models.QuestionPack{}
+	for rows.Next() {
+		var pack models.QuestionPack
+		if err := rows.Scan(&pack.ID, &pack.Name, &pack.Description, &pack.Shuffle, &pack.CreatedAt, &pack.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan question pack: %w", err)
+		}
+		packs = append(packs, pack)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range packs {
+		if packs[i].QuestionIDs, err = r.packQuestionIDs(packs[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return packs, nil
+}
+
+// GetPack 問題セットを取得
+func (r *PackRepository) GetPack(id int) (*models.QuestionPack, error) {
+	var pack models.QuestionPack
+	query := `SELECT id, name, COALESCE(description, ''), shuffle, created_at, updated_at FROM question_packs WHERE id = ?`
+	err := r.db.QueryRow(query, id).Scan(&pack.ID, &pack.Name, &pack.Description, &pack.Shuffle, &pack.CreatedAt, &pack.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repository.ErrPackNotFound
+		}
+		return nil, fmt.Errorf("failed to get question pack: %w", err)
+	}
+
+	if pack.QuestionIDs, err = r.packQuestionIDs(pack.ID); err != nil {
+		return nil, err
+	}
+	return &pack, nil
+}
+
+// UpdatePack 問題セットの内容と収録する問題を置き換える
+func (r *PackRepository) UpdatePack(pack *models.QuestionPack) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow(`SELECT 1 FROM question_packs WHERE id = ? FOR UPDATE`, pack.ID).Scan(&exists); err != nil {
+		if err == sql.ErrNoRows {
+			return repository.ErrPackNotFound
+		}
+		return fmt.Errorf("failed to lock question pack: %w", err)
+	}
+
+	query := `UPDATE question_packs SET name = ?, description = ?, shuffle = ? WHERE id = ?`
+	if _, err := tx.Exec(query, pack.Name, pack.Description, pack.Shuffle, pack.ID); err != nil {
+		return fmt.Errorf("failed to update question pack: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM question_pack_items WHERE pack_id = ?`, pack.ID); err != nil {
+		return fmt.Errorf("failed to clear question pack items: %w", err)
+	}
+	if err := insertPackItems(tx, pack.ID, pack.QuestionIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit question pack: %w", err)
+	}
+	return nil
+}
+
+// DeletePack 問題セットを削除
+func (r *PackRepository) DeletePack(id int) error {
+	result, err := r.db.Exec(`DELETE FROM question_packs WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete question pack: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete question pack: %w", err)
+	}
+	if affected == 0 {
+		return repository.ErrPackNotFound
+	}
+	return nil
+}
+
+// packQuestionIDs 問題セットに収録する問題のIDを並び順に取得
+func (r *PackRepository) packQuestionIDs(packID int) ([]int, error) {
+	rows, err := r.db.Query(`SELECT question_id FROM question_pack_items WHERE pack_id = ? ORDER BY position`, packID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query question pack items: %w", err)
+	}
+	defer rows.Close()
+
+	questionIDs := []int{}
+	for rows.Next() {
+		var questionID int
+		if err := rows.Scan(&questionID); err != nil {
+			return nil, fmt.Errorf("failed to scan question pack item: %w", err)
+		}
+		questionIDs = append(questionIDs, questionID)
+	}
+	return questionIDs, rows.Err()
+}
+
+// insertPackItems 問題セットに収録する問題をトランザクション内で登録
+func insertPackItems(tx *sql.Tx, packID int, questionIDs []int) error {
+	for i, questionID := range questionIDs {
+		_, err := tx.Exec(`INSERT INTO question_pack_items (pack_id, position, question_id) VALUES (?, ?, ?)`, packID, i+1, questionID)
+		if err != nil {
+			return fmt.Errorf("failed to add question to pack: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+
+	"quivra-backend/database"
+	"quivra-backend/models"
+)
+
+type PlayerRepository struct {
+	db *database.DB
+}
+
+// CreatePlayer プレイヤーを追加
+func (r *PlayerRepository) CreatePlayer(player *models.Player) error {
+	query := `INSERT INTO players (id, room_id, name, score, is_admin) VALUES (?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, player.ID, player.RoomID, player.Name, player.Score, player.IsAdmin)
+	if err != nil {
+		return fmt.Errorf("failed to add player: %w", err)
+	}
+	return nil
+}
+
+// GetPlayer ルーム内のプレイヤーを取得
+func (r *PlayerRepository) GetPlayer(roomID, playerID string) (*models.Player, error) {
+	var player models.Player
+	var teamID sql.NullString
+	query := `SELECT id, room_id, name, score, joined_at, is_admin, team_id, muted FROM players WHERE room_id = ? AND id = ?`
+	err := r.db.QueryRow(query, roomID, playerID).Scan(&player.ID, &player.RoomID, &player.Name, &player.Score, &player.JoinedAt, &player.IsAdmin, &teamID, &player.Muted)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("player not found")
+		}
+		return nil, fmt.Errorf("failed to get player: %w", err)
+	}
+	if teamID.Valid {
+		player.TeamID = &teamID.String
+	}
+	return &player, nil
+}
+
+// GetRoomPlayers ルームのプレイヤー一覧を取得
+func (r *PlayerRepository) GetRoomPlayers(roomID string) ([]models.Player, error) {
+	query := `SELECT id, room_id, name, score, joined_at, is_admin, team_id, muted FROM players WHERE room_id = ? ORDER BY joined_at`
+	rows, err := r.db.Query(query, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query players: %w", err)
+	}
+	defer rows.Close()
+
+	var players []models.Player
+	for rows.Next() {
+		var player models.Player
+		var teamID sql.NullString
+		err := rows.Scan(&player.ID, &player.RoomID, &player.Name, &player.Score, &player.JoinedAt, &player.IsAdmin, &teamID, &player.Muted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan player: %w", err)
+		}
+		if teamID.Valid {
+			player.TeamID = &teamID.String
+		}
+		players = append(players, player)
+	}
+
+	return players, nil
+}
+
+// UpdatePlayerScore プレイヤーのスコアを更新
+func (r *PlayerRepository) UpdatePlayerScore(playerID string, score int) error {
+	query := `UPDATE players SET score = ? WHERE id = ?`
+	_, err := r.db.Exec(query, score, playerID)
+	if err != nil {
+		return fmt.Errorf("failed to update player score: %w", err)
+	}
+	return nil
+}
+
+// GetRoomRanking ルームのランキングを取得
+func (r *PlayerRepository) GetRoomRanking(roomID string) ([]models.RoomRanking, error) {
+	query := `SELECT id, name, score FROM players WHERE room_id = ? ORDER BY score DESC, joined_at ASC`
+	rows, err := r.db.Query(query, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ranking: %w", err)
+	}
+	defer rows.Close()
+
+	var rankings []models.RoomRanking
+	rank := 1
+	for rows.Next() {
+		var ranking models.RoomRanking
+		err := rows.Scan(&ranking.PlayerID, &ranking.Name, &ranking.Score)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ranking: %w", err)
+		}
+		ranking.Rank = rank
+		rankings = append(rankings, ranking)
+		rank++
+	}
+
+	return rankings, nil
+}
+
+// SetPlayerTeam プレイヤーの所属チームを変更
+func (r *PlayerRepository) SetPlayerTeam(roomID, playerID string, teamID *string) error {
+	query := `UPDATE players SET team_id = ? WHERE room_id = ? AND id = ?`
+	_, err := r.db.Exec(query, teamID, roomID, playerID)
+	if err != nil {
+		return fmt.Errorf("failed to set player team: %w", err)
+	}
+	return nil
+}
+
+// SetPlayerMuted プレイヤーのミュートを切り替える
+func (r *PlayerRepository) SetPlayerMuted(roomID, playerID string, muted bool) error {
+	query := `UPDATE players SET muted = ? WHERE room_id = ? AND id = ?`
+	_, err := r.db.Exec(query, muted, roomID, playerID)
+	if err != nil {
+		return fmt.Errorf("failed to set player muted: %w", err)
+	}
+	return nil
+}
+
+// DeletePlayer プレイヤーを削除（セッション・回答キューは外部キーで連動して削除される）
+func (r *PlayerRepository) DeletePlayer(roomID, playerID string) error {
+	result, err := r.db.Exec(`DELETE FROM players WHERE room_id = ? AND id = ?`, roomID, playerID)
+	if err != nil {
+		return fmt.Errorf("failed to delete player: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete player: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("player not found")
+	}
+	return nil
+}
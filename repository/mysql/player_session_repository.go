@@ -0,0 +1,67 @@
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+
+	"quivra-backend/database"
+	"quivra-backend/models"
+)
+
+type PlayerSessionRepository struct {
+	db *database.DB
+}
+
+// CreatePlayerSession セッションを記録
+func (r *PlayerSessionRepository) CreatePlayerSession(session *models.PlayerSession) error {
+	var playerID interface{}
+	if session.PlayerID != "" {
+		playerID = session.PlayerID
+	}
+	query := `INSERT INTO player_sessions (id, player_id, room_id, role, expires_at) VALUES (?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, session.ID, playerID, session.RoomID, session.Role, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create player session: %w", err)
+	}
+	return nil
+}
+
+// GetPlayerSession セッションを取得
+func (r *PlayerSessionRepository) GetPlayerSession(sessionID string) (*models.PlayerSession, error) {
+	var session models.PlayerSession
+	var playerID sql.NullString
+	var revokedAt sql.NullTime
+	query := `SELECT id, player_id, room_id, role, created_at, expires_at, revoked_at FROM player_sessions WHERE id = ?`
+	err := r.db.QueryRow(query, sessionID).Scan(&session.ID, &playerID, &session.RoomID, &session.Role, &session.CreatedAt, &session.ExpiresAt, &revokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("player session not found")
+		}
+		return nil, fmt.Errorf("failed to get player session: %w", err)
+	}
+	session.PlayerID = playerID.String
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	return &session, nil
+}
+
+// RevokePlayerSession セッションを失効させる
+func (r *PlayerSessionRepository) RevokePlayerSession(sessionID string) error {
+	query := `UPDATE player_sessions SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND revoked_at IS NULL`
+	_, err := r.db.Exec(query, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke player session: %w", err)
+	}
+	return nil
+}
+
+// RevokePlayerSessions プレイヤーのセッションをすべて失効させる
+func (r *PlayerSessionRepository) RevokePlayerSessions(playerID string) error {
+	query := `UPDATE player_sessions SET revoked_at = CURRENT_TIMESTAMP WHERE player_id = ? AND revoked_at IS NULL`
+	_, err := r.db.Exec(query, playerID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke player sessions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,640 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"quivra-backend/database"
+	"quivra-backend/models"
+	"quivra-backend/repository"
+)
+
+type QuestionRepository struct {
+	db *database.DB
+}
+
+// CreateQuestion 問題と別解を登録
+func (r *QuestionRepository) CreateQuestion(question *models.Question) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := insertQuestion(tx, question)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit question: %w", err)
+	}
+
+	question.ID = id
+	question.Revision = 1
+	return nil
+}
+
+// CreateQuestions 複数の問題と別解を1つのトランザクションで登録
+func (r *QuestionRepository) CreateQuestions(questions []*models.Question) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ids := make([]int, len(questions))
+	for i, question := range questions {
+		if ids[i], err = insertQuestion(tx, question); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit questions: %w", err)
+	}
+
+	for i, question := range questions {
+		question.ID = ids[i]
+		question.Revision = 1
+	}
+	return nil
+}
+
+// insertQuestion トランザクション内で問題と別解を登録し、採番したIDを返す
+func insertQuestion(tx *sql.Tx, question *models.Question) (int, error) {
+	query := `INSERT INTO questions (question, answer, answer_tolerance, category, difficulty) VALUES (?, ?, ?, ?, ?)`
+	result, err := tx.Exec(query, question.Question, question.Answer, question.AnswerTolerance, question.Category, question.Difficulty)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create question: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get question ID: %w", err)
+	}
+
+	if err := insertAliases(tx, int(id), question.Aliases); err != nil {
+		return 0, err
+	}
+	if err := insertTags(tx, int(id), question.Tags); err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// UpdateQuestion 現在の版を question_revisions に残し、問題と別解を置き換えて版を1つ進める
+func (r *QuestionRepository) UpdateQuestion(question *models.Question) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := scanQuestion(tx.QueryRow(`SELECT `+questionColumns+` FROM questions WHERE id = ? FOR UPDATE`, question.ID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return repository.ErrQuestionNotFound
+		}
+		return fmt.Errorf("failed to lock question: %w", err)
+	}
+	if current.DeletedAt != nil {
+		return repository.ErrQuestionNotFound
+	}
+	if current.Revision != question.Revision {
+		return repository.ErrQuestionRevisionConflict
+	}
+
+	aliases, err := txAliases(tx, current.ID)
+	if err != nil {
+		return err
+	}
+	aliasesJSON, err := json.Marshal(aliases)
+	if err != nil {
+		return fmt.Errorf("failed to encode aliases: %w", err)
+	}
+	validFrom := current.CreatedAt
+	if current.UpdatedAt != nil {
+		validFrom = *current.UpdatedAt
+	}
+
+	revisionQuery := `INSERT INTO question_revisions (question_id, revision, question, answer, answer_tolerance, aliases, category, difficulty, created_at)
+					  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.Exec(revisionQuery, current.ID, current.Revision, current.Question, current.Answer, current.AnswerTolerance,
+		string(aliasesJSON), current.Category, current.Difficulty, validFrom)
+	if err != nil {
+		return fmt.Errorf("failed to save question revision: %w", err)
+	}
+
+	now := time.Now()
+	updateQuery := `UPDATE questions SET question = ?, answer = ?, answer_tolerance = ?, category = ?, difficulty = ?, revision = revision + 1, updated_at = ? WHERE id = ?`
+	_, err = tx.Exec(updateQuery, question.Question, question.Answer, question.AnswerTolerance, question.Category, question.Difficulty, now, question.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update question: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM question_aliases WHERE question_id = ?`, question.ID); err != nil {
+		return fmt.Errorf("failed to clear aliases: %w", err)
+	}
+	if err := insertAliases(tx, question.ID, question.Aliases); err != nil {
+		return err
+	}
+	if err := replaceTags(tx, question.ID, question.Tags); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit question: %w", err)
+	}
+
+	question.Revision = current.Revision + 1
+	question.CreatedAt = current.CreatedAt
+	question.UpdatedAt = &now
+	return nil
+}
+
+// SetQuestionTags 問題のタグを置き換える
+func (r *QuestionRepository) SetQuestionTags(questionID int, tags []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := replaceTags(tx, questionID, tags); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tags: %w", err)
+	}
+	return nil
+}
+
+// DeleteQuestion 問題を論理削除
+func (r *QuestionRepository) DeleteQuestion(id int) error {
+	result, err := r.db.Exec(`UPDATE questions SET deleted_at = NOW() WHERE id = ? AND deleted_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete question: %w", err)
+	}
+	return requireQuestionAffected(result, "delete question")
+}
+
+// RestoreQuestion 論理削除した問題を元に戻す
+func (r *QuestionRepository) RestoreQuestion(id int) error {
+	result, err := r.db.Exec(`UPDATE questions SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore question: %w", err)
+	}
+	return requireQuestionAffected(result, "restore question")
+}
+
+// GetQuestionRevisions 置き換えられた過去の版を古い順に取得
+func (r *QuestionRepository) GetQuestionRevisions(questionID int) ([]models.QuestionRevision, error) {
+	query := `SELECT question_id, revision, question, answer, answer_tolerance, aliases, category, difficulty, created_at, replaced_at
+			  FROM question_revisions WHERE question_id = ? ORDER BY revision`
+	rows, err := r.db.Query(query, questionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query question revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []models.QuestionRevision{}
+	for rows.Next() {
+		var revision models.QuestionRevision
+		var tolerance sql.NullInt64
+		var aliases []byte
+		var replacedAt time.Time
+		err := rows.Scan(&revision.QuestionID, &revision.Revision, &revision.Question, &revision.Answer, &tolerance,
+			&aliases, &revision.Category, &revision.Difficulty, &revision.CreatedAt, &replacedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan question revision: %w", err)
+		}
+		revision.AnswerTolerance = nullableInt(tolerance)
+		revision.ReplacedAt = &replacedAt
+		if err := json.Unmarshal(aliases, &revision.Aliases); err != nil {
+			return nil, fmt.Errorf("failed to decode aliases: %w", err)
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+// GetQuestions 削除していない問題の一覧を取得
+func (r *QuestionRepository) GetQuestions(category, difficulty string) ([]models.Question, error) {
+	query := `SELECT ` + questionColumns + ` FROM questions WHERE deleted_at IS NULL`
+	args := []interface{}{}
+
+	if category != "" {
+		query += ` AND category = ?`
+		args = append(args, category)
+	}
+
+	if difficulty != "" {
+		query += ` AND difficulty = ?`
+		args = append(args, difficulty)
+	}
+
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query questions: %w", err)
+	}
+	defer rows.Close()
+
+	var questions []models.Question
+	for rows.Next() {
+		question, err := scanQuestion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan question: %w", err)
+		}
+		questions = append(questions, *question)
+	}
+
+	if err := r.loadAliases(questions); err != nil {
+		return nil, err
+	}
+	if err := r.loadTags(questions); err != nil {
+		return nil, err
+	}
+
+	return questions, nil
+}
+
+// SearchQuestions 条件に合う問題を1ページ分取得し、条件に合う全件数も返す
+func (r *QuestionRepository) SearchQuestions(query repository.QuestionQuery) ([]models.Question, int, error) {
+	where, args := questionQueryConditions(query)
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM questions WHERE ` + strings.Join(where, " AND ")
+	if err := r.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count questions: %w", err)
+	}
+
+	sortKey := questionSortColumns[query.Sort]
+	direction, compare := "ASC", ">"
+	if query.Descending {
+		direction, compare = "DESC", "<"
+	}
+	if query.After != nil {
+		if query.Sort == repository.QuestionSortID {
+			where = append(where, `id `+compare+` ?`)
+			args = append(args, query.After.ID)
+		} else {
+			where = append(where, `(`+sortKey+` `+compare+` ? OR (`+sortKey+` = ? AND id `+compare+` ?))`)
+			args = append(args, query.After.At, query.After.At, query.After.ID)
+		}
+	}
+
+	selectQuery := `SELECT ` + questionColumns + ` FROM questions WHERE ` + strings.Join(where, " AND ") +
+		` ORDER BY ` + sortKey + ` ` + direction + `, id ` + direction + ` LIMIT ?`
+	args = append(args, query.Limit)
+
+	rows, err := r.db.Query(selectQuery, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query questions: %w", err)
+	}
+	defer rows.Close()
+
+	questions := []models.Question{}
+	for rows.Next() {
+		question, err := scanQuestion(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan question: %w", err)
+		}
+		questions = append(questions, *question)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if err := r.loadAliases(questions); err != nil {
+		return nil, 0, err
+	}
+	if err := r.loadTags(questions); err != nil {
+		return nil, 0, err
+	}
+	return questions, total, nil
+}
+
+// questionSortColumns 並び順ごとのソートキー
+var questionSortColumns = map[string]string{
+	repository.QuestionSortCreatedAt: "created_at",
+	repository.QuestionSortUpdatedAt: "COALESCE(updated_at, created_at)",
+	repository.QuestionSortID:        "id",
+}
+
+// ngramTokenSize MySQL の ngram パーサーの既定のトークン長（これより短い語は全文検索できない）
+const ngramTokenSize = 2
+
+// questionQueryConditions 検索条件を WHERE 句の条件に変換する（位置・件数は含まない）
+func questionQueryConditions(query repository.QuestionQuery) ([]string, []interface{}) {
+	where := []string{`deleted_at IS NULL`}
+	var args []interface{}
+
+	// 日本語など ASCII 以外を含む語は ngram の全文索引で検索する。
+	// ASCII のみの語は ngram だと英語のストップワードを含むトークンが索引に載らないため、短い語と同様に LIKE で検索する
+	// 答えを照合しない場合は問題文だけの全文索引を使う（MATCH の列は索引の列と一致している必要がある）
+	var phrases []string
+	for _, term := range query.Terms {
+		if utf8.RuneCountInString(term) >= ngramTokenSize && !isASCII(term) {
+			phrases = append(phrases, `+"`+strings.ReplaceAll(term, `"`, ``)+`"`)
+			continue
+		}
+		pattern := "%" + escapeLike(term) + "%"
+		if query.SearchAnswers {
+			where = append(where, `(question LIKE ? OR answer LIKE ?)`)
+			args = append(args, pattern, pattern)
+		} else {
+			where = append(where, `question LIKE ?`)
+			args = append(args, pattern)
+		}
+	}
+	if len(phrases) > 0 {
+		if query.SearchAnswers {
+			where = append(where, `MATCH(question, answer) AGAINST (? IN BOOLEAN MODE)`)
+		} else {
+			where = append(where, `MATCH(question) AGAINST (? IN BOOLEAN MODE)`)
+		}
+		args = append(args, strings.Join(phrases, " "))
+	}
+
+	if len(query.Categories) > 0 {
+		where = append(where, `category IN (`+placeholders(len(query.Categories))+`)`)
+		for _, category := range query.Categories {
+			args = append(args, category)
+		}
+	}
+	if len(query.Difficulties) > 0 {
+		where = append(where, `difficulty IN (`+placeholders(len(query.Difficulties))+`)`)
+		for _, difficulty := range query.Difficulties {
+			args = append(args, difficulty)
+		}
+	}
+	if len(query.Tags) > 0 {
+		where = append(where, `id IN (SELECT question_id FROM question_tags WHERE tag IN (`+placeholders(len(query.Tags))+`)
+								GROUP BY question_id HAVING COUNT(*) = ?)`)
+		for _, tag := range query.Tags {
+			args = append(args, tag)
+		}
+		args = append(args, len(query.Tags))
+	}
+	return where, args
+}
+
+// placeholders n 個の ? をカンマ区切りで返す
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// escapeLike LIKE のワイルドカードをエスケープする
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// isASCII ASCII 文字のみか
+func isASCII(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if value[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// GetQuestion 問題を取得（出題済みのマッチから参照できるよう、論理削除した問題も返す）
+func (r *QuestionRepository) GetQuestion(id int) (*models.Question, error) {
+	question, err := scanQuestion(r.db.QueryRow(`SELECT `+questionColumns+` FROM questions WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, repository.ErrQuestionNotFound
+		}
+		return nil, fmt.Errorf("failed to get question: %w", err)
+	}
+
+	if err := r.loadQuestionAliases(question); err != nil {
+		return nil, err
+	}
+
+	return question, nil
+}
+
+// GetRandomQuestion 削除していない問題からランダムに取得
+func (r *QuestionRepository) GetRandomQuestion(category, difficulty string) (*models.Question, error) {
+	query := `SELECT ` + questionColumns + ` FROM questions WHERE deleted_at IS NULL`
+	args := []interface{}{}
+
+	if category != "" {
+		query += ` AND category = ?`
+		args = append(args, category)
+	}
+
+	if difficulty != "" {
+		query += ` AND difficulty = ?`
+		args = append(args, difficulty)
+	}
+
+	query += ` ORDER BY RAND() LIMIT 1`
+
+	question, err := scanQuestion(r.db.QueryRow(query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no questions found")
+		}
+		return nil, fmt.Errorf("failed to get random question: %w", err)
+	}
+
+	if err := r.loadQuestionAliases(question); err != nil {
+		return nil, err
+	}
+
+	return question, nil
+}
+
+// questionColumns scanQuestion で読み込む列
+const questionColumns = `id, question, answer, answer_tolerance, category, difficulty, revision, created_at, updated_at, deleted_at`
+
+// rowScanner *sql.Row と *sql.Rows の共通部分
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanQuestion questionColumns の1行を読み込む（別解は読み込まない）
+func scanQuestion(row rowScanner) (*models.Question, error) {
+	var question models.Question
+	var tolerance sql.NullInt64
+	var updatedAt, deletedAt sql.NullTime
+	err := row.Scan(&question.ID, &question.Question, &question.Answer, &tolerance, &question.Category, &question.Difficulty,
+		&question.Revision, &question.CreatedAt, &updatedAt, &deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	question.AnswerTolerance = nullableInt(tolerance)
+	if updatedAt.Valid {
+		question.UpdatedAt = &updatedAt.Time
+	}
+	if deletedAt.Valid {
+		question.DeletedAt = &deletedAt.Time
+	}
+	return &question, nil
+}
+
+// txAliases トランザクション内で1問分の別解を読み込む
+func txAliases(tx *sql.Tx, questionID int) ([]string, error) {
+	rows, err := tx.Query(`SELECT alias FROM question_aliases WHERE question_id = ? ORDER BY id`, questionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aliases: %w", err)
+	}
+	defer rows.Close()
+
+	aliases := []string{}
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, fmt.Errorf("failed to scan alias: %w", err)
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, rows.Err()
+}
+
+// requireQuestionAffected 更新対象の問題がなかった場合は ErrQuestionNotFound を返す
+func requireQuestionAffected(result sql.Result, action string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+	if affected == 0 {
+		return repository.ErrQuestionNotFound
+	}
+	return nil
+}
+
+// loadAliases 問題一覧に別解を読み込む
+func (r *QuestionRepository) loadAliases(questions []models.Question) error {
+	if len(questions) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(questions))
+	args := make([]interface{}, len(questions))
+	index := make(map[int]int, len(questions))
+	for i, question := range questions {
+		placeholders[i] = "?"
+		args[i] = question.ID
+		index[question.ID] = i
+		questions[i].Aliases = []string{}
+	}
+
+	query := `SELECT question_id, alias FROM question_aliases WHERE question_id IN (` + strings.Join(placeholders, ", ") + `) ORDER BY id`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query aliases: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var questionID int
+		var alias string
+		if err := rows.Scan(&questionID, &alias); err != nil {
+			return fmt.Errorf("failed to scan alias: %w", err)
+		}
+		if i, ok := index[questionID]; ok {
+			questions[i].Aliases = append(questions[i].Aliases, alias)
+		}
+	}
+
+	return rows.Err()
+}
+
+// loadQuestionAliases 1問分の別解とタグを読み込む
+func (r *QuestionRepository) loadQuestionAliases(question *models.Question) error {
+	questions := []models.Question{*question}
+	if err := r.loadAliases(questions); err != nil {
+		return err
+	}
+	if err := r.loadTags(questions); err != nil {
+		return err
+	}
+	question.Aliases = questions[0].Aliases
+	question.Tags = questions[0].Tags
+	return nil
+}
+
+// loadTags 問題一覧にタグを読み込む
+func (r *QuestionRepository) loadTags(questions []models.Question) error {
+	if len(questions) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, len(questions))
+	index := make(map[int]int, len(questions))
+	for i, question := range questions {
+		args[i] = question.ID
+		index[question.ID] = i
+		questions[i].Tags = []string{}
+	}
+
+	query := `SELECT question_id, tag FROM question_tags WHERE question_id IN (` + placeholders(len(questions)) + `) ORDER BY tag`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var questionID int
+		var tag string
+		if err := rows.Scan(&questionID, &tag); err != nil {
+			return fmt.Errorf("failed to scan tag: %w", err)
+		}
+		if i, ok := index[questionID]; ok {
+			questions[i].Tags = append(questions[i].Tags, tag)
+		}
+	}
+
+	return rows.Err()
+}
+
+// insertAliases 別解をトランザクション内で登録
+func insertAliases(tx *sql.Tx, questionID int, aliases []string) error {
+	for _, alias := range aliases {
+		_, err := tx.Exec(`INSERT INTO question_aliases (question_id, alias) VALUES (?, ?)`, questionID, alias)
+		if err != nil {
+			return fmt.Errorf("failed to add alias: %w", err)
+		}
+	}
+	return nil
+}
+
+// insertTags タグをトランザクション内で登録
+func insertTags(tx *sql.Tx, questionID int, tags []string) error {
+	for _, tag := range tags {
+		if _, err := tx.Exec(`INSERT INTO question_tags (question_id, tag) VALUES (?, ?)`, questionID, tag); err != nil {
+			return fmt.Errorf("failed to add tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// replaceTags タグをトランザクション内で置き換える
+func replaceTags(tx *sql.Tx, questionID int, tags []string) error {
+	if _, err := tx.Exec(`DELETE FROM question_tags WHERE question_id = ?`, questionID); err != nil {
+		return fmt.Errorf("failed to clear tags: %w", err)
+	}
+	return insertTags(tx, questionID, tags)
+}
+
+// nullableInt NULL許容の整数カラムをポインタに変換
+func nullableInt(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	v := int(value.Int64)
+	return &v
+}
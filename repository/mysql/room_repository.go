@@ -0,0 +1,167 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"quivra-backend/database"
+	"quivra-backend/models"
+)
+
+type RoomRepository struct {
+	db *database.DB
+}
+
+// CreateRoom ルームを作成
+func (r *RoomRepository) CreateRoom(room *models.Room) error {
+	settingsJSON, err := json.Marshal(room.Settings)
+	if err != nil {
+		return fmt.Errorf("failed to encode room settings: %w", err)
+	}
+
+	query := `INSERT INTO rooms (id, name, status, is_public, created_by, settings) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err = r.db.Exec(query, room.ID, room.Name, room.Status, room.IsPublic, room.CreatedBy, settingsJSON)
+	if err != nil {
+		return fmt.Errorf("failed to create room: %w", err)
+	}
+	return nil
+}
+
+// GetRoom ルーム情報を取得
+func (r *RoomRepository) GetRoom(roomID string) (*models.Room, error) {
+	var room models.Room
+	var settings sql.NullString
+	query := `SELECT id, name, created_at, status, is_public, created_by, settings FROM rooms WHERE id = ?`
+	err := r.db.QueryRow(query, roomID).Scan(&room.ID, &room.Name, &room.CreatedAt, &room.Status, &room.IsPublic, &room.CreatedBy, &settings)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("room not found")
+		}
+		return nil, fmt.Errorf("failed to get room: %w", err)
+	}
+
+	if err := decodeRoomSettings(settings, &room); err != nil {
+		return nil, err
+	}
+
+	return &room, nil
+}
+
+// GetPublicRooms 公開ルーム一覧を取得
+func (r *RoomRepository) GetPublicRooms() ([]models.Room, error) {
+	query := `SELECT id, name, created_at, status, is_public, created_by, settings FROM rooms WHERE is_public = TRUE AND status = 'waiting' ORDER BY created_at DESC`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query public rooms: %w", err)
+	}
+	defer rows.Close()
+
+	var rooms []models.Room
+	for rows.Next() {
+		var room models.Room
+		var settings sql.NullString
+		err := rows.Scan(&room.ID, &room.Name, &room.CreatedAt, &room.Status, &room.IsPublic, &room.CreatedBy, &settings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan room: %w", err)
+		}
+		if err := decodeRoomSettings(settings, &room); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, room)
+	}
+
+	return rooms, nil
+}
+
+// UpdateRoomStatus ルームの状態を更新
+func (r *RoomRepository) UpdateRoomStatus(roomID, status string) error {
+	query := `UPDATE rooms SET status = ? WHERE id = ?`
+	_, err := r.db.Exec(query, status, roomID)
+	if err != nil {
+		return fmt.Errorf("failed to update room status: %w", err)
+	}
+	return nil
+}
+
+// DeleteRoom ルームを削除（プレイヤー・セッション等も連動して削除される）
+func (r *RoomRepository) DeleteRoom(roomID string) error {
+	result, err := r.db.Exec(`DELETE FROM rooms WHERE id = ?`, roomID)
+	if err != nil {
+		return fmt.Errorf("failed to delete room: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete room: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("room not found")
+	}
+	return nil
+}
+
+// AddBan 参加禁止を登録（同じ名前が既に禁止されていれば何もしない）
+func (r *RoomRepository) AddBan(ban *models.RoomBan) error {
+	query := `INSERT IGNORE INTO room_bans (room_id, name, player_id) VALUES (?, ?, ?)`
+	_, err := r.db.Exec(query, ban.RoomID, ban.Name, ban.PlayerID)
+	if err != nil {
+		return fmt.Errorf("failed to add ban: %w", err)
+	}
+	return nil
+}
+
+// IsNameBanned 名前がルームへの参加を禁止されているか
+func (r *RoomRepository) IsNameBanned(roomID, name string) (bool, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM room_bans WHERE room_id = ? AND name = ?`, roomID, name).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check ban: %w", err)
+	}
+	return count > 0, nil
+}
+
+// IsPlayerBanned プレイヤーを指定した参加禁止があるか
+func (r *RoomRepository) IsPlayerBanned(roomID, playerID string) (bool, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM room_bans WHERE room_id = ? AND player_id = ?`, roomID, playerID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check ban: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetRoomBans ルームの参加禁止を登録順に取得
+func (r *RoomRepository) GetRoomBans(roomID string) ([]models.RoomBan, error) {
+	query := `SELECT room_id, name, player_id, created_at FROM room_bans WHERE room_id = ? ORDER BY created_at, name`
+	rows, err := r.db.Query(query, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bans: %w", err)
+	}
+	defer rows.Close()
+
+	bans := []models.RoomBan{}
+	for rows.Next() {
+		var ban models.RoomBan
+		var playerID sql.NullString
+		if err := rows.Scan(&ban.RoomID, &ban.Name, &playerID, &ban.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ban: %w", err)
+		}
+		if playerID.Valid {
+			ban.PlayerID = &playerID.String
+		}
+		bans = append(bans, ban)
+	}
+	return bans, nil
+}
+
+// decodeRoomSettings JSONカラムのルーム設定を展開
+func decodeRoomSettings(settings sql.NullString, room *models.Room) error {
+	if !settings.Valid {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(settings.String), &room.Settings); err != nil {
+		return fmt.Errorf("failed to decode room settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+
+	"quivra-backend/database"
+	"quivra-backend/models"
+	"quivra-backend/repository"
+)
+
+type SessionRepository struct {
+	db *database.DB
+}
+
+// CreateMatch マッチと出題順を登録
+func (r *SessionRepository) CreateMatch(match *models.Match) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO matches (id, room_id, total_questions, current_number, status) VALUES (?, ?, ?, ?, ?)`
+	_, err = tx.Exec(query, match.ID, match.RoomID, match.TotalQuestions, match.CurrentNumber, match.Status)
+	if err != nil {
+		return fmt.Errorf("failed to create match: %w", err)
+	}
+
+	for i, questionID := range match.QuestionIDs {
+		_, err := tx.Exec(`INSERT INTO match_questions (match_id, position, question_id) VALUES (?, ?, ?)`, match.ID, i+1, questionID)
+		if err != nil {
+			return fmt.Errorf("failed to add match question: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit match: %w", err)
+	}
+	return nil
+}
+
+// GetActiveMatch 進行中のマッチを取得
+func (r *SessionRepository) GetActiveMatch(roomID string) (*models.Match, error) {
+	var match models.Match
+	query := `SELECT id, room_id, total_questions, current_number, status, started_at, ended_at
+			  FROM matches
+			  WHERE room_id = ? AND status = 'playing'
+			  ORDER BY started_at DESC LIMIT 1`
+
+	var endedAt sql.NullTime
+	err := r.db.QueryRow(query, roomID).Scan(
+		&match.ID, &match.RoomID, &match.TotalQuestions, &match.CurrentNumber,
+		&match.Status, &match.StartedAt, &endedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no active match found")
+		}
+		return nil, fmt.Errorf("failed to get active match: %w", err)
+	}
+
+	if endedAt.Valid {
+		match.EndedAt = &endedAt.Time
+	}
+
+	return &match, nil
+}
+
+// AdvanceMatch マッチを次の問題番号に進める
+func (r *SessionRepository) AdvanceMatch(matchID string) (int, error) {
+	query := `UPDATE matches SET current_number = current_number + 1 WHERE id = ? AND status = 'playing' AND current_number < total_questions`
+	result, err := r.db.Exec(query, matchID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to advance match: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to advance match: %w", err)
+	}
+	if affected == 0 {
+		return 0, repository.ErrNoRemainingQuestions
+	}
+
+	var number int
+	err = r.db.QueryRow(`SELECT current_number FROM matches WHERE id = ?`, matchID).Scan(&number)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get match progress: %w", err)
+	}
+	return number, nil
+}
+
+// FinishMatch マッチを終了
+func (r *SessionRepository) FinishMatch(matchID string) error {
+	// 回答中のままの問題も終了させる
+	sessionQuery := `UPDATE game_sessions SET status = 'finished', ended_at = NOW() WHERE match_id = ? AND status IN ('waiting', 'question', 'buzzed')`
+	_, err := r.db.Exec(sessionQuery, matchID)
+	if err != nil {
+		return fmt.Errorf("failed to finish match questions: %w", err)
+	}
+
+	query := `UPDATE matches SET status = 'finished', ended_at = NOW() WHERE id = ?`
+	_, err = r.db.Exec(query, matchID)
+	if err != nil {
+		return fmt.Errorf("failed to finish match: %w", err)
+	}
+	return nil
+}
+
+// GetMatchSummary マッチの結果一覧を取得
+func (r *SessionRepository) GetMatchSummary(matchID string) (*models.MatchSummary, error) {
+	summary := &models.MatchSummary{MatchID: matchID}
+	err := r.db.QueryRow(`SELECT room_id, total_questions FROM matches WHERE id = ?`, matchID).Scan(&summary.RoomID, &summary.TotalQuestions)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("match not found")
+		}
+		return nil, fmt.Errorf("failed to get match: %w", err)
+	}
+
+	// 出題後に問題が編集されていれば、出題したときの版の問題文・正解を表示する
+	query := `SELECT gs.question_number, gs.question_id, COALESCE(qr.question, q.question, ''), COALESCE(qr.answer, q.answer, ''), gs.status,
+					 gs.buzzed_player_id, COALESCE(p.name, '')
+			  FROM game_sessions gs
+			  LEFT JOIN questions q ON q.id = gs.question_id
+			  LEFT JOIN question_revisions qr ON qr.question_id = gs.question_id AND qr.revision = gs.question_revision
+			  LEFT JOIN players p ON p.id = gs.buzzed_player_id
+			  WHERE gs.match_id = ?
+			  ORDER BY gs.question_number ASC`
+	rows, err := r.db.Query(query, matchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query match results: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result models.MatchQuestionResult
+		var questionID sql.NullInt64
+		var answeredBy sql.NullString
+		err := rows.Scan(&result.QuestionNumber, &questionID, &result.Question, &result.CorrectAnswer,
+			&result.Status, &answeredBy, &result.AnsweredByName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan match result: %w", err)
+		}
+		result.QuestionID = nullableInt(questionID)
+		// 正解者がいる問題のみ回答者を表示
+		if answeredBy.Valid && result.Status == "answered" {
+			pid := answeredBy.String
+			result.AnsweredBy = &pid
+		} else {
+			result.AnsweredByName = ""
+		}
+		summary.Results = append(summary.Results, result)
+	}
+	summary.AskedQuestions = len(summary.Results)
+
+	return summary, nil
+}
+
+// GetMatchQuestionID マッチの number 問目に出題する問題のIDを取得
+func (r *SessionRepository) GetMatchQuestionID(matchID string, number int) (int, error) {
+	var questionID int
+	err := r.db.QueryRow(`SELECT question_id FROM match_questions WHERE match_id = ? AND position = ?`, matchID, number).Scan(&questionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("match question not found")
+		}
+		return 0, fmt.Errorf("failed to get match question: %w", err)
+	}
+	return questionID, nil
+}
+
+// CreateGameSession ゲームセッションを作成
+func (r *SessionRepository) CreateGameSession(session *models.GameSession) error {
+	query := `INSERT INTO game_sessions (id, room_id, match_id, question_number, status) VALUES (?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, session.ID, session.RoomID, session.MatchID, session.QuestionNumber, session.Status)
+	if err != nil {
+		return fmt.Errorf("failed to create game session: %w", err)
+	}
+	return nil
+}
+
+// UpdateGameSessionStatus 現在の状態が from の場合のみ状態を更新する
+func (r *SessionRepository) UpdateGameSessionStatus(sessionID, from, to string, change models.GameSessionChange) error {
+	query := `UPDATE game_sessions SET status = ?`
+	args := []interface{}{to}
+	if change.QuestionID != nil {
+		query += `, question_id = ?`
+		args = append(args, *change.QuestionID)
+	}
+	if change.QuestionRevision != nil {
+		query += `, question_revision = ?`
+		args = append(args, *change.QuestionRevision)
+	}
+	if change.BuzzedPlayerID != nil {
+		query += `, buzzed_player_id = ?`
+		args = append(args, *change.BuzzedPlayerID)
+	}
+	if change.StartedAt != nil {
+		query += `, started_at = ?`
+		args = append(args, *change.StartedAt)
+	}
+	if change.EndedAt != nil {
+		query += `, ended_at = ?`
+		args = append(args, *change.EndedAt)
+	}
+	query += ` WHERE id = ? AND status = ?`
+	args = append(args, sessionID, from)
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update game session: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update game session: %w", err)
+	}
+	if affected == 0 {
+		return repository.ErrSessionStatusChanged
+	}
+	return nil
+}
+
+// GetGameSession ゲームセッションを取得
+func (r *SessionRepository) GetGameSession(sessionID string) (*models.GameSession, error) {
+	query := `SELECT id, room_id, match_id, question_number, question_id, question_revision, started_at, ended_at, status, buzzed_player_id FROM game_sessions WHERE id = ?`
+
+	session, err := scanGameSession(r.db.QueryRow(query, sessionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("game session not found")
+		}
+		return nil, fmt.Errorf("failed to get game session: %w", err)
+	}
+	return session, nil
+}
+
+// GetActiveGameSession アクティブなゲームセッションを取得
+func (r *SessionRepository) GetActiveGameSession(roomID string) (*models.GameSession, error) {
+	query := `SELECT id, room_id, match_id, question_number, question_id, question_revision, started_at, ended_at, status, buzzed_player_id
+			  FROM game_sessions
+			  WHERE room_id = ? AND status IN ('waiting', 'question', 'buzzed')
+			  ORDER BY started_at DESC LIMIT 1`
+
+	session, err := scanGameSession(r.db.QueryRow(query, roomID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no active game session found")
+		}
+		return nil, fmt.Errorf("failed to get active game session: %w", err)
+	}
+	return session, nil
+}
+
+// scanGameSession ゲームセッションの1行を読み込む
+func scanGameSession(row *sql.Row) (*models.GameSession, error) {
+	var session models.GameSession
+	var matchID sql.NullString
+	var questionID sql.NullInt64
+	var questionRevision sql.NullInt64
+	var endedAt sql.NullTime
+	var buzzedPlayerID sql.NullString
+
+	err := row.Scan(
+		&session.ID, &session.RoomID, &matchID, &session.QuestionNumber, &questionID, &questionRevision, &session.StartedAt,
+		&endedAt, &session.Status, &buzzedPlayerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if matchID.Valid {
+		mid := matchID.String
+		session.MatchID = &mid
+	}
+	session.QuestionID = nullableInt(questionID)
+	session.QuestionRevision = nullableInt(questionRevision)
+	if endedAt.Valid {
+		session.EndedAt = &endedAt.Time
+	}
+	if buzzedPlayerID.Valid {
+		pid := buzzedPlayerID.String
+		session.BuzzedPlayerID = &pid
+	}
+
+	return &session, nil
+}
@@ -0,0 +1,84 @@
+package mysql
+
+import (
+	"fmt"
+
+	"quivra-backend/database"
+	"quivra-backend/repository"
+)
+
+var _ repository.Store = (*Store)(nil)
+
+// Store MySQL をバックエンドとするストレージ
+type Store struct {
+	db *database.DB
+}
+
+func NewStore(db *database.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) Rooms() repository.RoomRepository {
+	return &RoomRepository{db: s.db}
+}
+
+func (s *Store) Players() repository.PlayerRepository {
+	return &PlayerRepository{db: s.db}
+}
+
+func (s *Store) Teams() repository.TeamRepository {
+	return &TeamRepository{db: s.db}
+}
+
+func (s *Store) Questions() repository.QuestionRepository {
+	return &QuestionRepository{db: s.db}
+}
+
+func (s *Store) Packs() repository.PackRepository {
+	return &PackRepository{db: s.db}
+}
+
+func (s *Store) Sessions() repository.SessionRepository {
+	return &SessionRepository{db: s.db}
+}
+
+func (s *Store) BuzzQueue() repository.BuzzQueueRepository {
+	return &BuzzQueueRepository{db: s.db}
+}
+
+func (s *Store) PlayerSessions() repository.PlayerSessionRepository {
+	return &PlayerSessionRepository{db: s.db}
+}
+
+// ResetAll 全データを削除
+func (s *Store) ResetAll() error {
+	// 外部キー制約のため、子テーブルから順に削除する
+	tables := []string{
+		"buzz_queue",
+		"player_sessions",
+		"game_sessions",
+		"match_questions",
+		"matches",
+		"players",
+		"room_bans",
+		"teams",
+		"rooms",
+		"question_pack_items",
+		"question_packs",
+		"question_tags",
+		"question_revisions",
+		"question_aliases",
+		"questions",
+	}
+
+	for _, table := range tables {
+		if _, err := s.db.Exec("DELETE FROM " + table); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
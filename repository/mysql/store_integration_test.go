@@ -0,0 +1,41 @@
+//go:build integration
+
+package mysql
+
+import (
+	"os"
+	"testing"
+
+	"quivra-backend/database"
+	"quivra-backend/repository"
+	"quivra-backend/repository/repositorytest"
+)
+
+// TestStore DB_HOST などで指定した MySQL に対して実行する（全データを削除するため、テスト用のデータベースを指定すること）
+func TestStore(t *testing.T) {
+	if os.Getenv("DB_HOST") == "" {
+		t.Skip("DB_HOST is not set")
+	}
+
+	db, err := database.NewDB()
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	store := NewStore(db)
+	repositorytest.TestStore(t, func(t *testing.T) repository.Store {
+		if err := store.ResetAll(); err != nil {
+			t.Fatalf("failed to reset database: %v", err)
+		}
+		return store
+	})
+}
@@ -0,0 +1,70 @@
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+
+	"quivra-backend/database"
+	"quivra-backend/models"
+)
+
+type TeamRepository struct {
+	db *database.DB
+}
+
+// CreateTeam チームを作成
+func (r *TeamRepository) CreateTeam(team *models.Team) error {
+	query := `INSERT INTO teams (id, room_id, name) VALUES (?, ?, ?)`
+	_, err := r.db.Exec(query, team.ID, team.RoomID, team.Name)
+	if err != nil {
+		return fmt.Errorf("failed to create team: %w", err)
+	}
+	return nil
+}
+
+// GetTeam ルーム内のチームを取得
+func (r *TeamRepository) GetTeam(roomID, teamID string) (*models.Team, error) {
+	var team models.Team
+	query := `SELECT id, room_id, name, created_at FROM teams WHERE room_id = ? AND id = ?`
+	err := r.db.QueryRow(query, roomID, teamID).Scan(&team.ID, &team.RoomID, &team.Name, &team.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("team not found")
+		}
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+	return &team, nil
+}
+
+// GetRoomTeams ルームのチーム一覧を取得（作成順）
+func (r *TeamRepository) GetRoomTeams(roomID string) ([]models.Team, error) {
+	query := `SELECT id, room_id, name, created_at FROM teams WHERE room_id = ? ORDER BY created_at, id`
+	rows, err := r.db.Query(query, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query teams: %w", err)
+	}
+	defer rows.Close()
+
+	var teams []models.Team
+	for rows.Next() {
+		var team models.Team
+		if err := rows.Scan(&team.ID, &team.RoomID, &team.Name, &team.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan team: %w", err)
+		}
+		teams = append(teams, team)
+	}
+	return teams, nil
+}
+
+// DeleteTeam チームを削除（所属プレイヤーは外部キーの ON DELETE SET NULL でチームから外れる）
+func (r *TeamRepository) DeleteTeam(roomID, teamID string) error {
+	query := `DELETE FROM teams WHERE room_id = ? AND id = ?`
+	result, err := r.db.Exec(query, roomID, teamID)
+	if err != nil {
+		return fmt.Errorf("failed to delete team: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("team not found")
+	}
+	return nil
+}
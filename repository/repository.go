@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"quivra-backend/models"
+)
+
+// ErrNoRemainingQuestions マッチの全問題が出題済み
+var ErrNoRemainingQuestions = errors.New("match has no remaining questions")
+
+// ErrQuestionNotFound 問題が存在しない（更新・削除では論理削除済みの問題も含む）
+var ErrQuestionNotFound = errors.New("question not found")
+
+// ErrQuestionRevisionConflict 問題を読み込んだ後に他の編集で版が進んでいた
+var ErrQuestionRevisionConflict = errors.New("question has been modified")
+
+// ErrPackNotFound 問題セットが存在しない
+var ErrPackNotFound = errors.New("question pack not found")
+
+// ErrSessionStatusChanged ゲームセッションの状態が想定と異なり、更新しなかった（他のイベントが先に状態を変えた）
+var ErrSessionStatusChanged = errors.New("game session status has changed")
+
+// RoomRepository ルームの永続化
+type RoomRepository interface {
+	CreateRoom(room *models.Room) error
+	GetRoom(roomID string) (*models.Room, error)
+	GetPublicRooms() ([]models.Room, error)
+	UpdateRoomStatus(roomID, status string) error
+	DeleteRoom(roomID string) error
+	// AddBan 参加禁止を登録する（同じ名前が既に禁止されていれば何もしない）
+	AddBan(ban *models.RoomBan) error
+	IsNameBanned(roomID, name string) (bool, error)
+	// IsPlayerBanned プレイヤーを指定した参加禁止があるか（プレイヤーは削除済みでもよい）
+	IsPlayerBanned(roomID, playerID string) (bool, error)
+	// GetRoomBans ルームの参加禁止を登録順に取得
+	GetRoomBans(roomID string) ([]models.RoomBan, error)
+}
+
+// PlayerRepository プレイヤーの永続化
+type PlayerRepository interface {
+	CreatePlayer(player *models.Player) error
+	GetPlayer(roomID, playerID string) (*models.Player, error)
+	GetRoomPlayers(roomID string) ([]models.Player, error)
+	UpdatePlayerScore(playerID string, score int) error
+	GetRoomRanking(roomID string) ([]models.RoomRanking, error)
+	// SetPlayerTeam プレイヤーの所属チームを変更（nil でチームから外す）
+	SetPlayerTeam(roomID, playerID string, teamID *string) error
+	SetPlayerMuted(roomID, playerID string, muted bool) error
+	// DeletePlayer プレイヤーをルームから削除する（セッション・回答キューも連動して削除される）
+	DeletePlayer(roomID, playerID string) error
+}
+
+// TeamRepository チームの永続化
+type TeamRepository interface {
+	CreateTeam(team *models.Team) error
+	GetTeam(roomID, teamID string) (*models.Team, error)
+	GetRoomTeams(roomID string) ([]models.Team, error)
+	// DeleteTeam チームを削除し、所属していたプレイヤーをチームから外す
+	DeleteTeam(roomID, teamID string) error
+}
+
+// 問題一覧の並び順
+const (
+	QuestionSortCreatedAt = "created_at"
+	QuestionSortUpdatedAt = "updated_at" // 編集していない問題は作成日時
+	QuestionSortID        = "id"
+)
+
+// QuestionCursor 並び順のキーとIDで表した一覧の位置（この位置より後を取得する）
+type QuestionCursor struct {
+	At time.Time // QuestionSortID では使わない
+	ID int
+}
+
+// QuestionQuery 論理削除していない問題の検索条件（空の項目は絞り込まない）
+type QuestionQuery struct {
+	Terms         []string // すべてを問題文（SearchAnswers なら問題文または答え）に含む
+	SearchAnswers bool     // 検索語を答えにも照合する（正解を見られない利用者に答えを探らせないため、既定は問題文のみ）
+	Categories    []string // いずれかに一致
+	Difficulties  []string // いずれかに一致
+	Tags          []string // すべてを持つ
+	Sort          string
+	Descending    bool
+	After         *QuestionCursor
+	Limit         int
+}
+
+// QuestionRepository 問題・別解・編集履歴の永続化
+type QuestionRepository interface {
+	// CreateQuestion 問題と別解・タグをまとめて登録し、採番したIDを question に設定する
+	CreateQuestion(question *models.Question) error
+	// CreateQuestions 複数の問題を1つのトランザクションで登録する（1件でも失敗すれば何も登録しない）
+	CreateQuestions(questions []*models.Question) error
+	// GetQuestions 論理削除していない問題を新しい順に取得する
+	GetQuestions(category, difficulty string) ([]models.Question, error)
+	// GetQuestion 論理削除した問題も返す（出題済みのマッチから参照するため）。存在しなければ ErrQuestionNotFound
+	GetQuestion(id int) (*models.Question, error)
+	GetRandomQuestion(category, difficulty string) (*models.Question, error)
+	// SearchQuestions 条件に合う問題を After の後から Limit 件取得し、条件に合う全件数（After・Limit を除く）も返す
+	SearchQuestions(query QuestionQuery) ([]models.Question, int, error)
+	// SetQuestionTags 問題のタグを置き換える（版は進めない）
+	SetQuestionTags(questionID int, tags []string) error
+	// UpdateQuestion 現在の版を履歴に残し、問題・別解・タグを question の内容で置き換えて版を1つ進める。
+	// question.Revision は編集元の版で、現在の版と異なれば ErrQuestionRevisionConflict。
+	// 存在しない・論理削除済みなら ErrQuestionNotFound
+	UpdateQuestion(question *models.Question) error
+	// DeleteQuestion 論理削除する。存在しない・削除済みなら ErrQuestionNotFound
+	DeleteQuestion(id int) error
+	// RestoreQuestion 論理削除を取り消す。存在しない・削除していなければ ErrQuestionNotFound
+	RestoreQuestion(id int) error
+	// GetQuestionRevisions 置き換えられた過去の版を古い順に取得する（最新版は含まない）
+	GetQuestionRevisions(questionID int) ([]models.QuestionRevision, error)
+}
+
+// PackRepository 問題セットと収録する問題（並び順付き）の永続化
+type PackRepository interface {
+	// CreatePack 問題セットを登録し、採番したIDを pack に設定する
+	CreatePack(pack *models.QuestionPack) error
+	GetPacks() ([]models.QuestionPack, error)
+	// GetPack 存在しなければ ErrPackNotFound
+	GetPack(id int) (*models.QuestionPack, error)
+	// UpdatePack 名前・説明・出題順と収録する問題を置き換える。存在しなければ ErrPackNotFound
+	UpdatePack(pack *models.QuestionPack) error
+	DeletePack(id int) error
+}
+
+// SessionRepository マッチとゲームセッションの永続化
+type SessionRepository interface {
+	// CreateMatch マッチと出題順（match.QuestionIDs）を登録する
+	CreateMatch(match *models.Match) error
+	GetActiveMatch(roomID string) (*models.Match, error)
+	// AdvanceMatch 問題番号を1つ進めて新しい番号を返す。残りがなければ ErrNoRemainingQuestions
+	AdvanceMatch(matchID string) (int, error)
+	// FinishMatch マッチと回答中のセッションを終了する
+	FinishMatch(matchID string) error
+	GetMatchSummary(matchID string) (*models.MatchSummary, error)
+	// GetMatchQuestionID マッチの number 問目（1始まり）に出題する問題のID
+	GetMatchQuestionID(matchID string, number int) (int, error)
+
+	CreateGameSession(session *models.GameSession) error
+	GetGameSession(sessionID string) (*models.GameSession, error)
+	GetActiveGameSession(roomID string) (*models.GameSession, error)
+	// UpdateGameSessionStatus 現在の状態が from の場合のみ to に更新する（UPDATE ... WHERE status = ?）。
+	// 状態が一致しなければ ErrSessionStatusChanged
+	UpdateGameSessionStatus(sessionID, from, to string, change models.GameSessionChange) error
+}
+
+// BuzzQueueRepository 回答キューの永続化
+type BuzzQueueRepository interface {
+	AddToQueue(entry *models.BuzzQueue) error
+	GetQueue(roomID string) ([]models.BuzzQueue, error)
+	GetNextPlayer(roomID string) (*models.BuzzQueue, error)
+	RemoveFromQueue(roomID, playerID string) error
+	ClearQueue(roomID string) error
+	IsPlayerInQueue(roomID, playerID string) (bool, error)
+	// GetBuzzHistory キューから外れたものも含めたルームの早押しの記録（受信順）
+	GetBuzzHistory(roomID string) ([]models.BuzzQueue, error)
+}
+
+// PlayerSessionRepository セッショントークンの発行記録と失効の永続化
+type PlayerSessionRepository interface {
+	CreatePlayerSession(session *models.PlayerSession) error
+	GetPlayerSession(sessionID string) (*models.PlayerSession, error)
+	RevokePlayerSession(sessionID string) error
+	// RevokePlayerSessions プレイヤーの有効なセッションをすべて失効させる
+	RevokePlayerSessions(playerID string) error
+}
+
+// Store ストレージの実装（MySQL / インメモリ）ごとに各リポジトリをまとめたもの
+type Store interface {
+	Rooms() RoomRepository
+	Players() PlayerRepository
+	Teams() TeamRepository
+	Questions() QuestionRepository
+	Packs() PackRepository
+	Sessions() SessionRepository
+	BuzzQueue() BuzzQueueRepository
+	PlayerSessions() PlayerSessionRepository
+
+	// ResetAll 全データを削除
+	ResetAll() error
+	Close() error
+}
@@ -0,0 +1,677 @@
+// Package repositorytest ストレージの実装（MySQL / インメモリ）が repository の取り決めどおりに動くかを確かめる共通のテスト
+package repositorytest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"quivra-backend/models"
+	"quivra-backend/repository"
+)
+
+// TestStore newStore が返す空のストアに対して repository の各インターフェースの取り決めを確かめる。
+// newStore はサブテストごとに呼ばれ、データが空のストアを返すこと
+func TestStore(t *testing.T, newStore func(t *testing.T) repository.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store repository.Store)
+	}{
+		{"Rooms", testRooms},
+		{"Bans", testBans},
+		{"Players", testPlayers},
+		{"Teams", testTeams},
+		{"Questions", testQuestions},
+		{"QuestionSearch", testQuestionSearch},
+		{"Packs", testPacks},
+		{"Sessions", testSessions},
+		{"BuzzQueue", testBuzzQueue},
+		{"PlayerSessions", testPlayerSessions},
+		{"DeleteRoom", testDeleteRoom},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+// mustCreateRoom テスト用のルームを作成
+func mustCreateRoom(t *testing.T, store repository.Store, roomID string, public bool) {
+	t.Helper()
+	room := &models.Room{ID: roomID, Name: "room " + roomID, Status: "waiting", IsPublic: public, CreatedBy: "host"}
+	if err := store.Rooms().CreateRoom(room); err != nil {
+		t.Fatalf("CreateRoom(%s): %v", roomID, err)
+	}
+}
+
+// mustCreatePlayer テスト用のプレイヤーを作成
+func mustCreatePlayer(t *testing.T, store repository.Store, roomID, playerID string) {
+	t.Helper()
+	player := &models.Player{ID: playerID, RoomID: roomID, Name: "player " + playerID}
+	if err := store.Players().CreatePlayer(player); err != nil {
+		t.Fatalf("CreatePlayer(%s): %v", playerID, err)
+	}
+}
+
+// mustCreateQuestion テスト用の問題を登録
+func mustCreateQuestion(t *testing.T, store repository.Store, question *models.Question) {
+	t.Helper()
+	if err := store.Questions().CreateQuestion(question); err != nil {
+		t.Fatalf("CreateQuestion(%q): %v", question.Question, err)
+	}
+}
+
+func testRooms(t *testing.T, store repository.Store) {
+	mustCreateRoom(t, store, "ROOM01", true)
+	mustCreateRoom(t, store, "ROOM02", false)
+
+	if err := store.Rooms().CreateRoom(&models.Room{ID: "ROOM01", Name: "dup", Status: "waiting"}); err == nil {
+		t.Error("CreateRoom with a duplicate ID succeeded")
+	}
+
+	room, err := store.Rooms().GetRoom("ROOM01")
+	if err != nil {
+		t.Fatalf("GetRoom: %v", err)
+	}
+	if room.Name != "room ROOM01" || !room.IsPublic || room.CreatedAt.IsZero() {
+		t.Errorf("GetRoom = %+v", room)
+	}
+	if _, err := store.Rooms().GetRoom("NOROOM"); err == nil {
+		t.Error("GetRoom of an unknown room succeeded")
+	}
+
+	public, err := store.Rooms().GetPublicRooms()
+	if err != nil {
+		t.Fatalf("GetPublicRooms: %v", err)
+	}
+	if len(public) != 1 || public[0].ID != "ROOM01" {
+		t.Errorf("GetPublicRooms = %+v, want only ROOM01", public)
+	}
+
+	// 待機中でなくなったルームは公開ルーム一覧に出さない
+	if err := store.Rooms().UpdateRoomStatus("ROOM01", "playing"); err != nil {
+		t.Fatalf("UpdateRoomStatus: %v", err)
+	}
+	if room, _ := store.Rooms().GetRoom("ROOM01"); room == nil || room.Status != "playing" {
+		t.Errorf("status after UpdateRoomStatus = %+v", room)
+	}
+	if public, _ := store.Rooms().GetPublicRooms(); len(public) != 0 {
+		t.Errorf("GetPublicRooms after start = %+v, want none", public)
+	}
+}
+
+func testBans(t *testing.T, store repository.Store) {
+	mustCreateRoom(t, store, "ROOM01", false)
+	mustCreateRoom(t, store, "ROOM02", false)
+
+	for i := 0; i < 2; i++ {
+		if err := store.Rooms().AddBan(&models.RoomBan{RoomID: "ROOM01", Name: "troll"}); err != nil {
+			t.Fatalf("AddBan #%d: %v", i+1, err)
+		}
+	}
+	playerID := "p-9"
+	if err := store.Rooms().AddBan(&models.RoomBan{RoomID: "ROOM01", Name: "spammer", PlayerID: &playerID}); err != nil {
+		t.Fatalf("AddBan: %v", err)
+	}
+
+	bans, err := store.Rooms().GetRoomBans("ROOM01")
+	if err != nil {
+		t.Fatalf("GetRoomBans: %v", err)
+	}
+	if len(bans) != 2 || bans[0].Name != "troll" || bans[1].Name != "spammer" {
+		t.Errorf("GetRoomBans = %+v, want troll then spammer once each", bans)
+	} else if bans[0].PlayerID != nil || bans[1].PlayerID == nil || *bans[1].PlayerID != playerID {
+		t.Errorf("GetRoomBans player ids = %v, %v; want none and %s", bans[0].PlayerID, bans[1].PlayerID, playerID)
+	}
+
+	if banned, err := store.Rooms().IsNameBanned("ROOM01", "troll"); err != nil || !banned {
+		t.Errorf("IsNameBanned(ROOM01, troll) = %v, %v; want true", banned, err)
+	}
+	if banned, err := store.Rooms().IsNameBanned("ROOM02", "troll"); err != nil || banned {
+		t.Errorf("IsNameBanned(ROOM02, troll) = %v, %v; want false", banned, err)
+	}
+
+	if banned, err := store.Rooms().IsPlayerBanned("ROOM01", playerID); err != nil || !banned {
+		t.Errorf("IsPlayerBanned(ROOM01, %s) = %v, %v; want true", playerID, banned, err)
+	}
+	if banned, err := store.Rooms().IsPlayerBanned("ROOM02", playerID); err != nil || banned {
+		t.Errorf("IsPlayerBanned(ROOM02, %s) = %v, %v; want false", playerID, banned, err)
+	}
+	if banned, err := store.Rooms().IsPlayerBanned("ROOM01", "p-1"); err != nil || banned {
+		t.Errorf("IsPlayerBanned(ROOM01, p-1) = %v, %v; want false", banned, err)
+	}
+}
+
+func testPlayers(t *testing.T, store repository.Store) {
+	mustCreateRoom(t, store, "ROOM01", false)
+	mustCreateRoom(t, store, "ROOM02", false)
+
+	if err := store.Players().CreatePlayer(&models.Player{ID: "p-orphan", RoomID: "NOROOM", Name: "orphan"}); err == nil {
+		t.Error("CreatePlayer in an unknown room succeeded")
+	}
+
+	mustCreatePlayer(t, store, "ROOM01", "p-1")
+	mustCreatePlayer(t, store, "ROOM01", "p-2")
+	mustCreatePlayer(t, store, "ROOM01", "p-3")
+
+	if _, err := store.Players().GetPlayer("ROOM02", "p-1"); err == nil {
+		t.Error("GetPlayer found a player through another room")
+	}
+
+	if err := store.Players().UpdatePlayerScore("p-2", 30); err != nil {
+		t.Fatalf("UpdatePlayerScore: %v", err)
+	}
+	if err := store.Players().UpdatePlayerScore("p-3", 10); err != nil {
+		t.Fatalf("UpdatePlayerScore: %v", err)
+	}
+
+	ranking, err := store.Players().GetRoomRanking("ROOM01")
+	if err != nil {
+		t.Fatalf("GetRoomRanking: %v", err)
+	}
+	want := []string{"p-2", "p-3", "p-1"}
+	if len(ranking) != len(want) {
+		t.Fatalf("GetRoomRanking = %+v, want %v", ranking, want)
+	}
+	for i, id := range want {
+		if ranking[i].PlayerID != id || ranking[i].Rank != i+1 {
+			t.Errorf("ranking[%d] = %+v, want %s at rank %d", i, ranking[i], id, i+1)
+		}
+	}
+
+	if err := store.Players().SetPlayerMuted("ROOM01", "p-1", true); err != nil {
+		t.Fatalf("SetPlayerMuted: %v", err)
+	}
+	if player, _ := store.Players().GetPlayer("ROOM01", "p-1"); player == nil || !player.Muted {
+		t.Errorf("player after SetPlayerMuted = %+v, want muted", player)
+	}
+	if err := store.Players().SetPlayerMuted("ROOM02", "p-1", true); err == nil {
+		t.Error("SetPlayerMuted through another room succeeded")
+	}
+
+	if err := store.Players().DeletePlayer("ROOM01", "p-1"); err != nil {
+		t.Fatalf("DeletePlayer: %v", err)
+	}
+	if err := store.Players().DeletePlayer("ROOM01", "p-1"); err == nil {
+		t.Error("DeletePlayer of a deleted player succeeded")
+	}
+	if players, _ := store.Players().GetRoomPlayers("ROOM01"); len(players) != 2 {
+		t.Errorf("GetRoomPlayers after delete = %+v, want 2 players", players)
+	}
+}
+
+func testTeams(t *testing.T, store repository.Store) {
+	mustCreateRoom(t, store, "ROOM01", false)
+	mustCreatePlayer(t, store, "ROOM01", "p-1")
+
+	team := &models.Team{ID: "team-red", RoomID: "ROOM01", Name: "Red"}
+	if err := store.Teams().CreateTeam(team); err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+	if err := store.Teams().CreateTeam(&models.Team{ID: "team-red-2", RoomID: "ROOM01", Name: "Red"}); err == nil {
+		t.Error("CreateTeam with a duplicate name succeeded")
+	}
+
+	teamID := team.ID
+	if err := store.Players().SetPlayerTeam("ROOM01", "p-1", &teamID); err != nil {
+		t.Fatalf("SetPlayerTeam: %v", err)
+	}
+	if player, _ := store.Players().GetPlayer("ROOM01", "p-1"); player == nil || player.TeamID == nil || *player.TeamID != teamID {
+		t.Errorf("player after SetPlayerTeam = %+v", player)
+	}
+
+	// チームを削除すると所属していたプレイヤーはチームから外れる
+	if err := store.Teams().DeleteTeam("ROOM01", teamID); err != nil {
+		t.Fatalf("DeleteTeam: %v", err)
+	}
+	if _, err := store.Teams().GetTeam("ROOM01", teamID); err == nil {
+		t.Error("GetTeam found a deleted team")
+	}
+	if player, _ := store.Players().GetPlayer("ROOM01", "p-1"); player == nil || player.TeamID != nil {
+		t.Errorf("player after DeleteTeam = %+v, want no team", player)
+	}
+	if err := store.Teams().DeleteTeam("ROOM01", teamID); err == nil {
+		t.Error("DeleteTeam of a deleted team succeeded")
+	}
+}
+
+func testQuestions(t *testing.T, store repository.Store) {
+	tolerance := 1
+	question := &models.Question{
+		Question:        "What is the capital of France?",
+		Answer:          "Paris",
+		AnswerTolerance: &tolerance,
+		Aliases:         []string{"パリ"},
+		Category:        "geography",
+		Difficulty:      "easy",
+		Tags:            []string{"europe"},
+	}
+	mustCreateQuestion(t, store, question)
+	if question.ID == 0 || question.Revision != 1 {
+		t.Fatalf("CreateQuestion set ID %d revision %d, want a new ID at revision 1", question.ID, question.Revision)
+	}
+
+	stale := *question
+	question.Answer = "Paris, France"
+	question.Aliases = []string{"パリ", "Lutetia"}
+	if err := store.Questions().UpdateQuestion(question); err != nil {
+		t.Fatalf("UpdateQuestion: %v", err)
+	}
+	if question.Revision != 2 {
+		t.Errorf("revision after UpdateQuestion = %d, want 2", question.Revision)
+	}
+
+	// 読み込んだ後に他の編集で版が進んでいれば上書きしない
+	stale.Answer = "Lyon"
+	if err := store.Questions().UpdateQuestion(&stale); !errors.Is(err, repository.ErrQuestionRevisionConflict) {
+		t.Errorf("UpdateQuestion with a stale revision = %v, want ErrQuestionRevisionConflict", err)
+	}
+
+	stored, err := store.Questions().GetQuestion(question.ID)
+	if err != nil {
+		t.Fatalf("GetQuestion: %v", err)
+	}
+	if stored.Answer != "Paris, France" || len(stored.Aliases) != 2 || stored.Revision != 2 {
+		t.Errorf("GetQuestion = %+v", stored)
+	}
+	if stored.AnswerTolerance == nil || *stored.AnswerTolerance != 1 {
+		t.Errorf("AnswerTolerance = %v, want 1", stored.AnswerTolerance)
+	}
+
+	revisions, err := store.Questions().GetQuestionRevisions(question.ID)
+	if err != nil {
+		t.Fatalf("GetQuestionRevisions: %v", err)
+	}
+	if len(revisions) != 1 || revisions[0].Revision != 1 || revisions[0].Answer != "Paris" || revisions[0].ReplacedAt == nil {
+		t.Errorf("GetQuestionRevisions = %+v, want only revision 1 with the old answer", revisions)
+	}
+
+	if _, err := store.Questions().GetQuestion(question.ID + 1000); !errors.Is(err, repository.ErrQuestionNotFound) {
+		t.Errorf("GetQuestion of an unknown ID = %v, want ErrQuestionNotFound", err)
+	}
+
+	// 論理削除した問題は一覧に出さないが、ID を指定すれば取得できる
+	if err := store.Questions().DeleteQuestion(question.ID); err != nil {
+		t.Fatalf("DeleteQuestion: %v", err)
+	}
+	if err := store.Questions().DeleteQuestion(question.ID); !errors.Is(err, repository.ErrQuestionNotFound) {
+		t.Errorf("DeleteQuestion twice = %v, want ErrQuestionNotFound", err)
+	}
+	if questions, _ := store.Questions().GetQuestions("", ""); len(questions) != 0 {
+		t.Errorf("GetQuestions after delete = %+v, want none", questions)
+	}
+	if deleted, err := store.Questions().GetQuestion(question.ID); err != nil || deleted.DeletedAt == nil {
+		t.Errorf("GetQuestion of a deleted question = %+v, %v; want it with DeletedAt", deleted, err)
+	}
+	if err := store.Questions().UpdateQuestion(question); !errors.Is(err, repository.ErrQuestionNotFound) {
+		t.Errorf("UpdateQuestion of a deleted question = %v, want ErrQuestionNotFound", err)
+	}
+
+	if err := store.Questions().RestoreQuestion(question.ID); err != nil {
+		t.Fatalf("RestoreQuestion: %v", err)
+	}
+	if err := store.Questions().RestoreQuestion(question.ID); !errors.Is(err, repository.ErrQuestionNotFound) {
+		t.Errorf("RestoreQuestion of a live question = %v, want ErrQuestionNotFound", err)
+	}
+	if questions, _ := store.Questions().GetQuestions("geography", ""); len(questions) != 1 {
+		t.Errorf("GetQuestions after restore = %+v, want the question", questions)
+	}
+}
+
+func testQuestionSearch(t *testing.T, store repository.Store) {
+	questions := []*models.Question{
+		{Question: "Which planet is known as the red planet?", Answer: "Mars", Category: "science", Difficulty: "easy", Tags: []string{"space"}},
+		{Question: "Which planet has the largest moon?", Answer: "Jupiter", Category: "science", Difficulty: "medium", Tags: []string{"space", "moons"}},
+		{Question: "Who painted the Mona Lisa?", Answer: "Leonardo da Vinci", Category: "art", Difficulty: "easy"},
+	}
+	if err := store.Questions().CreateQuestions(questions); err != nil {
+		t.Fatalf("CreateQuestions: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query repository.QuestionQuery
+		want  int
+	}{
+		{"all", repository.QuestionQuery{}, 3},
+		{"question text", repository.QuestionQuery{Terms: []string{"planet"}}, 2},
+		{"every term", repository.QuestionQuery{Terms: []string{"planet", "moon"}}, 1},
+		{"answer hidden by default", repository.QuestionQuery{Terms: []string{"jupiter"}}, 0},
+		{"answer with SearchAnswers", repository.QuestionQuery{Terms: []string{"jupiter"}, SearchAnswers: true}, 1},
+		{"category", repository.QuestionQuery{Categories: []string{"art"}}, 1},
+		{"difficulty", repository.QuestionQuery{Difficulties: []string{"easy", "hard"}}, 2},
+		{"every tag", repository.QuestionQuery{Tags: []string{"space", "moons"}}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.query.Sort = repository.QuestionSortID
+			tt.query.Limit = 10
+			found, total, err := store.Questions().SearchQuestions(tt.query)
+			if err != nil {
+				t.Fatalf("SearchQuestions: %v", err)
+			}
+			if total != tt.want || len(found) != tt.want {
+				t.Errorf("SearchQuestions found %d of %d, want %d", len(found), total, tt.want)
+			}
+		})
+	}
+
+	// After・Limit でページを進めても全件数は変わらない
+	page, total, err := store.Questions().SearchQuestions(repository.QuestionQuery{Sort: repository.QuestionSortID, Limit: 2})
+	if err != nil {
+		t.Fatalf("SearchQuestions: %v", err)
+	}
+	if len(page) != 2 || total != 3 || page[0].ID != questions[0].ID {
+		t.Fatalf("first page = %d questions of %d", len(page), total)
+	}
+	next, total, err := store.Questions().SearchQuestions(repository.QuestionQuery{
+		Sort:  repository.QuestionSortID,
+		After: &repository.QuestionCursor{ID: page[1].ID},
+		Limit: 2,
+	})
+	if err != nil {
+		t.Fatalf("SearchQuestions: %v", err)
+	}
+	if len(next) != 1 || total != 3 || next[0].ID != questions[2].ID {
+		t.Errorf("second page = %+v of %d, want only question %d", next, total, questions[2].ID)
+	}
+}
+
+func testPacks(t *testing.T, store repository.Store) {
+	first := &models.Question{Question: "First question", Answer: "one", Category: "general", Difficulty: "easy"}
+	second := &models.Question{Question: "Second question", Answer: "two", Category: "general", Difficulty: "easy"}
+	mustCreateQuestion(t, store, first)
+	mustCreateQuestion(t, store, second)
+
+	pack := &models.QuestionPack{Name: "Warm-up", QuestionIDs: []int{second.ID, first.ID}}
+	if err := store.Packs().CreatePack(pack); err != nil {
+		t.Fatalf("CreatePack: %v", err)
+	}
+
+	stored, err := store.Packs().GetPack(pack.ID)
+	if err != nil {
+		t.Fatalf("GetPack: %v", err)
+	}
+	if len(stored.QuestionIDs) != 2 || stored.QuestionIDs[0] != second.ID || stored.QuestionIDs[1] != first.ID {
+		t.Errorf("QuestionIDs = %v, want registration order [%d %d]", stored.QuestionIDs, second.ID, first.ID)
+	}
+
+	pack.Name = "Finals"
+	pack.Shuffle = true
+	pack.QuestionIDs = []int{first.ID}
+	if err := store.Packs().UpdatePack(pack); err != nil {
+		t.Fatalf("UpdatePack: %v", err)
+	}
+	if stored, _ := store.Packs().GetPack(pack.ID); stored == nil || stored.Name != "Finals" || !stored.Shuffle || len(stored.QuestionIDs) != 1 {
+		t.Errorf("GetPack after update = %+v", stored)
+	}
+
+	if err := store.Packs().DeletePack(pack.ID); err != nil {
+		t.Fatalf("DeletePack: %v", err)
+	}
+	if _, err := store.Packs().GetPack(pack.ID); !errors.Is(err, repository.ErrPackNotFound) {
+		t.Errorf("GetPack after delete = %v, want ErrPackNotFound", err)
+	}
+	if err := store.Packs().UpdatePack(pack); !errors.Is(err, repository.ErrPackNotFound) {
+		t.Errorf("UpdatePack after delete = %v, want ErrPackNotFound", err)
+	}
+}
+
+func testSessions(t *testing.T, store repository.Store) {
+	mustCreateRoom(t, store, "ROOM01", false)
+	mustCreatePlayer(t, store, "ROOM01", "p-1")
+	question := &models.Question{Question: "Sessions question", Answer: "answer", Category: "general", Difficulty: "easy"}
+	mustCreateQuestion(t, store, question)
+
+	match := &models.Match{ID: "match-1", RoomID: "ROOM01", TotalQuestions: 1, Status: "playing", QuestionIDs: []int{question.ID}}
+	if err := store.Sessions().CreateMatch(match); err != nil {
+		t.Fatalf("CreateMatch: %v", err)
+	}
+	if active, err := store.Sessions().GetActiveMatch("ROOM01"); err != nil || active.ID != match.ID {
+		t.Fatalf("GetActiveMatch = %+v, %v", active, err)
+	}
+	if id, err := store.Sessions().GetMatchQuestionID(match.ID, 1); err != nil || id != question.ID {
+		t.Errorf("GetMatchQuestionID(1) = %d, %v; want %d", id, err, question.ID)
+	}
+
+	number, err := store.Sessions().AdvanceMatch(match.ID)
+	if err != nil || number != 1 {
+		t.Fatalf("AdvanceMatch = %d, %v; want 1", number, err)
+	}
+	if _, err := store.Sessions().AdvanceMatch(match.ID); !errors.Is(err, repository.ErrNoRemainingQuestions) {
+		t.Errorf("AdvanceMatch past the last question = %v, want ErrNoRemainingQuestions", err)
+	}
+
+	matchID := match.ID
+	session := &models.GameSession{ID: "session-1", RoomID: "ROOM01", MatchID: &matchID, QuestionNumber: number, Status: "waiting"}
+	if err := store.Sessions().CreateGameSession(session); err != nil {
+		t.Fatalf("CreateGameSession: %v", err)
+	}
+
+	questionID := question.ID
+	if err := store.Sessions().UpdateGameSessionStatus(session.ID, "waiting", "question", models.GameSessionChange{QuestionID: &questionID}); err != nil {
+		t.Fatalf("UpdateGameSessionStatus waiting→question: %v", err)
+	}
+	// 他のイベントが先に状態を変えていれば更新しない
+	if err := store.Sessions().UpdateGameSessionStatus(session.ID, "waiting", "question", models.GameSessionChange{}); !errors.Is(err, repository.ErrSessionStatusChanged) {
+		t.Errorf("UpdateGameSessionStatus from a stale status = %v, want ErrSessionStatusChanged", err)
+	}
+
+	playerID := "p-1"
+	if err := store.Sessions().UpdateGameSessionStatus(session.ID, "question", "buzzed", models.GameSessionChange{BuzzedPlayerID: &playerID}); err != nil {
+		t.Fatalf("UpdateGameSessionStatus question→buzzed: %v", err)
+	}
+	active, err := store.Sessions().GetActiveGameSession("ROOM01")
+	if err != nil {
+		t.Fatalf("GetActiveGameSession: %v", err)
+	}
+	if active.Status != "buzzed" || active.QuestionID == nil || *active.QuestionID != questionID || active.BuzzedPlayerID == nil || *active.BuzzedPlayerID != playerID {
+		t.Errorf("GetActiveGameSession = %+v", active)
+	}
+
+	endedAt := time.Now()
+	if err := store.Sessions().UpdateGameSessionStatus(session.ID, "buzzed", "answered", models.GameSessionChange{EndedAt: &endedAt}); err != nil {
+		t.Fatalf("UpdateGameSessionStatus buzzed→answered: %v", err)
+	}
+	if _, err := store.Sessions().GetActiveGameSession("ROOM01"); err == nil {
+		t.Error("GetActiveGameSession returned an answered session")
+	}
+
+	summary, err := store.Sessions().GetMatchSummary(match.ID)
+	if err != nil {
+		t.Fatalf("GetMatchSummary: %v", err)
+	}
+	if summary.AskedQuestions != 1 || len(summary.Results) != 1 {
+		t.Fatalf("GetMatchSummary = %+v, want 1 result", summary)
+	}
+	result := summary.Results[0]
+	if result.Question != question.Question || result.CorrectAnswer != question.Answer || result.AnsweredBy == nil || *result.AnsweredBy != playerID {
+		t.Errorf("match result = %+v", result)
+	}
+
+	// マッチを終了すると回答中のセッションも終了する
+	open := &models.GameSession{ID: "session-2", RoomID: "ROOM01", MatchID: &matchID, QuestionNumber: 2, Status: "question"}
+	if err := store.Sessions().CreateGameSession(open); err != nil {
+		t.Fatalf("CreateGameSession: %v", err)
+	}
+	if err := store.Sessions().FinishMatch(match.ID); err != nil {
+		t.Fatalf("FinishMatch: %v", err)
+	}
+	if _, err := store.Sessions().GetActiveMatch("ROOM01"); err == nil {
+		t.Error("GetActiveMatch returned a finished match")
+	}
+	if finished, err := store.Sessions().GetGameSession(open.ID); err != nil || finished.Status != "finished" || finished.EndedAt == nil {
+		t.Errorf("session after FinishMatch = %+v, %v; want finished", finished, err)
+	}
+}
+
+func testBuzzQueue(t *testing.T, store repository.Store) {
+	mustCreateRoom(t, store, "ROOM01", false)
+	for _, id := range []string{"p-1", "p-2", "p-3"} {
+		mustCreatePlayer(t, store, "ROOM01", id)
+	}
+
+	// キューは受信順ではなく補正後の押下時刻順
+	base := time.Now().Truncate(time.Second)
+	buzzes := []struct {
+		playerID string
+		pressed  time.Duration
+	}{
+		{"p-1", 2 * time.Second},
+		{"p-2", 0},
+		{"p-3", time.Second},
+	}
+	for i, buzz := range buzzes {
+		entry := &models.BuzzQueue{
+			ID:        "buzz-" + buzz.playerID,
+			RoomID:    "ROOM01",
+			PlayerID:  buzz.playerID,
+			BuzzedAt:  base.Add(time.Duration(i) * time.Second),
+			PressedAt: base.Add(buzz.pressed),
+			IsActive:  true,
+		}
+		if err := store.BuzzQueue().AddToQueue(entry); err != nil {
+			t.Fatalf("AddToQueue(%s): %v", buzz.playerID, err)
+		}
+	}
+
+	queue, err := store.BuzzQueue().GetQueue("ROOM01")
+	if err != nil {
+		t.Fatalf("GetQueue: %v", err)
+	}
+	want := []string{"p-2", "p-3", "p-1"}
+	if got := queuePlayerIDs(queue); !equalStrings(got, want) {
+		t.Errorf("GetQueue order = %v, want %v", got, want)
+	}
+	if next, err := store.BuzzQueue().GetNextPlayer("ROOM01"); err != nil || next.PlayerID != "p-2" {
+		t.Errorf("GetNextPlayer = %+v, %v; want p-2", next, err)
+	}
+
+	if err := store.BuzzQueue().RemoveFromQueue("ROOM01", "p-2"); err != nil {
+		t.Fatalf("RemoveFromQueue: %v", err)
+	}
+	if in, _ := store.BuzzQueue().IsPlayerInQueue("ROOM01", "p-2"); in {
+		t.Error("IsPlayerInQueue is true after RemoveFromQueue")
+	}
+	if in, _ := store.BuzzQueue().IsPlayerInQueue("ROOM01", "p-3"); !in {
+		t.Error("IsPlayerInQueue is false for a queued player")
+	}
+
+	// 削除したプレイヤーの早押しは記録からも消える
+	if err := store.Players().DeletePlayer("ROOM01", "p-3"); err != nil {
+		t.Fatalf("DeletePlayer: %v", err)
+	}
+	if queue, _ := store.BuzzQueue().GetQueue("ROOM01"); !equalStrings(queuePlayerIDs(queue), []string{"p-1"}) {
+		t.Errorf("GetQueue after DeletePlayer = %v, want [p-1]", queuePlayerIDs(queue))
+	}
+
+	if err := store.BuzzQueue().ClearQueue("ROOM01"); err != nil {
+		t.Fatalf("ClearQueue: %v", err)
+	}
+	if _, err := store.BuzzQueue().GetNextPlayer("ROOM01"); err == nil {
+		t.Error("GetNextPlayer succeeded on a cleared queue")
+	}
+
+	// キューから外れた早押しも記録には残る
+	history, err := store.BuzzQueue().GetBuzzHistory("ROOM01")
+	if err != nil {
+		t.Fatalf("GetBuzzHistory: %v", err)
+	}
+	if got := queuePlayerIDs(history); !equalStrings(got, []string{"p-1", "p-2"}) {
+		t.Errorf("GetBuzzHistory = %v, want [p-1 p-2] in arrival order", got)
+	}
+}
+
+func testPlayerSessions(t *testing.T, store repository.Store) {
+	mustCreateRoom(t, store, "ROOM01", false)
+	mustCreatePlayer(t, store, "ROOM01", "p-1")
+
+	expiresAt := time.Now().Add(time.Hour)
+	for _, id := range []string{"ps-1", "ps-2"} {
+		session := &models.PlayerSession{ID: id, PlayerID: "p-1", RoomID: "ROOM01", Role: models.SessionRolePlayer, ExpiresAt: expiresAt}
+		if err := store.PlayerSessions().CreatePlayerSession(session); err != nil {
+			t.Fatalf("CreatePlayerSession(%s): %v", id, err)
+		}
+	}
+
+	if err := store.PlayerSessions().RevokePlayerSession("ps-1"); err != nil {
+		t.Fatalf("RevokePlayerSession: %v", err)
+	}
+	if session, err := store.PlayerSessions().GetPlayerSession("ps-1"); err != nil || session.RevokedAt == nil {
+		t.Errorf("ps-1 after RevokePlayerSession = %+v, %v; want revoked", session, err)
+	}
+	if session, err := store.PlayerSessions().GetPlayerSession("ps-2"); err != nil || session.RevokedAt != nil {
+		t.Errorf("ps-2 after RevokePlayerSession(ps-1) = %+v, %v; want active", session, err)
+	}
+
+	if err := store.PlayerSessions().RevokePlayerSessions("p-1"); err != nil {
+		t.Fatalf("RevokePlayerSessions: %v", err)
+	}
+	if session, err := store.PlayerSessions().GetPlayerSession("ps-2"); err != nil || session.RevokedAt == nil {
+		t.Errorf("ps-2 after RevokePlayerSessions = %+v, %v; want revoked", session, err)
+	}
+
+	// プレイヤーを削除するとセッションも無効になる
+	if err := store.Players().DeletePlayer("ROOM01", "p-1"); err != nil {
+		t.Fatalf("DeletePlayer: %v", err)
+	}
+	if _, err := store.PlayerSessions().GetPlayerSession("ps-2"); err == nil {
+		t.Error("GetPlayerSession found a session of a deleted player")
+	}
+}
+
+func testDeleteRoom(t *testing.T, store repository.Store) {
+	mustCreateRoom(t, store, "ROOM01", false)
+	mustCreateRoom(t, store, "ROOM02", false)
+	mustCreatePlayer(t, store, "ROOM01", "p-1")
+	mustCreatePlayer(t, store, "ROOM02", "p-2")
+	if err := store.Rooms().AddBan(&models.RoomBan{RoomID: "ROOM01", Name: "troll"}); err != nil {
+		t.Fatalf("AddBan: %v", err)
+	}
+
+	if err := store.Rooms().DeleteRoom("ROOM01"); err != nil {
+		t.Fatalf("DeleteRoom: %v", err)
+	}
+	if _, err := store.Rooms().GetRoom("ROOM01"); err == nil {
+		t.Error("GetRoom found a deleted room")
+	}
+	if _, err := store.Players().GetPlayer("ROOM01", "p-1"); err == nil {
+		t.Error("GetPlayer found a player of a deleted room")
+	}
+	if banned, _ := store.Rooms().IsNameBanned("ROOM01", "troll"); banned {
+		t.Error("IsNameBanned is true for a deleted room")
+	}
+	if _, err := store.Players().GetPlayer("ROOM02", "p-2"); err != nil {
+		t.Errorf("GetPlayer in another room after DeleteRoom: %v", err)
+	}
+
+	if err := store.ResetAll(); err != nil {
+		t.Fatalf("ResetAll: %v", err)
+	}
+	if _, err := store.Rooms().GetRoom("ROOM02"); err == nil {
+		t.Error("GetRoom found a room after ResetAll")
+	}
+}
+
+// queuePlayerIDs キューに並んだプレイヤーのID
+func queuePlayerIDs(queue []models.BuzzQueue) []string {
+	ids := []string{}
+	for _, entry := range queue {
+		ids = append(ids, entry.PlayerID)
+	}
+	return ids
+}
+
+// equalStrings 2つのスライスが同じ要素を同じ順で持つか
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"math/big"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// AnswerMatch 回答の照合結果
+type AnswerMatch struct {
+	Matched    bool    `json:"matched"`
+	Confidence float64 `json:"confidence"`
+	Distance   int     `json:"distance"`
+}
+
+var numericPattern = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+
+// NormalizeAnswer 回答を照合用に正規化する
+// NFKC正規化（全角/半角の統一）、ひらがな→カタカナ、小文字化、空白・句読点の除去を行う
+func NormalizeAnswer(answer string) string {
+	normalized := norm.NFKC.String(answer)
+
+	var b strings.Builder
+	for _, r := range normalized {
+		switch {
+		case unicode.IsSpace(r), unicode.IsPunct(r):
+			continue
+		case r >= 'ぁ' && r <= 'ゖ':
+			// ひらがなをカタカナに寄せる
+			r += 'ァ' - 'ぁ'
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// parseNumericAnswer 数値として解釈できる回答を有理数に変換する（"3.14" と "3.140" を同一視するため）
+func parseNumericAnswer(answer string) (*big.Rat, bool) {
+	s := strings.TrimSpace(norm.NFKC.String(answer))
+	s = strings.ReplaceAll(s, ",", "")
+	if !numericPattern.MatchString(s) {
+		return nil, false
+	}
+
+	value, ok := new(big.Rat).SetString(s)
+	return value, ok
+}
+
+// DefaultAnswerTolerance 正解の長さから許容する編集距離を決める
+func DefaultAnswerTolerance(normalizedAnswer string) int {
+	length := len([]rune(normalizedAnswer))
+	switch {
+	case length <= 4:
+		return 0
+	case length <= 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// MatchAnswer 回答と正解を照合する。tolerance が nil の場合は正解の長さから許容編集距離を決める
+func MatchAnswer(input, expected string, tolerance *int) AnswerMatch {
+	// 数値の回答は値として比較し、あいまい一致は行わない
+	if expectedValue, ok := parseNumericAnswer(expected); ok {
+		if inputValue, ok := parseNumericAnswer(input); ok {
+			if expectedValue.Cmp(inputValue) == 0 {
+				return AnswerMatch{Matched: true, Confidence: 1}
+			}
+			return AnswerMatch{Matched: false, Confidence: 0, Distance: -1}
+		}
+	}
+
+	normalizedInput := NormalizeAnswer(input)
+	normalizedExpected := NormalizeAnswer(expected)
+	if normalizedInput == "" || normalizedExpected == "" {
+		return AnswerMatch{Matched: false, Confidence: 0, Distance: -1}
+	}
+
+	distance := editDistance([]rune(normalizedInput), []rune(normalizedExpected))
+
+	maxDistance := DefaultAnswerTolerance(normalizedExpected)
+	if tolerance != nil {
+		maxDistance = *tolerance
+	}
+
+	longest := len([]rune(normalizedInput))
+	if n := len([]rune(normalizedExpected)); n > longest {
+		longest = n
+	}
+	confidence := 1 - float64(distance)/float64(longest)
+	if confidence < 0 {
+		confidence = 0
+	}
+
+	return AnswerMatch{
+		Matched:    distance <= maxDistance,
+		Confidence: confidence,
+		Distance:   distance,
+	}
+}
+
+// MatchAcceptedAnswers 正解と別解のすべてと照合し、最も一致度の高い結果を返す
+func MatchAcceptedAnswers(input string, accepted []string, tolerance *int) AnswerMatch {
+	best := AnswerMatch{Matched: false, Confidence: 0, Distance: -1}
+	for _, answer := range accepted {
+		match := MatchAnswer(input, answer, tolerance)
+		if match.Matched && !best.Matched {
+			best = match
+			continue
+		}
+		if match.Matched == best.Matched && match.Confidence > best.Confidence {
+			best = match
+		}
+	}
+	return best
+}
+
+// editDistance レーベンシュタイン距離を計算
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
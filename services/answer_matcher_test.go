@@ -0,0 +1,142 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalizeAnswer(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"full-width alphanumerics", "ＡＢＣ　１２３", "abc123"},
+		{"half-width katakana", "ﾄｳｷｮｳ", "トウキョウ"},
+		{"hiragana to katakana", "とうきょう", "トウキョウ"},
+		{"mixed kana", "とうキョう", "トウキョウ"},
+		{"case, spaces and punctuation", " Tokyo Tower! ", "tokyotower"},
+		{"punctuation only", "！？、。", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeAnswer(tt.input); got != tt.want {
+				t.Errorf("NormalizeAnswer(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchAnswer(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+
+	tests := []struct {
+		name         string
+		input        string
+		expected     string
+		tolerance    *int
+		wantMatched  bool
+		wantDistance int
+	}{
+		{"exact", "Paris", "Paris", nil, true, 0},
+		{"full-width input", "ＰＡＲＩＳ", "Paris", nil, true, 0},
+		{"half-width katakana input", "ﾊﾟﾘ", "パリ", nil, true, 0},
+		{"hiragana input", "ぱり", "パリ", nil, true, 0},
+		{"ignores spaces", "New  York", "newyork", nil, true, 0},
+
+		// 正解の長さで許容編集距離が決まる（4文字以下: 0、8文字以下: 1、それより長い: 2）
+		{"4 chars, 1 typo", "abce", "abcd", nil, false, 1},
+		{"5 chars, 1 typo", "abcdf", "abcde", nil, true, 1},
+		{"5 chars, 2 typos", "abcxx", "abcde", nil, false, 2},
+		{"8 chars, 1 typo", "abcdefgx", "abcdefgh", nil, true, 1},
+		{"9 chars, 2 typos", "abcdefgxx", "abcdefghi", nil, true, 2},
+		{"9 chars, 3 typos", "abcdefxxx", "abcdefghi", nil, false, 3},
+
+		// 問題ごとの許容編集距離は長さによる既定値より優先する
+		{"tolerance 0 rejects a typo", "abcdefghx", "abcdefghi", intPtr(0), false, 1},
+		{"tolerance 3 accepts 3 typos", "abcdefxxx", "abcdefghi", intPtr(3), true, 3},
+		{"tolerance 1 on a short answer", "abce", "abcd", intPtr(1), true, 1},
+
+		// 数値は値で比較し、あいまい一致しない
+		{"numeric trailing zero", "3.140", "3.14", nil, true, 0},
+		{"full-width numeric", "３．１４", "3.14", nil, true, 0},
+		{"numeric thousands separator", "1,000", "1000", nil, true, 0},
+		{"numeric off by one", "13", "12", intPtr(5), false, -1},
+
+		{"empty input", "", "Paris", nil, false, -1},
+		{"punctuation only input", "？", "Paris", nil, false, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MatchAnswer(tt.input, tt.expected, tt.tolerance)
+			if got.Matched != tt.wantMatched || got.Distance != tt.wantDistance {
+				t.Errorf("MatchAnswer(%q, %q) = %+v, want matched %v distance %d",
+					tt.input, tt.expected, got, tt.wantMatched, tt.wantDistance)
+			}
+		})
+	}
+}
+
+func TestMatchAnswerConfidence(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		want     float64
+	}{
+		{"Paris", "Paris", 1},
+		{"abcdf", "abcde", 0.8},
+		{"abcdef", "abcde", 1 - 1.0/6},
+		{"xyz", "abc", 0},
+	}
+	for _, tt := range tests {
+		got := MatchAnswer(tt.input, tt.expected, nil)
+		if math.Abs(got.Confidence-tt.want) > 1e-9 {
+			t.Errorf("MatchAnswer(%q, %q).Confidence = %v, want %v", tt.input, tt.expected, got.Confidence, tt.want)
+		}
+	}
+}
+
+func TestMatchAcceptedAnswers(t *testing.T) {
+	accepted := []string{"Leonardo da Vinci", "ダ・ヴィンチ", "Da Vinci"}
+
+	tests := []struct {
+		name           string
+		input          string
+		accepted       []string
+		wantMatched    bool
+		wantConfidence float64
+	}{
+		{"main answer", "leonardo da vinci", accepted, true, 1},
+		{"alias", "davinci", accepted, true, 1},
+		{"alias with normalized kana", "だヴィンチ", accepted, true, 1},
+		{"alias within tolerance", "da vinchi", accepted, true, 1 - 1.0/8},
+		{"no answer matches", "Michelangelo", accepted, false, -1},
+		{"no accepted answers", "anything", nil, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MatchAcceptedAnswers(tt.input, tt.accepted, nil)
+			if got.Matched != tt.wantMatched {
+				t.Fatalf("MatchAcceptedAnswers(%q) = %+v, want matched %v", tt.input, got, tt.wantMatched)
+			}
+			if tt.wantConfidence >= 0 && math.Abs(got.Confidence-tt.wantConfidence) > 1e-9 {
+				t.Errorf("MatchAcceptedAnswers(%q).Confidence = %v, want %v", tt.input, got.Confidence, tt.wantConfidence)
+			}
+		})
+	}
+}
+
+func TestMatchAcceptedAnswersPrefersMatch(t *testing.T) {
+	// 一致しない別解の一致度が高くても、一致した答えを返す
+	tolerance := 2
+	got := MatchAcceptedAnswers("abcd", []string{"abcdxyz", "ab"}, &tolerance)
+	if !got.Matched || got.Distance != 2 {
+		t.Errorf("MatchAcceptedAnswers = %+v, want the match against ab", got)
+	}
+
+	// 一致した答えが複数あれば一致度の高いほう
+	got = MatchAcceptedAnswers("abcd", []string{"abce", "abcd"}, &tolerance)
+	if !got.Matched || got.Confidence != 1 {
+		t.Errorf("MatchAcceptedAnswers = %+v, want the exact match", got)
+	}
+}
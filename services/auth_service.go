@@ -0,0 +1,208 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"quivra-backend/models"
+	"quivra-backend/repository"
+)
+
+var (
+	// ErrInvalidToken 署名や形式が不正なトークン
+	ErrInvalidToken = errors.New("invalid session token")
+	// ErrTokenExpired 有効期限切れのトークン
+	ErrTokenExpired = errors.New("session token expired")
+	// ErrTokenRevoked 失効済みのトークン
+	ErrTokenRevoked = errors.New("session token revoked")
+)
+
+// SessionClaims セッショントークンに含まれる情報
+type SessionClaims struct {
+	SessionID string `json:"sid"`
+	PlayerID  string `json:"pid,omitempty"` // 観戦者の場合は空
+	RoomID    string `json:"rid"`
+	Role      string `json:"role,omitempty"` // 空の場合はプレイヤー
+	ExpiresAt int64  `json:"exp"`
+}
+
+// IsSpectator 観戦者のトークンか
+func (c *SessionClaims) IsSpectator() bool {
+	return c.Role == models.SessionRoleSpectator
+}
+
+// AuthService プレイヤーのセッショントークンを発行・検証する
+// トークンは base64url(claims) + "." + base64url(HMAC-SHA256) 形式で、失効管理のため発行記録をストレージに保存する
+type AuthService struct {
+	store       repository.Store
+	secret      []byte
+	ttl         time.Duration
+	operatorKey string
+}
+
+func NewAuthService(store repository.Store, secret []byte, ttl time.Duration, operatorKey string) *AuthService {
+	return &AuthService{
+		store:       store,
+		secret:      secret,
+		ttl:         ttl,
+		operatorKey: operatorKey,
+	}
+}
+
+// IssueToken プレイヤーのセッショントークンを発行
+func (as *AuthService) IssueToken(roomID, playerID string) (string, time.Time, error) {
+	return as.issue(roomID, playerID, models.SessionRolePlayer)
+}
+
+// IssueSpectatorToken ルームを観戦するためのセッショントークンを発行（プレイヤーとしては登録しない）
+func (as *AuthService) IssueSpectatorToken(roomID string) (string, time.Time, error) {
+	return as.issue(roomID, "", models.SessionRoleSpectator)
+}
+
+// issue セッションを記録してトークンを発行
+func (as *AuthService) issue(roomID, playerID, role string) (string, time.Time, error) {
+	sessionID, err := generateSessionToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	// DBのTIMESTAMPに合わせて秒単位に丸める
+	expiresAt := time.Now().Add(as.ttl).Truncate(time.Second)
+	session := &models.PlayerSession{
+		ID:        sessionID,
+		PlayerID:  playerID,
+		RoomID:    roomID,
+		Role:      role,
+		ExpiresAt: expiresAt,
+	}
+	if err := as.store.PlayerSessions().CreatePlayerSession(session); err != nil {
+		return "", time.Time{}, err
+	}
+
+	claims := SessionClaims{
+		SessionID: sessionID,
+		PlayerID:  playerID,
+		RoomID:    roomID,
+		ExpiresAt: expiresAt.Unix(),
+	}
+	if role == models.SessionRoleSpectator {
+		claims.Role = role
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to encode session claims: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + as.sign(encoded), expiresAt, nil
+}
+
+// Authenticate トークンの署名・有効期限・失効状態を検証してクレームを返す
+func (as *AuthService) Authenticate(token string) (*SessionClaims, error) {
+	claims, err := as.parse(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+
+	// プレイヤーやルームが削除された場合もセッションは見つからない
+	session, err := as.store.PlayerSessions().GetPlayerSession(claims.SessionID)
+	if err != nil {
+		return nil, ErrTokenRevoked
+	}
+	if session.RevokedAt != nil || session.PlayerID != claims.PlayerID || session.RoomID != claims.RoomID ||
+		(session.Role == models.SessionRoleSpectator) != claims.IsSpectator() {
+		return nil, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+// PreviousPlayer 以前に発行したプレイヤーのトークンの署名だけを検証して、ルームIDとプレイヤーIDを返す
+// 有効期限切れ・失効済み（キック・参加禁止で削除されたプレイヤー）のトークンも受け付ける。参加禁止の照合にのみ使う
+func (as *AuthService) PreviousPlayer(token string) (roomID, playerID string, err error) {
+	claims, err := as.parse(token)
+	if err != nil {
+		return "", "", err
+	}
+	if claims.IsSpectator() {
+		return "", "", ErrInvalidToken
+	}
+	return claims.RoomID, claims.PlayerID, nil
+}
+
+// RevokeToken トークンを失効させる
+func (as *AuthService) RevokeToken(token string) error {
+	claims, err := as.parse(token)
+	if err != nil {
+		return err
+	}
+	return as.store.PlayerSessions().RevokePlayerSession(claims.SessionID)
+}
+
+// RevokePlayer プレイヤーに発行した全トークンを失効させる
+func (as *AuthService) RevokePlayer(playerID string) error {
+	return as.store.PlayerSessions().RevokePlayerSessions(playerID)
+}
+
+// DisplayKey ルームの表示用接続（ビッグスクリーン）のキー
+// ルームIDから署名鍵で導出するため保存しない（トークンのペイロードは base64url で ":" を含まないため署名と衝突しない）
+func (as *AuthService) DisplayKey(roomID string) string {
+	return as.sign("display:" + roomID)
+}
+
+// VerifyDisplayKey 表示用接続のキーがルームのものか検証する
+func (as *AuthService) VerifyDisplayKey(roomID, key string) bool {
+	return key != "" && hmac.Equal([]byte(key), []byte(as.DisplayKey(roomID)))
+}
+
+// IsOperator 問題・問題セットを管理するオペレーターのキーか
+// ルームの管理者トークンは誰でもルームを作って取得できるため、サーバーの設定（ADMIN_API_KEY）と照合する
+func (as *AuthService) IsOperator(key string) bool {
+	return as.operatorKey != "" && key != "" && hmac.Equal([]byte(key), []byte(as.operatorKey))
+}
+
+// parse 署名を検証してクレームを取り出す（有効期限・失効は確認しない）
+func (as *AuthService) parse(token string) (*SessionClaims, error) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(as.sign(encoded))) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var claims SessionClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.SessionID == "" || (claims.PlayerID == "") != claims.IsSpectator() {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+func (as *AuthService) sign(encoded string) string {
+	mac := hmac.New(sha256.New, as.secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// generateSessionToken 推測されないセッションIDを生成
+func generateSessionToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
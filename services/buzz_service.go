@@ -0,0 +1,769 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"quivra-backend/models"
+	"quivra-backend/repository"
+)
+
+// 早押しの状態（ルームごと）
+//
+//	idle → open → buzzed → judging → closed → open → ... → idle
+//
+// buzzed・judging の間も後続の早押しはキューに並ぶ。判定が誤答なら buzzed（キューが空なら open）に戻る
+const (
+	BuzzStateIdle    = "idle"    // 出題していない
+	BuzzStateOpen    = "open"    // 出題中で、まだ誰も押していない
+	BuzzStateBuzzed  = "buzzed"  // 押したプレイヤーがいて、キューの先頭の回答を待っている
+	BuzzStateJudging = "judging" // キューの先頭のプレイヤーの回答を判定中
+	BuzzStateClosed  = "closed"  // 問題が終了し、次の問題を待っている
+)
+
+// buzzTransitions 許可する状態遷移（どの状態からも idle には戻せる）
+var buzzTransitions = map[string][]string{
+	BuzzStateIdle:    {BuzzStateOpen},
+	BuzzStateOpen:    {BuzzStateBuzzed, BuzzStateClosed, BuzzStateIdle},
+	BuzzStateBuzzed:  {BuzzStateJudging, BuzzStateOpen, BuzzStateClosed, BuzzStateIdle},
+	BuzzStateJudging: {BuzzStateBuzzed, BuzzStateOpen, BuzzStateClosed, BuzzStateIdle},
+	BuzzStateClosed:  {BuzzStateOpen, BuzzStateIdle},
+}
+
+var (
+	// ErrAlreadyInQueue プレイヤーが既に回答キューにいる
+	ErrAlreadyInQueue = errors.New("player already in queue")
+	// ErrTeammateInQueue 同じチームのメンバーが既に回答キューにいる
+	ErrTeammateInQueue = errors.New("a teammate is already in the buzz queue")
+	// ErrBuzzNotOpen 早押しを受け付けていない
+	ErrBuzzNotOpen = errors.New("buzzing is not open")
+	// ErrNotAnswerer 回答キューの先頭のプレイヤーではない
+	ErrNotAnswerer = errors.New("player is not next in the buzz queue")
+	// ErrInvalidBuzzTransition 現在の状態からは遷移できない
+	ErrInvalidBuzzTransition = errors.New("invalid buzz state transition")
+)
+
+// buzzPersistBuffer 書き込み待ちの早押しイベントの上限（超えると早押しの処理が書き込みを待つ）
+const buzzPersistBuffer = 4096
+
+// BuzzPress 早押しの受信時刻と遅延補正の材料
+type BuzzPress struct {
+	ReceivedAt      time.Time
+	RTT             time.Duration // 接続の往復遅延の推定値（未測定は0）
+	ClientPressedAt *int64        // クライアントの時計での押下時刻（Unix ミリ秒）
+	ClientSentAt    *int64        // クライアントの時計での送信時刻（Unix ミリ秒）
+
+	// TeamID 「1チーム1人まで」のルールが有効な場合のプレイヤーのチーム（ルールが無効・無所属なら nil）
+	TeamID *string
+}
+
+// BuzzStatus ルームの早押しの現在の状態
+type BuzzStatus struct {
+	State      string
+	QuestionID int    // 出題中・直前の問題（idle では0）
+	Answerer   string // キューの先頭のプレイヤー（buzzed・judging のみ）
+}
+
+// AcceptsBuzz 早押しを受け付ける状態か
+func (s BuzzStatus) AcceptsBuzz() bool {
+	return s.State == BuzzStateOpen || s.State == BuzzStateBuzzed || s.State == BuzzStateJudging
+}
+
+// BuzzLockout 早押し制限の残り
+type BuzzLockout struct {
+	RetryAfter         time.Duration // 時間による制限の残り
+	QuestionsRemaining int           // 問題数による制限の残り（現在の問題を含む）
+}
+
+// BuzzService ルームごとの早押しの状態・回答キュー・誤答による早押し制限をメモリ上で管理する
+// 状態遷移とキューの操作は同じロック内で不可分に行い、履歴として buzz_queue への書き込みは非同期に行う
+type BuzzService struct {
+	store           repository.Store
+	maxCompensation time.Duration
+	onStateChange   func(roomID string, change models.BuzzStateChangedData)
+
+	mu    sync.Mutex
+	rooms map[string]*roomBuzz // roomId -> 早押しの状態
+
+	persist chan buzzEvent
+	done    chan struct{}
+}
+
+type roomBuzz struct {
+	mu         sync.Mutex
+	loaded     bool
+	state      string
+	questionID int
+	entries    []queuedBuzz            // 補正後の押下時刻順
+	lockouts   map[string]*buzzLockout // playerId -> 誤答による早押し制限
+}
+
+type queuedBuzz struct {
+	models.BuzzQueue
+	teamID *string
+}
+
+// buzzLockout 誤答したプレイヤーの早押し制限
+type buzzLockout struct {
+	currentQuestion bool      // 現在の問題の間は押せない
+	questions       int       // 現在の問題に続いて押せない問題数
+	until           time.Time // この時刻まで押せない
+}
+
+// buzzEvent ストレージに書き込む早押しイベント
+type buzzEvent struct {
+	kind     string // add / remove / clear
+	roomID   string
+	playerID string
+	entry    models.BuzzQueue
+}
+
+var queueIDSequence uint64
+
+func NewBuzzService(store repository.Store, maxCompensation time.Duration) *BuzzService {
+	return &BuzzService{
+		store:           store,
+		maxCompensation: maxCompensation,
+		rooms:           make(map[string]*roomBuzz),
+		persist:         make(chan buzzEvent, buzzPersistBuffer),
+		done:            make(chan struct{}),
+	}
+}
+
+// ValidateLockoutSettings 誤答時の早押し制限の設定を検証
+func ValidateLockoutSettings(settings models.LockoutSettings) error {
+	switch settings.Mode {
+	case models.LockoutModeNone, models.LockoutModeQuestion:
+		return nil
+	case models.LockoutModeSeconds:
+		if settings.Seconds <= 0 {
+			return fmt.Errorf("lockout seconds must be positive")
+		}
+		return nil
+	case models.LockoutModeQuestions:
+		if settings.Questions <= 0 {
+			return fmt.Errorf("lockout questions must be positive")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown lockout mode: %s", settings.Mode)
+	}
+}
+
+// OnStateChange 状態が変わったときに呼ぶ関数を設定する（ルームのロック内で呼ぶため、遷移の順に届く）
+func (bs *BuzzService) OnStateChange(fn func(roomID string, change models.BuzzStateChangedData)) {
+	bs.onStateChange = fn
+}
+
+// Run 早押しイベントを受け付けた順にストレージへ書き込む（Close されるまで）
+func (bs *BuzzService) Run() {
+	defer close(bs.done)
+
+	for event := range bs.persist {
+		var err error
+		switch event.kind {
+		case "add":
+			err = bs.store.BuzzQueue().AddToQueue(&event.entry)
+		case "remove":
+			err = bs.store.BuzzQueue().RemoveFromQueue(event.roomID, event.playerID)
+		case "clear":
+			err = bs.store.BuzzQueue().ClearQueue(event.roomID)
+		}
+		if err != nil {
+			log.Printf("Error persisting buzz event (%s, room %s): %v", event.kind, event.roomID, err)
+		}
+	}
+}
+
+// Close 書き込み待ちのイベントをすべて書き込んでから終了する
+func (bs *BuzzService) Close() {
+	close(bs.persist)
+	<-bs.done
+}
+
+// Compensation 受信時刻から差し引く時間を計算する
+// 片道の遅延（RTT/2）と、クライアントで押してから送信するまでの時間の合計を maxCompensation までに制限する。
+// クライアントの時刻は押下と送信の差だけを使うため、サーバーとの時計のずれは影響しない
+func (bs *BuzzService) Compensation(press BuzzPress) time.Duration {
+	compensation := press.RTT / 2
+	if press.ClientPressedAt != nil && press.ClientSentAt != nil && *press.ClientSentAt > *press.ClientPressedAt {
+		compensation += time.Duration(*press.ClientSentAt-*press.ClientPressedAt) * time.Millisecond
+	}
+
+	if compensation < 0 {
+		return 0
+	}
+	if compensation > bs.maxCompensation {
+		return bs.maxCompensation
+	}
+	return compensation
+}
+
+// Status ルームの早押しの現在の状態を取得
+func (bs *BuzzService) Status(roomID string) (BuzzStatus, error) {
+	room, err := bs.room(roomID)
+	if err != nil {
+		return BuzzStatus{}, err
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	return room.status(), nil
+}
+
+// OpenQuestion 問題を出題して早押しの受付を始める（idle・closed → open）
+// キューを空にし、問題数による早押し制限を1問分進める
+func (bs *BuzzService) OpenQuestion(roomID string, questionID int) error {
+	room, err := bs.room(roomID)
+	if err != nil {
+		return err
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.state != BuzzStateIdle && room.state != BuzzStateClosed {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidBuzzTransition, room.state, BuzzStateOpen)
+	}
+
+	bs.clearEntries(roomID, room)
+	room.advanceLockouts()
+	room.questionID = questionID
+	bs.transition(roomID, room, BuzzStateOpen)
+	return nil
+}
+
+// CloseQuestion 問題を終了して早押しを締め切る（→ closed）。出題中でなければ何もしない
+func (bs *BuzzService) CloseQuestion(roomID string) error {
+	room, err := bs.room(roomID)
+	if err != nil {
+		return err
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.state == BuzzStateIdle || room.state == BuzzStateClosed {
+		return nil
+	}
+
+	bs.clearEntries(roomID, room)
+	bs.transition(roomID, room, BuzzStateClosed)
+	return nil
+}
+
+// ResetRoom マッチの開始・終了時に早押しの状態を idle に戻す（キューと誤答による制限も破棄）
+func (bs *BuzzService) ResetRoom(roomID string) error {
+	room, err := bs.room(roomID)
+	if err != nil {
+		return err
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	bs.clearEntries(roomID, room)
+	room.lockouts = make(map[string]*buzzLockout)
+	room.questionID = 0
+	if room.state != BuzzStateIdle {
+		bs.transition(roomID, room, BuzzStateIdle)
+	}
+	return nil
+}
+
+// AddToQueue プレイヤーを回答キューに追加（遅延補正後の押下時刻で並ぶ）
+// 状態・重複・チームのルールのチェックと追加を同じロック内で行うため、連打しても二重に入らない
+func (bs *BuzzService) AddToQueue(roomID, playerID string, press BuzzPress) error {
+	room, err := bs.room(roomID)
+	if err != nil {
+		return err
+	}
+
+	entry := queuedBuzz{
+		BuzzQueue: models.BuzzQueue{
+			ID:              generateQueueID(),
+			RoomID:          roomID,
+			PlayerID:        playerID,
+			BuzzedAt:        press.ReceivedAt,
+			PressedAt:       press.ReceivedAt.Add(-bs.Compensation(press)),
+			IsActive:        true,
+			ClientPressedAt: press.ClientPressedAt,
+			ClientSentAt:    press.ClientSentAt,
+			RTTMillis:       int(press.RTT / time.Millisecond),
+		},
+		teamID: press.TeamID,
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if !room.status().AcceptsBuzz() {
+		return ErrBuzzNotOpen
+	}
+	for _, queued := range room.entries {
+		if queued.PlayerID == playerID {
+			return ErrAlreadyInQueue
+		}
+		if press.TeamID != nil && queued.teamID != nil && *queued.teamID == *press.TeamID {
+			return ErrTeammateInQueue
+		}
+	}
+
+	// 判定中は先頭のプレイヤーを入れ替えない
+	start := 0
+	if room.state == BuzzStateJudging {
+		start = 1
+	}
+	index := start + sort.Search(len(room.entries)-start, func(i int) bool {
+		return queuedBefore(entry.BuzzQueue, room.entries[start+i].BuzzQueue)
+	})
+	room.entries = append(room.entries, queuedBuzz{})
+	copy(room.entries[index+1:], room.entries[index:])
+	room.entries[index] = entry
+
+	// ロック内で送り、同じルームのイベントが書き込まれる順序を保つ
+	bs.persist <- buzzEvent{kind: "add", roomID: roomID, entry: entry.BuzzQueue}
+
+	if room.state == BuzzStateOpen {
+		bs.transition(roomID, room, BuzzStateBuzzed)
+	} else if index == 0 {
+		// 先頭のプレイヤーが変わったことを知らせる
+		bs.notify(roomID, room, room.state)
+	}
+	return nil
+}
+
+// BeginJudging キューの先頭のプレイヤーの回答の判定を始め（buzzed → judging）、そのプレイヤーの補正後の押下時刻を返す
+// 同じ回答を二重に判定しないよう、判定が終わるまで他の判定は受け付けない
+func (bs *BuzzService) BeginJudging(roomID, playerID string) (time.Time, error) {
+	room, err := bs.room(roomID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.state != BuzzStateBuzzed {
+		if room.state == BuzzStateOpen {
+			return time.Time{}, ErrNotAnswerer
+		}
+		return time.Time{}, fmt.Errorf("%w: %s -> %s", ErrInvalidBuzzTransition, room.state, BuzzStateJudging)
+	}
+	if room.entries[0].PlayerID != playerID {
+		return time.Time{}, ErrNotAnswerer
+	}
+
+	bs.transition(roomID, room, BuzzStateJudging)
+	return room.entries[0].PressedAt, nil
+}
+
+// FinishJudging 判定を終える。closeQuestion なら問題を終了し（→ closed）、
+// そうでなければ回答したプレイヤーをキューから外して次のプレイヤーに移る（→ buzzed、キューが空なら open）
+func (bs *BuzzService) FinishJudging(roomID, playerID string, closeQuestion bool) error {
+	return bs.finishJudging(roomID, playerID, closeQuestion, nil)
+}
+
+// FinishWrongAnswer 誤答の判定を終え、回答したプレイヤーの早押しを設定に従って制限してから次のプレイヤーに移る
+// 制限は回答権の移動と同じロック内で行うため、外れた直後に押し直してもキューには入らない
+func (bs *BuzzService) FinishWrongAnswer(roomID, playerID string, settings models.LockoutSettings) error {
+	return bs.finishJudging(roomID, playerID, false, newBuzzLockout(settings))
+}
+
+// finishJudging 判定を終える。lockout が nil でなければ回答したプレイヤーの早押しを制限する
+// 判定中でない（時間切れで締め切られた）・回答権が取り消されていた場合は何も変更しない
+func (bs *BuzzService) finishJudging(roomID, playerID string, closeQuestion bool, lockout *buzzLockout) error {
+	room, err := bs.room(roomID)
+	if err != nil {
+		return err
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.state != BuzzStateJudging {
+		return fmt.Errorf("%w: finish judging in %s", ErrInvalidBuzzTransition, room.state)
+	}
+	// 判定中にキックなどで回答権が取り消され、次のプレイヤーの判定が始まっている
+	if room.entries[0].PlayerID != playerID {
+		return ErrNotAnswerer
+	}
+
+	if lockout != nil {
+		room.lockouts[playerID] = lockout
+	}
+	if closeQuestion {
+		bs.clearEntries(roomID, room)
+		bs.transition(roomID, room, BuzzStateClosed)
+		return nil
+	}
+
+	bs.removeEntry(roomID, room, playerID)
+	if len(room.entries) > 0 {
+		bs.transition(roomID, room, BuzzStateBuzzed)
+	} else {
+		bs.transition(roomID, room, BuzzStateOpen)
+	}
+	return nil
+}
+
+// GetQueue ルームの回答キューを取得
+func (bs *BuzzService) GetQueue(roomID string) ([]models.BuzzQueue, error) {
+	room, err := bs.room(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	entries := make([]models.BuzzQueue, 0, len(room.entries))
+	for _, queued := range room.entries {
+		entries = append(entries, queued.BuzzQueue)
+	}
+	return entries, nil
+}
+
+// GetBuzzAudit ルームの早押しの記録を、生の時刻と補正後の時刻を並べて取得（受信順）
+// ストレージへの書き込みは非同期のため、直前の早押しはまだ含まれないことがある
+func (bs *BuzzService) GetBuzzAudit(roomID string) ([]models.BuzzAuditEntry, error) {
+	history, err := bs.store.BuzzQueue().GetBuzzHistory(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	players, err := bs.store.Players().GetRoomPlayers(roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get room players: %w", err)
+	}
+	names := make(map[string]string)
+	for _, player := range players {
+		names[player.ID] = player.Name
+	}
+
+	entries := []models.BuzzAuditEntry{}
+	for _, buzz := range history {
+		entries = append(entries, models.BuzzAuditEntry{
+			BuzzQueue:          buzz,
+			Name:               names[buzz.PlayerID],
+			CompensationMillis: buzz.BuzzedAt.Sub(buzz.PressedAt).Milliseconds(),
+		})
+	}
+	return entries, nil
+}
+
+// RemoveFromQueue プレイヤーをキューから削除（切断時など）。キューにいなければ false
+// 判定中の先頭のプレイヤーは判定が終わるまで残す
+func (bs *BuzzService) RemoveFromQueue(roomID, playerID string) (bool, error) {
+	room, err := bs.room(roomID)
+	if err != nil {
+		return false, err
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.state == BuzzStateJudging && room.entries[0].PlayerID == playerID {
+		return false, nil
+	}
+	removed, _ := bs.dequeue(roomID, room, playerID)
+	return removed, nil
+}
+
+// WithdrawPlayer ルームから外すプレイヤーをキューから削除する（キック・参加禁止）。キューにいなければ removed は false
+// 判定中の先頭のプレイヤーでも外して次のプレイヤーに回答権を移し（判定を終えても加点する相手がいないため）、判定中だったかを返す
+func (bs *BuzzService) WithdrawPlayer(roomID, playerID string) (removed, wasJudging bool, err error) {
+	room, err := bs.room(roomID)
+	if err != nil {
+		return false, false, err
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	removed, wasJudging = bs.dequeue(roomID, room, playerID)
+	return removed, wasJudging, nil
+}
+
+// dequeue プレイヤーをキューから外し、先頭が空いた場合は次のプレイヤーに移る（room.mu のロック取得済みであること）
+// 判定中の先頭のプレイヤーを外した場合は wasJudging が true になる
+func (bs *BuzzService) dequeue(roomID string, room *roomBuzz, playerID string) (removed, wasJudging bool) {
+	wasAnswerer := len(room.entries) > 0 && room.entries[0].PlayerID == playerID
+	if !bs.removeEntry(roomID, room, playerID) {
+		return false, false
+	}
+	wasJudging = wasAnswerer && room.state == BuzzStateJudging
+
+	switch {
+	case (room.state == BuzzStateBuzzed || wasJudging) && len(room.entries) == 0:
+		bs.transition(roomID, room, BuzzStateOpen)
+	case wasJudging:
+		bs.transition(roomID, room, BuzzStateBuzzed)
+	case room.state == BuzzStateBuzzed && wasAnswerer:
+		bs.notify(roomID, room, room.state)
+	}
+	return true, wasJudging
+}
+
+// ClearQueue ルームのキューをクリア（管理者によるリセット）。押されていた場合は open に戻る
+// 判定中の先頭のプレイヤーは判定が終わるまで残す
+func (bs *BuzzService) ClearQueue(roomID string) error {
+	room, err := bs.room(roomID)
+	if err != nil {
+		return err
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.state == BuzzStateJudging {
+		for len(room.entries) > 1 {
+			bs.removeEntry(roomID, room, room.entries[len(room.entries)-1].PlayerID)
+		}
+		return nil
+	}
+
+	bs.clearEntries(roomID, room)
+	if room.state == BuzzStateBuzzed {
+		bs.transition(roomID, room, BuzzStateOpen)
+	}
+	return nil
+}
+
+// IsPlayerInQueue プレイヤーがキューにいるかチェック
+func (bs *BuzzService) IsPlayerInQueue(roomID, playerID string) (bool, error) {
+	room, err := bs.room(roomID)
+	if err != nil {
+		return false, err
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	for _, queued := range room.entries {
+		if queued.PlayerID == playerID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// LockOut 誤答したプレイヤーの早押しを設定に従って制限する
+func (bs *BuzzService) LockOut(roomID, playerID string, settings models.LockoutSettings) error {
+	lockout := newBuzzLockout(settings)
+	if lockout == nil {
+		return nil
+	}
+
+	room, err := bs.room(roomID)
+	if err != nil {
+		return err
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	room.lockouts[playerID] = lockout
+	return nil
+}
+
+// CheckLockout プレイヤーが誤答により早押しを制限されているか確認
+func (bs *BuzzService) CheckLockout(roomID, playerID string) (BuzzLockout, bool) {
+	room, err := bs.room(roomID)
+	if err != nil {
+		return BuzzLockout{}, false
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	lockout, exists := room.lockouts[playerID]
+	if !exists {
+		return BuzzLockout{}, false
+	}
+
+	if lockout.currentQuestion {
+		return BuzzLockout{QuestionsRemaining: lockout.questions + 1}, true
+	}
+	if remaining := time.Until(lockout.until); remaining > 0 {
+		return BuzzLockout{RetryAfter: remaining}, true
+	}
+	return BuzzLockout{}, false
+}
+
+// newBuzzLockout 設定に従った早押し制限（制限しない設定なら nil）
+func newBuzzLockout(settings models.LockoutSettings) *buzzLockout {
+	lockout := &buzzLockout{}
+	switch settings.Mode {
+	case models.LockoutModeQuestion:
+		lockout.currentQuestion = true
+	case models.LockoutModeSeconds:
+		lockout.until = time.Now().Add(time.Duration(settings.Seconds) * time.Second)
+	case models.LockoutModeQuestions:
+		lockout.currentQuestion = true
+		lockout.questions = settings.Questions
+	default:
+		return nil
+	}
+	return lockout
+}
+
+// ForgetRoom 削除されたルームの早押しの状態をメモリから破棄
+func (bs *BuzzService) ForgetRoom(roomID string) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	delete(bs.rooms, roomID)
+}
+
+// Reset 全ルームの早押しの状態をメモリから破棄（全データリセット用）
+func (bs *BuzzService) Reset() {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	bs.rooms = make(map[string]*roomBuzz)
+}
+
+// room ルームの早押しの状態を取得する。初回はストレージに残っているキューを読み込む（再起動後の復元）
+// 復元したキューがあれば buzzed から再開する。チームのルールはメモリ上で追加した早押しにのみ適用する
+func (bs *BuzzService) room(roomID string) (*roomBuzz, error) {
+	bs.mu.Lock()
+	room, exists := bs.rooms[roomID]
+	if !exists {
+		room = &roomBuzz{
+			state:    BuzzStateIdle,
+			lockouts: make(map[string]*buzzLockout),
+		}
+		bs.rooms[roomID] = room
+	}
+	bs.mu.Unlock()
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if !room.loaded {
+		stored, err := bs.store.BuzzQueue().GetQueue(roomID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load queue: %w", err)
+		}
+		for _, buzz := range stored {
+			room.entries = append(room.entries, queuedBuzz{BuzzQueue: buzz})
+		}
+		if len(room.entries) > 0 {
+			room.state = BuzzStateBuzzed
+		}
+		room.loaded = true
+	}
+	return room, nil
+}
+
+// transition 状態を遷移させて通知する（room.mu のロック取得済みであること）
+// 遷移表にない遷移は呼び出し側の誤りのため、ログに残して遷移させない
+func (bs *BuzzService) transition(roomID string, room *roomBuzz, to string) {
+	if !canTransition(room.state, to) {
+		log.Printf("Invalid buzz state transition in room %s: %s -> %s", roomID, room.state, to)
+		return
+	}
+
+	previous := room.state
+	room.state = to
+	bs.notify(roomID, room, previous)
+}
+
+// notify 状態の変化を通知する（room.mu のロック取得済みであること）
+func (bs *BuzzService) notify(roomID string, room *roomBuzz, previous string) {
+	if bs.onStateChange == nil {
+		return
+	}
+
+	status := room.status()
+	bs.onStateChange(roomID, models.BuzzStateChangedData{
+		State:      status.State,
+		Previous:   previous,
+		QuestionID: status.QuestionID,
+		Answerer:   status.Answerer,
+	})
+}
+
+// clearEntries キューを空にする（room.mu のロック取得済みであること）
+func (bs *BuzzService) clearEntries(roomID string, room *roomBuzz) {
+	if len(room.entries) == 0 {
+		return
+	}
+	room.entries = nil
+	bs.persist <- buzzEvent{kind: "clear", roomID: roomID}
+}
+
+// removeEntry プレイヤーをキューから外す（room.mu のロック取得済みであること）
+func (bs *BuzzService) removeEntry(roomID string, room *roomBuzz, playerID string) bool {
+	removed := false
+	entries := room.entries[:0]
+	for _, queued := range room.entries {
+		if queued.PlayerID == playerID {
+			removed = true
+			continue
+		}
+		entries = append(entries, queued)
+	}
+	room.entries = entries
+
+	if removed {
+		bs.persist <- buzzEvent{kind: "remove", roomID: roomID, playerID: playerID}
+	}
+	return removed
+}
+
+// status 現在の状態（room.mu のロック取得済みであること）
+func (room *roomBuzz) status() BuzzStatus {
+	status := BuzzStatus{
+		State:      room.state,
+		QuestionID: room.questionID,
+	}
+	if (room.state == BuzzStateBuzzed || room.state == BuzzStateJudging) && len(room.entries) > 0 {
+		status.Answerer = room.entries[0].PlayerID
+	}
+	return status
+}
+
+// advanceLockouts 次の問題へ進んだときに問題単位の制限を1問分進める（room.mu のロック取得済みであること）
+func (room *roomBuzz) advanceLockouts() {
+	for playerID, lockout := range room.lockouts {
+		if lockout.questions > 0 {
+			lockout.questions--
+			continue
+		}
+		lockout.currentQuestion = false
+		if time.Now().After(lockout.until) {
+			delete(room.lockouts, playerID)
+		}
+	}
+}
+
+// canTransition from から to への遷移が許可されているか
+func canTransition(from, to string) bool {
+	for _, allowed := range buzzTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// queuedBefore a が b より先に並ぶか（補正後の押下時刻順、同時刻は受信順）
+func queuedBefore(a, b models.BuzzQueue) bool {
+	if !a.PressedAt.Equal(b.PressedAt) {
+		return a.PressedAt.Before(b.PressedAt)
+	}
+	return a.BuzzedAt.Before(b.BuzzedAt)
+}
+
+// generateQueueID キューIDを生成（同時刻の早押しでも重複しないよう連番を付ける）
+func generateQueueID() string {
+	return fmt.Sprintf("%d-%d", time.Now().UnixNano(), atomic.AddUint64(&queueIDSequence, 1))
+}
@@ -0,0 +1,591 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"quivra-backend/models"
+	"quivra-backend/repository/memory"
+)
+
+// buzzRecorder 早押しの状態変化の通知を記録する
+type buzzRecorder struct {
+	mu      sync.Mutex
+	changes []models.BuzzStateChangedData
+}
+
+func (r *buzzRecorder) record(roomID string, change models.BuzzStateChangedData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.changes = append(r.changes, change)
+}
+
+// states 通知された遷移先の状態
+func (r *buzzRecorder) states() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	states := []string{}
+	for _, change := range r.changes {
+		states = append(states, change.State)
+	}
+	return states
+}
+
+// last 最後に通知された状態変化
+func (r *buzzRecorder) last() models.BuzzStateChangedData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.changes) == 0 {
+		return models.BuzzStateChangedData{}
+	}
+	return r.changes[len(r.changes)-1]
+}
+
+// newTestBuzzService インメモリストレージを使う BuzzService（書き込みはテスト終了時に閉じる）
+func newTestBuzzService(t testing.TB, maxCompensation time.Duration) (*BuzzService, *buzzRecorder) {
+	t.Helper()
+	bs := NewBuzzService(memory.NewStore(), maxCompensation)
+	recorder := &buzzRecorder{}
+	bs.OnStateChange(recorder.record)
+	go bs.Run()
+	t.Cleanup(bs.Close)
+	return bs, recorder
+}
+
+// buzzAt time を受信時刻とする早押し（遅延補正なし）
+func buzzAt(at time.Time) BuzzPress {
+	return BuzzPress{ReceivedAt: at}
+}
+
+// assertBuzzState ルームの早押しの状態と回答者を確認する
+func assertBuzzState(t *testing.T, bs *BuzzService, roomID, state, answerer string) {
+	t.Helper()
+	status, err := bs.Status(roomID)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if status.State != state || status.Answerer != answerer {
+		t.Fatalf("status = %s (answerer %q), want %s (answerer %q)", status.State, status.Answerer, state, answerer)
+	}
+}
+
+// assertQueue ルームの回答キューの並びを確認する
+func assertQueue(t *testing.T, bs *BuzzService, roomID string, want ...string) {
+	t.Helper()
+	queue, err := bs.GetQueue(roomID)
+	if err != nil {
+		t.Fatalf("GetQueue: %v", err)
+	}
+	got := []string{}
+	for _, entry := range queue {
+		got = append(got, entry.PlayerID)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("queue = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("queue = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestBuzzServiceQuestionCycle(t *testing.T) {
+	bs, recorder := newTestBuzzService(t, 0)
+	base := time.Now()
+
+	assertBuzzState(t, bs, "room", BuzzStateIdle, "")
+	if err := bs.AddToQueue("room", "alice", buzzAt(base)); !errors.Is(err, ErrBuzzNotOpen) {
+		t.Fatalf("AddToQueue while idle = %v, want ErrBuzzNotOpen", err)
+	}
+
+	if err := bs.OpenQuestion("room", 1); err != nil {
+		t.Fatalf("OpenQuestion: %v", err)
+	}
+	if err := bs.OpenQuestion("room", 2); !errors.Is(err, ErrInvalidBuzzTransition) {
+		t.Fatalf("OpenQuestion while open = %v, want ErrInvalidBuzzTransition", err)
+	}
+	if _, err := bs.BeginJudging("room", "alice"); !errors.Is(err, ErrNotAnswerer) {
+		t.Fatalf("BeginJudging with an empty queue = %v, want ErrNotAnswerer", err)
+	}
+
+	if err := bs.AddToQueue("room", "alice", buzzAt(base)); err != nil {
+		t.Fatalf("AddToQueue(alice): %v", err)
+	}
+	if err := bs.AddToQueue("room", "alice", buzzAt(base.Add(time.Millisecond))); !errors.Is(err, ErrAlreadyInQueue) {
+		t.Fatalf("AddToQueue(alice) twice = %v, want ErrAlreadyInQueue", err)
+	}
+	if err := bs.AddToQueue("room", "bob", buzzAt(base.Add(2*time.Millisecond))); err != nil {
+		t.Fatalf("AddToQueue(bob): %v", err)
+	}
+	assertBuzzState(t, bs, "room", BuzzStateBuzzed, "alice")
+
+	// キューの先頭のプレイヤーしか判定を始められない
+	if _, err := bs.BeginJudging("room", "bob"); !errors.Is(err, ErrNotAnswerer) {
+		t.Fatalf("BeginJudging(bob) = %v, want ErrNotAnswerer", err)
+	}
+	if _, err := bs.BeginJudging("room", "alice"); err != nil {
+		t.Fatalf("BeginJudging(alice): %v", err)
+	}
+	if _, err := bs.BeginJudging("room", "alice"); !errors.Is(err, ErrInvalidBuzzTransition) {
+		t.Fatalf("BeginJudging twice = %v, want ErrInvalidBuzzTransition", err)
+	}
+
+	// 判定中に押したプレイヤーは先頭を追い越さない
+	if err := bs.AddToQueue("room", "carol", buzzAt(base.Add(-time.Second))); err != nil {
+		t.Fatalf("AddToQueue(carol): %v", err)
+	}
+	assertQueue(t, bs, "room", "alice", "carol", "bob")
+
+	// 誤答なら次のプレイヤーに移る
+	if err := bs.FinishJudging("room", "alice", false); err != nil {
+		t.Fatalf("FinishJudging(alice): %v", err)
+	}
+	assertBuzzState(t, bs, "room", BuzzStateBuzzed, "carol")
+
+	if _, err := bs.BeginJudging("room", "carol"); err != nil {
+		t.Fatalf("BeginJudging(carol): %v", err)
+	}
+	if err := bs.FinishJudging("room", "bob", true); !errors.Is(err, ErrNotAnswerer) {
+		t.Fatalf("FinishJudging(bob) = %v, want ErrNotAnswerer", err)
+	}
+
+	// 正解なら問題を終了する
+	if err := bs.FinishJudging("room", "carol", true); err != nil {
+		t.Fatalf("FinishJudging(carol): %v", err)
+	}
+	assertBuzzState(t, bs, "room", BuzzStateClosed, "")
+	assertQueue(t, bs, "room")
+	if err := bs.AddToQueue("room", "bob", buzzAt(base)); !errors.Is(err, ErrBuzzNotOpen) {
+		t.Fatalf("AddToQueue while closed = %v, want ErrBuzzNotOpen", err)
+	}
+
+	if err := bs.ResetRoom("room"); err != nil {
+		t.Fatalf("ResetRoom: %v", err)
+	}
+	assertBuzzState(t, bs, "room", BuzzStateIdle, "")
+
+	want := []string{
+		BuzzStateOpen, BuzzStateBuzzed, BuzzStateJudging, BuzzStateBuzzed,
+		BuzzStateJudging, BuzzStateClosed, BuzzStateIdle,
+	}
+	if got := recorder.states(); !equalStates(got, want) {
+		t.Errorf("state changes = %v, want %v", got, want)
+	}
+}
+
+func TestBuzzServiceWrongAnswerEmptiesQueue(t *testing.T) {
+	bs, _ := newTestBuzzService(t, 0)
+
+	if err := bs.OpenQuestion("room", 1); err != nil {
+		t.Fatalf("OpenQuestion: %v", err)
+	}
+	if err := bs.AddToQueue("room", "alice", buzzAt(time.Now())); err != nil {
+		t.Fatalf("AddToQueue: %v", err)
+	}
+	if _, err := bs.BeginJudging("room", "alice"); err != nil {
+		t.Fatalf("BeginJudging: %v", err)
+	}
+	if err := bs.FinishJudging("room", "alice", false); err != nil {
+		t.Fatalf("FinishJudging: %v", err)
+	}
+	assertBuzzState(t, bs, "room", BuzzStateOpen, "")
+
+	if err := bs.FinishJudging("room", "alice", false); !errors.Is(err, ErrInvalidBuzzTransition) {
+		t.Errorf("FinishJudging while open = %v, want ErrInvalidBuzzTransition", err)
+	}
+}
+
+func TestBuzzServiceTeammateInQueue(t *testing.T) {
+	bs, _ := newTestBuzzService(t, 0)
+	red, blue := "red", "blue"
+	now := time.Now()
+
+	if err := bs.OpenQuestion("room", 1); err != nil {
+		t.Fatalf("OpenQuestion: %v", err)
+	}
+	if err := bs.AddToQueue("room", "alice", BuzzPress{ReceivedAt: now, TeamID: &red}); err != nil {
+		t.Fatalf("AddToQueue(alice): %v", err)
+	}
+	if err := bs.AddToQueue("room", "bob", BuzzPress{ReceivedAt: now, TeamID: &red}); !errors.Is(err, ErrTeammateInQueue) {
+		t.Errorf("AddToQueue(bob) = %v, want ErrTeammateInQueue", err)
+	}
+	if err := bs.AddToQueue("room", "carol", BuzzPress{ReceivedAt: now, TeamID: &blue}); err != nil {
+		t.Errorf("AddToQueue(carol): %v", err)
+	}
+}
+
+func TestBuzzServiceRemoveFromQueue(t *testing.T) {
+	bs, recorder := newTestBuzzService(t, 0)
+	base := time.Now()
+
+	if err := bs.OpenQuestion("room", 1); err != nil {
+		t.Fatalf("OpenQuestion: %v", err)
+	}
+	for i, playerID := range []string{"alice", "bob"} {
+		if err := bs.AddToQueue("room", playerID, buzzAt(base.Add(time.Duration(i)*time.Millisecond))); err != nil {
+			t.Fatalf("AddToQueue(%s): %v", playerID, err)
+		}
+	}
+
+	// 先頭のプレイヤーが切断すると次のプレイヤーが回答者になる
+	if removed, err := bs.RemoveFromQueue("room", "alice"); err != nil || !removed {
+		t.Fatalf("RemoveFromQueue(alice) = %v, %v", removed, err)
+	}
+	assertBuzzState(t, bs, "room", BuzzStateBuzzed, "bob")
+	if change := recorder.last(); change.State != BuzzStateBuzzed || change.Answerer != "bob" {
+		t.Errorf("last change = %+v, want bob as the answerer", change)
+	}
+	if removed, _ := bs.RemoveFromQueue("room", "alice"); removed {
+		t.Error("RemoveFromQueue of a player not in the queue reported a removal")
+	}
+
+	// 判定中の先頭のプレイヤーは判定が終わるまで残す
+	if _, err := bs.BeginJudging("room", "bob"); err != nil {
+		t.Fatalf("BeginJudging: %v", err)
+	}
+	if removed, _ := bs.RemoveFromQueue("room", "bob"); removed {
+		t.Error("RemoveFromQueue removed the player being judged")
+	}
+	assertBuzzState(t, bs, "room", BuzzStateJudging, "bob")
+
+	if err := bs.FinishJudging("room", "bob", false); err != nil {
+		t.Fatalf("FinishJudging: %v", err)
+	}
+	assertBuzzState(t, bs, "room", BuzzStateOpen, "")
+}
+
+func TestBuzzServiceWithdrawPlayer(t *testing.T) {
+	tests := []struct {
+		name           string
+		queue          []string
+		judging        bool
+		withdraw       string
+		wantRemoved    bool
+		wantWasJudging bool
+		wantState      string
+		wantAnswerer   string
+	}{
+		{"not queued", []string{"alice"}, false, "bob", false, false, BuzzStateBuzzed, "alice"},
+		{"waiting in line", []string{"alice", "bob"}, true, "bob", true, false, BuzzStateJudging, "alice"},
+		{"answerer before judging", []string{"alice", "bob"}, false, "alice", true, false, BuzzStateBuzzed, "bob"},
+		{"judged player with a next player", []string{"alice", "bob"}, true, "alice", true, true, BuzzStateBuzzed, "bob"},
+		{"judged player alone", []string{"alice"}, true, "alice", true, true, BuzzStateOpen, ""},
+		{"only player before judging", []string{"alice"}, false, "alice", true, false, BuzzStateOpen, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs, _ := newTestBuzzService(t, 0)
+			base := time.Now()
+
+			if err := bs.OpenQuestion("room", 1); err != nil {
+				t.Fatalf("OpenQuestion: %v", err)
+			}
+			for i, playerID := range tt.queue {
+				if err := bs.AddToQueue("room", playerID, buzzAt(base.Add(time.Duration(i)*time.Millisecond))); err != nil {
+					t.Fatalf("AddToQueue(%s): %v", playerID, err)
+				}
+			}
+			if tt.judging {
+				if _, err := bs.BeginJudging("room", tt.queue[0]); err != nil {
+					t.Fatalf("BeginJudging: %v", err)
+				}
+			}
+
+			removed, wasJudging, err := bs.WithdrawPlayer("room", tt.withdraw)
+			if err != nil {
+				t.Fatalf("WithdrawPlayer: %v", err)
+			}
+			if removed != tt.wantRemoved || wasJudging != tt.wantWasJudging {
+				t.Errorf("WithdrawPlayer = removed %v, wasJudging %v; want %v, %v", removed, wasJudging, tt.wantRemoved, tt.wantWasJudging)
+			}
+			assertBuzzState(t, bs, "room", tt.wantState, tt.wantAnswerer)
+
+			// 外されたプレイヤーの判定を後から終えても、次のプレイヤーの回答権を奪わない
+			if tt.wantWasJudging {
+				if err := bs.FinishJudging("room", tt.withdraw, true); err == nil {
+					t.Error("FinishJudging for the withdrawn player succeeded")
+				}
+				assertBuzzState(t, bs, "room", tt.wantState, tt.wantAnswerer)
+			}
+		})
+	}
+}
+
+func TestBuzzServiceClearQueue(t *testing.T) {
+	bs, _ := newTestBuzzService(t, 0)
+	base := time.Now()
+
+	if err := bs.OpenQuestion("room", 1); err != nil {
+		t.Fatalf("OpenQuestion: %v", err)
+	}
+	for i, playerID := range []string{"alice", "bob", "carol"} {
+		if err := bs.AddToQueue("room", playerID, buzzAt(base.Add(time.Duration(i)*time.Millisecond))); err != nil {
+			t.Fatalf("AddToQueue(%s): %v", playerID, err)
+		}
+	}
+
+	// 判定中は先頭のプレイヤーだけ残す
+	if _, err := bs.BeginJudging("room", "alice"); err != nil {
+		t.Fatalf("BeginJudging: %v", err)
+	}
+	if err := bs.ClearQueue("room"); err != nil {
+		t.Fatalf("ClearQueue: %v", err)
+	}
+	assertQueue(t, bs, "room", "alice")
+	assertBuzzState(t, bs, "room", BuzzStateJudging, "alice")
+
+	if err := bs.FinishJudging("room", "alice", false); err != nil {
+		t.Fatalf("FinishJudging: %v", err)
+	}
+	if err := bs.AddToQueue("room", "bob", buzzAt(base)); err != nil {
+		t.Fatalf("AddToQueue(bob): %v", err)
+	}
+	if err := bs.ClearQueue("room"); err != nil {
+		t.Fatalf("ClearQueue: %v", err)
+	}
+	assertQueue(t, bs, "room")
+	assertBuzzState(t, bs, "room", BuzzStateOpen, "")
+}
+
+func TestBuzzServiceLockout(t *testing.T) {
+	bs, _ := newTestBuzzService(t, 0)
+
+	if err := bs.OpenQuestion("room", 1); err != nil {
+		t.Fatalf("OpenQuestion: %v", err)
+	}
+	settings := models.LockoutSettings{Mode: models.LockoutModeQuestions, Questions: 1}
+	if err := bs.LockOut("room", "alice", settings); err != nil {
+		t.Fatalf("LockOut: %v", err)
+	}
+
+	// 同じ問題と続く1問は押せず、その次の問題で解除される
+	for question, wantRemaining := range []int{2, 1, 0} {
+		lockout, locked := bs.CheckLockout("room", "alice")
+		if locked != (wantRemaining > 0) || lockout.QuestionsRemaining != wantRemaining {
+			t.Fatalf("question %d: CheckLockout = %+v, %v; want %d remaining", question+1, lockout, locked, wantRemaining)
+		}
+		if err := bs.CloseQuestion("room"); err != nil {
+			t.Fatalf("CloseQuestion: %v", err)
+		}
+		if err := bs.OpenQuestion("room", question+2); err != nil {
+			t.Fatalf("OpenQuestion: %v", err)
+		}
+	}
+
+	// マッチの開始・終了で制限は解除される
+	if err := bs.LockOut("room", "bob", models.LockoutSettings{Mode: models.LockoutModeSeconds, Seconds: 60}); err != nil {
+		t.Fatalf("LockOut: %v", err)
+	}
+	if lockout, locked := bs.CheckLockout("room", "bob"); !locked || lockout.RetryAfter <= 0 {
+		t.Fatalf("CheckLockout(bob) = %+v, %v; want a time lockout", lockout, locked)
+	}
+	if err := bs.ResetRoom("room"); err != nil {
+		t.Fatalf("ResetRoom: %v", err)
+	}
+	if _, locked := bs.CheckLockout("room", "bob"); locked {
+		t.Error("lockout survived ResetRoom")
+	}
+}
+
+func TestBuzzServiceRestoresQueue(t *testing.T) {
+	store := memory.NewStore()
+	base := time.Now()
+	for i, playerID := range []string{"bob", "alice"} {
+		entry := &models.BuzzQueue{
+			ID:        playerID,
+			RoomID:    "room",
+			PlayerID:  playerID,
+			BuzzedAt:  base,
+			PressedAt: base.Add(-time.Duration(i) * time.Millisecond),
+			IsActive:  true,
+		}
+		if err := store.BuzzQueue().AddToQueue(entry); err != nil {
+			t.Fatalf("AddToQueue: %v", err)
+		}
+	}
+
+	// 再起動後は保存されていたキューから buzzed で再開する
+	bs := NewBuzzService(store, 0)
+	go bs.Run()
+	t.Cleanup(bs.Close)
+	assertBuzzState(t, bs, "room", BuzzStateBuzzed, "alice")
+	assertQueue(t, bs, "room", "alice", "bob")
+}
+
+// equalStates 2つの状態の並びが等しいか
+func equalStates(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBuzzServiceCompensation(t *testing.T) {
+	millis := func(v int64) *int64 { return &v }
+	// クライアントの時計はサーバーと1時間ずれている
+	clientNow := time.Now().Add(time.Hour).UnixMilli()
+
+	tests := []struct {
+		name  string
+		press BuzzPress
+		want  time.Duration
+	}{
+		{"no measurements", BuzzPress{}, 0},
+		{"half the RTT", BuzzPress{RTT: 80 * time.Millisecond}, 40 * time.Millisecond},
+		{"RTT and press-to-send delay", BuzzPress{RTT: 80 * time.Millisecond, ClientPressedAt: millis(clientNow), ClientSentAt: millis(clientNow + 30)}, 70 * time.Millisecond},
+		{"only one client timestamp", BuzzPress{RTT: 80 * time.Millisecond, ClientPressedAt: millis(clientNow)}, 40 * time.Millisecond},
+		{"sent before pressed", BuzzPress{ClientPressedAt: millis(clientNow), ClientSentAt: millis(clientNow - 500)}, 0},
+		{"clamped to the maximum", BuzzPress{RTT: 100 * time.Millisecond, ClientPressedAt: millis(clientNow), ClientSentAt: millis(clientNow + 5000)}, 150 * time.Millisecond},
+		{"RTT alone over the maximum", BuzzPress{RTT: time.Second}, 150 * time.Millisecond},
+		{"negative RTT", BuzzPress{RTT: -100 * time.Millisecond}, 0},
+	}
+
+	bs := NewBuzzService(memory.NewStore(), 150*time.Millisecond)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bs.Compensation(tt.press); got != tt.want {
+				t.Errorf("Compensation = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuzzServiceOrdersByCompensatedPress(t *testing.T) {
+	bs, _ := newTestBuzzService(t, 100*time.Millisecond)
+	millis := func(v int64) *int64 { return &v }
+	base := time.Now()
+	behind := base.Add(-time.Hour).UnixMilli() // 1時間遅れた時計
+	ahead := base.Add(time.Hour).UnixMilli()   // 1時間進んだ時計
+
+	if err := bs.OpenQuestion("room", 1); err != nil {
+		t.Fatalf("OpenQuestion: %v", err)
+	}
+
+	presses := []struct {
+		playerID string
+		press    BuzzPress
+	}{
+		// 受信は最初だが補正なし：base
+		{"bob", BuzzPress{ReceivedAt: base}},
+		// 遅れた時計・遅い回線：base+40ms - (20ms + 30ms) = base-10ms
+		{"alice", BuzzPress{ReceivedAt: base.Add(40 * time.Millisecond), RTT: 40 * time.Millisecond, ClientPressedAt: millis(behind), ClientSentAt: millis(behind + 30)}},
+		// 進んだ時計：base+10ms - 5ms = base+5ms
+		{"carol", BuzzPress{ReceivedAt: base.Add(10 * time.Millisecond), ClientPressedAt: millis(ahead), ClientSentAt: millis(ahead + 5)}},
+		// 押してから送るまで1秒と申告しても上限の100msまでしか補正しない：base+300ms - 100ms = base+200ms
+		{"dave", BuzzPress{ReceivedAt: base.Add(300 * time.Millisecond), ClientPressedAt: millis(ahead), ClientSentAt: millis(ahead + 1000)}},
+		// 補正後の押下時刻が bob と同じなら受信順：base+30ms - 30ms = base
+		{"erin", BuzzPress{ReceivedAt: base.Add(30 * time.Millisecond), RTT: 60 * time.Millisecond}},
+	}
+	for _, p := range presses {
+		if err := bs.AddToQueue("room", p.playerID, p.press); err != nil {
+			t.Fatalf("AddToQueue(%s): %v", p.playerID, err)
+		}
+	}
+
+	assertQueue(t, bs, "room", "alice", "bob", "erin", "carol", "dave")
+	assertBuzzState(t, bs, "room", BuzzStateBuzzed, "alice")
+
+	queue, err := bs.GetQueue("room")
+	if err != nil {
+		t.Fatalf("GetQueue: %v", err)
+	}
+	if got := queue[0].BuzzedAt.Sub(queue[0].PressedAt); got != 50*time.Millisecond || queue[0].RTTMillis != 40 {
+		t.Errorf("alice compensated by %v with RTT %dms, want 50ms with RTT 40ms", got, queue[0].RTTMillis)
+	}
+}
+
+// BenchmarkBuzzConcurrentPresses N 人が一斉に2回ずつ連打した場合の早押しの処理時間を測定する
+// キューに1人1件だけ入ることと、全件がストレージに書き込まれることも確認する
+func BenchmarkBuzzConcurrentPresses(b *testing.B) {
+	const presses = 2
+
+	for _, buzzers := range []int{10, 100, 500} {
+		b.Run(fmt.Sprintf("buzzers=%d", buzzers), func(b *testing.B) {
+			store := memory.NewStore()
+			bs := NewBuzzService(store, 0)
+			go bs.Run()
+
+			playerIDs := make([]string, buzzers)
+			for i := range playerIDs {
+				playerIDs[i] = fmt.Sprintf("buzzer-%d", i+1)
+			}
+			latencies := make([]time.Duration, 0, b.N*buzzers*presses)
+			var mu sync.Mutex
+
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				if err := bs.OpenQuestion("room", n+1); err != nil {
+					b.Fatalf("OpenQuestion: %v", err)
+				}
+
+				// 全員が揃ってから一斉に押す
+				var wg sync.WaitGroup
+				start := make(chan struct{})
+				for _, playerID := range playerIDs {
+					wg.Add(1)
+					go func(playerID string) {
+						defer wg.Done()
+						<-start
+
+						for i := 0; i < presses; i++ {
+							pressedAt := time.Now()
+							err := bs.AddToQueue("room", playerID, BuzzPress{ReceivedAt: pressedAt})
+							elapsed := time.Since(pressedAt)
+							if err != nil && !errors.Is(err, ErrAlreadyInQueue) {
+								b.Errorf("AddToQueue(%s): %v", playerID, err)
+							}
+
+							mu.Lock()
+							latencies = append(latencies, elapsed)
+							mu.Unlock()
+						}
+					}(playerID)
+				}
+				close(start)
+				wg.Wait()
+
+				b.StopTimer()
+				queue, err := bs.GetQueue("room")
+				if err != nil {
+					b.Fatalf("GetQueue: %v", err)
+				}
+				if len(queue) != buzzers {
+					b.Fatalf("queue length = %d, want %d", len(queue), buzzers)
+				}
+				if err := bs.CloseQuestion("room"); err != nil {
+					b.Fatalf("CloseQuestion: %v", err)
+				}
+				b.StartTimer()
+			}
+
+			// 書き込み待ちのイベントをストレージに反映するまでの時間も含める
+			bs.Close()
+			b.StopTimer()
+
+			history, err := store.BuzzQueue().GetBuzzHistory("room")
+			if err != nil {
+				b.Fatalf("GetBuzzHistory: %v", err)
+			}
+			if len(history) != b.N*buzzers {
+				b.Fatalf("persisted %d buzz-ins, want %d", len(history), b.N*buzzers)
+			}
+
+			sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+			percentile := func(p float64) float64 {
+				return float64(latencies[int(float64(len(latencies)-1)*p)].Nanoseconds())
+			}
+			b.ReportMetric(percentile(0.5), "p50-ns")
+			b.ReportMetric(percentile(0.99), "p99-ns")
+		})
+	}
+}
@@ -1,170 +1,289 @@
 package services
 
 import (
-	"database/sql"
+	"errors"
 	"fmt"
+	"math/rand"
+	"sort"
 	"time"
 
-	"quivra-backend/database"
 	"quivra-backend/models"
+	"quivra-backend/repository"
 )
 
-type GameService struct {
-	db *database.DB
-}
+// DefaultMatchQuestionCount 1マッチあたりのデフォルト問題数
+const DefaultMatchQuestionCount = 10
 
-func NewGameService(db *database.DB) *GameService {
-	return &GameService{db: db}
-}
+// MaxMatchQuestionCount 1マッチあたりの最大問題数
+const MaxMatchQuestionCount = 100
 
-// CreateGameSession ゲームセッションを作成
-func (gs *GameService) CreateGameSession(roomID string) (*models.GameSession, error) {
-	sessionID := generateSessionID()
+var (
+	// ErrNoRemainingQuestions マッチの全問題が出題済み
+	ErrNoRemainingQuestions = repository.ErrNoRemainingQuestions
+	// ErrInvalidMatchSpec 出題条件（問題数・難易度の配分）が不正
+	ErrInvalidMatchSpec = errors.New("invalid match question spec")
+	// ErrNoMatchingQuestions 出題条件に合う問題がない
+	ErrNoMatchingQuestions = errors.New("no questions match the spec")
+)
 
-	query := `INSERT INTO game_sessions (id, room_id, status) VALUES (?, ?, 'waiting')`
-	_, err := gs.db.Exec(query, sessionID, roomID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create game session: %w", err)
-	}
+// MatchQuestionSpec マッチで出題する問題の選び方
+type MatchQuestionSpec struct {
+	Count         int            // 0 なら問題セットの全問（問題セットなしなら DefaultMatchQuestionCount）
+	PackID        *int           // 指定すると問題セットの中から選ぶ
+	Categories    []string       // 指定するといずれかのカテゴリの問題だけを選ぶ
+	DifficultyMix map[string]int // 難易度ごとの比率（例: easy:2, medium:1）。Count をこの比で配分する
+}
 
-	return &models.GameSession{
-		ID:     sessionID,
-		RoomID: roomID,
-		Status: "waiting",
-	}, nil
+type GameService struct {
+	store    repository.Store
+	sessions *SessionStateMachine
 }
 
-// StartQuestion 問題を開始
-func (gs *GameService) StartQuestion(sessionID string, questionID int) error {
-	query := `UPDATE game_sessions SET question_id = ?, status = 'question', started_at = NOW() WHERE id = ?`
-	_, err := gs.db.Exec(query, questionID, sessionID)
-	if err != nil {
-		return fmt.Errorf("failed to start question: %w", err)
+func NewGameService(store repository.Store) *GameService {
+	return &GameService{
+		store:    store,
+		sessions: NewSessionStateMachine(store.Sessions()),
 	}
-	return nil
 }
 
-// SetBuzzedPlayer 早押しプレイヤーを設定
-func (gs *GameService) SetBuzzedPlayer(sessionID, playerID string) error {
-	query := `UPDATE game_sessions SET buzzed_player_id = ?, status = 'buzzed' WHERE id = ?`
-	_, err := gs.db.Exec(query, playerID, sessionID)
+// PlanMatchQuestions 条件に従ってマッチの出題順を決める（同じ問題は含まない）
+// 条件に合う問題が Count に満たない場合は、ある分だけを返す
+func (gs *GameService) PlanMatchQuestions(spec MatchQuestionSpec) ([]int, error) {
+	if spec.Count < 0 || spec.Count > MaxMatchQuestionCount {
+		return nil, fmt.Errorf("%w: question count must be between 0 and %d", ErrInvalidMatchSpec, MaxMatchQuestionCount)
+	}
+	mixTotal := 0
+	for difficulty, weight := range spec.DifficultyMix {
+		if !isValidDifficulty(difficulty) {
+			return nil, fmt.Errorf("%w: unknown difficulty %q", ErrInvalidMatchSpec, difficulty)
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("%w: difficulty weight must not be negative", ErrInvalidMatchSpec)
+		}
+		mixTotal += weight
+	}
+	if len(spec.DifficultyMix) > 0 && mixTotal == 0 {
+		return nil, fmt.Errorf("%w: difficulty mix must have a positive weight", ErrInvalidMatchSpec)
+	}
+
+	candidates, err := gs.matchCandidates(spec)
 	if err != nil {
-		return fmt.Errorf("failed to set buzzed player: %w", err)
+		return nil, err
 	}
-	return nil
-}
 
-// EndQuestion 問題を終了
-func (gs *GameService) EndQuestion(sessionID string, correct bool) error {
-	status := "finished"
-	if correct {
-		status = "answered"
+	count := spec.Count
+	if count == 0 {
+		count = DefaultMatchQuestionCount
+		if spec.PackID != nil {
+			count = min(len(candidates), MaxMatchQuestionCount)
+		}
 	}
 
-	query := `UPDATE game_sessions SET status = ?, ended_at = NOW() WHERE id = ?`
-	_, err := gs.db.Exec(query, status, sessionID)
-	if err != nil {
-		return fmt.Errorf("failed to end question: %w", err)
+	var questionIDs []int
+	if mixTotal == 0 {
+		for _, question := range candidates {
+			if len(questionIDs) == count {
+				break
+			}
+			questionIDs = append(questionIDs, question.ID)
+		}
+	} else {
+		quotas := splitByWeight(count, spec.DifficultyMix, mixTotal)
+		for _, question := range candidates {
+			if quotas[question.Difficulty] > 0 {
+				quotas[question.Difficulty]--
+				questionIDs = append(questionIDs, question.ID)
+			}
+		}
+	}
+
+	if len(questionIDs) == 0 {
+		return nil, ErrNoMatchingQuestions
 	}
-	return nil
+	return questionIDs, nil
 }
 
-// GetGameSession ゲームセッションを取得
-func (gs *GameService) GetGameSession(sessionID string) (*models.GameSession, error) {
-	var session models.GameSession
-	query := `SELECT id, room_id, question_id, started_at, ended_at, status, buzzed_player_id FROM game_sessions WHERE id = ?`
+// matchCandidates 出題候補を出題順に並べて返す
+// 問題セットは登録順（シャッフル指定時は無作為）、問題セットなしなら全問題を無作為に並べる
+func (gs *GameService) matchCandidates(spec MatchQuestionSpec) ([]models.Question, error) {
+	questions, err := gs.store.Questions().GetQuestions("", "")
+	if err != nil {
+		return nil, err
+	}
 
-	var questionID sql.NullInt64
-	var endedAt sql.NullTime
-	var buzzedPlayerID sql.NullString
+	shuffle := true
+	if spec.PackID != nil {
+		pack, err := gs.store.Packs().GetPack(*spec.PackID)
+		if err != nil {
+			return nil, err
+		}
+		shuffle = pack.Shuffle
 
-	err := gs.db.QueryRow(query, sessionID).Scan(
-		&session.ID, &session.RoomID, &questionID, &session.StartedAt,
-		&endedAt, &session.Status, &buzzedPlayerID,
-	)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("game session not found")
+		byID := make(map[int]models.Question, len(questions))
+		for _, question := range questions {
+			byID[question.ID] = question
+		}
+		questions = questions[:0]
+		for _, questionID := range pack.QuestionIDs {
+			if question, ok := byID[questionID]; ok {
+				questions = append(questions, question)
+			}
 		}
-		return nil, fmt.Errorf("failed to get game session: %w", err)
 	}
 
-	if questionID.Valid {
-		qid := int(questionID.Int64)
-		session.QuestionID = &qid
+	if len(spec.Categories) > 0 {
+		categories := make(map[string]bool, len(spec.Categories))
+		for _, category := range spec.Categories {
+			categories[category] = true
+		}
+		filtered := questions[:0]
+		for _, question := range questions {
+			if categories[question.Category] {
+				filtered = append(filtered, question)
+			}
+		}
+		questions = filtered
 	}
-	if endedAt.Valid {
-		session.EndedAt = &endedAt.Time
+
+	if shuffle {
+		rand.Shuffle(len(questions), func(i, j int) {
+			questions[i], questions[j] = questions[j], questions[i]
+		})
 	}
-	if buzzedPlayerID.Valid {
-		pid := buzzedPlayerID.String
-		session.BuzzedPlayerID = &pid
+	return questions, nil
+}
+
+// splitByWeight count を比率で難易度ごとに配分する（端数は余りの大きい順に1問ずつ割り当てる）
+func splitByWeight(count int, weights map[string]int, total int) map[string]int {
+	quotas := make(map[string]int, len(weights))
+	remainders := make(map[string]int, len(weights))
+	assigned := 0
+	for _, difficulty := range questionDifficulties {
+		quotas[difficulty] = count * weights[difficulty] / total
+		remainders[difficulty] = count * weights[difficulty] % total
+		assigned += quotas[difficulty]
 	}
 
-	return &session, nil
+	order := append([]string{}, questionDifficulties...)
+	sort.SliceStable(order, func(i, j int) bool {
+		return remainders[order[i]] > remainders[order[j]]
+	})
+	for i := 0; assigned < count; i++ {
+		quotas[order[i]]++
+		assigned++
+	}
+	return quotas
 }
 
-// GetActiveGameSession アクティブなゲームセッションを取得
-func (gs *GameService) GetActiveGameSession(roomID string) (*models.GameSession, error) {
-	var session models.GameSession
-	query := `SELECT id, room_id, question_id, started_at, ended_at, status, buzzed_player_id
-			  FROM game_sessions
-			  WHERE room_id = ? AND status IN ('waiting', 'question', 'buzzed')
-			  ORDER BY started_at DESC LIMIT 1`
-
-	var questionID sql.NullInt64
-	var endedAt sql.NullTime
-	var buzzedPlayerID sql.NullString
-
-	err := gs.db.QueryRow(query, roomID).Scan(
-		&session.ID, &session.RoomID, &questionID, &session.StartedAt,
-		&endedAt, &session.Status, &buzzedPlayerID,
-	)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("no active game session found")
-		}
-		return nil, fmt.Errorf("failed to get active game session: %w", err)
+// CreateMatch 決めた出題順でマッチを作成
+func (gs *GameService) CreateMatch(roomID string, questionIDs []int) (*models.Match, error) {
+	if len(questionIDs) == 0 {
+		return nil, ErrNoMatchingQuestions
 	}
 
-	if questionID.Valid {
-		qid := int(questionID.Int64)
-		session.QuestionID = &qid
+	match := &models.Match{
+		ID:             generateSessionID(),
+		RoomID:         roomID,
+		TotalQuestions: len(questionIDs),
+		QuestionIDs:    questionIDs,
+		CurrentNumber:  0,
+		Status:         "playing",
+		StartedAt:      time.Now(),
 	}
-	if endedAt.Valid {
-		session.EndedAt = &endedAt.Time
-	}
-	if buzzedPlayerID.Valid {
-		pid := buzzedPlayerID.String
-		session.BuzzedPlayerID = &pid
+	if err := gs.store.Sessions().CreateMatch(match); err != nil {
+		return nil, err
 	}
 
-	return &session, nil
+	return match, nil
+}
+
+// GetActiveMatch 進行中のマッチを取得
+func (gs *GameService) GetActiveMatch(roomID string) (*models.Match, error) {
+	return gs.store.Sessions().GetActiveMatch(roomID)
 }
 
-// CalculateScore スコアを計算
-func (gs *GameService) CalculateScore(difficulty string, timeToAnswer time.Duration) int {
-	baseScore := 100
+// AdvanceMatch マッチを次の問題番号に進める
+func (gs *GameService) AdvanceMatch(matchID string) (int, error) {
+	return gs.store.Sessions().AdvanceMatch(matchID)
+}
 
-	// 難易度による基本スコア調整
-	switch difficulty {
-	case "easy":
-		baseScore = 50
-	case "medium":
-		baseScore = 100
-	case "hard":
-		baseScore = 200
+// GetMatchQuestionID マッチの number 問目に出題する問題のIDを取得
+func (gs *GameService) GetMatchQuestionID(matchID string, number int) (int, error) {
+	return gs.store.Sessions().GetMatchQuestionID(matchID, number)
+}
+
+// FinishMatch マッチを終了
+func (gs *GameService) FinishMatch(matchID string) error {
+	return gs.store.Sessions().FinishMatch(matchID)
+}
+
+// GetMatchSummary マッチの結果一覧を取得
+func (gs *GameService) GetMatchSummary(matchID string) (*models.MatchSummary, error) {
+	return gs.store.Sessions().GetMatchSummary(matchID)
+}
+
+// CreateGameSession ゲームセッションを作成
+func (gs *GameService) CreateGameSession(roomID, matchID string, questionNumber int) (*models.GameSession, error) {
+	session := &models.GameSession{
+		ID:             generateSessionID(),
+		RoomID:         roomID,
+		MatchID:        &matchID,
+		QuestionNumber: questionNumber,
+		Status:         SessionWaiting,
+	}
+	if err := gs.store.Sessions().CreateGameSession(session); err != nil {
+		return nil, err
 	}
 
-	// 回答時間によるボーナス（5秒以内で最大ボーナス）
-	timeBonus := 0
-	if timeToAnswer <= 5*time.Second {
-		timeBonus = 50
-	} else if timeToAnswer <= 10*time.Second {
-		timeBonus = 25
+	return session, nil
+}
+
+// StartQuestion 問題を出題する（waiting → question）。出題した問題の版も記録する
+func (gs *GameService) StartQuestion(session *models.GameSession, question *models.Question) error {
+	now := time.Now()
+	return gs.sessions.Transition(session, "start", SessionQuestion, models.GameSessionChange{
+		QuestionID:       &question.ID,
+		QuestionRevision: &question.Revision,
+		StartedAt:        &now,
+	})
+}
+
+// BeginAnswer 早押ししたプレイヤーの回答の判定を始める（question → buzzed）
+func (gs *GameService) BeginAnswer(session *models.GameSession, playerID string) error {
+	return gs.sessions.Transition(session, "answer", SessionBuzzed, models.GameSessionChange{
+		BuzzedPlayerID: &playerID,
+	})
+}
+
+// FinishAnswer 回答の判定を終える。endQuestion なら正解者として問題を終了し（buzzed → answered）、
+// そうでなければ回答の受付に戻る（buzzed → question）
+func (gs *GameService) FinishAnswer(session *models.GameSession, playerID string, endQuestion bool) error {
+	if endQuestion {
+		return gs.sessions.Transition(session, "judge", SessionAnswered, models.GameSessionChange{
+			BuzzedPlayerID: &playerID,
+		})
 	}
+	return gs.sessions.Transition(session, "judge", SessionQuestion, models.GameSessionChange{})
+}
+
+// CheckAcceptingAnswers 回答を受け付けている問題か確認する（全員正解モードの回答など、状態を変えない回答用）
+func (gs *GameService) CheckAcceptingAnswers(session *models.GameSession) error {
+	return gs.sessions.Require(session, "answer", SessionQuestion, SessionBuzzed)
+}
 
-	return baseScore + timeBonus
+// EndQuestion 正解者なしで問題を終了する（時間切れ・スキップ。→ finished）
+func (gs *GameService) EndQuestion(session *models.GameSession) error {
+	return gs.sessions.Transition(session, "end", SessionFinished, models.GameSessionChange{})
+}
+
+// GetGameSession ゲームセッションを取得
+func (gs *GameService) GetGameSession(sessionID string) (*models.GameSession, error) {
+	return gs.store.Sessions().GetGameSession(sessionID)
+}
+
+// GetActiveGameSession アクティブなゲームセッションを取得
+func (gs *GameService) GetActiveGameSession(roomID string) (*models.GameSession, error) {
+	return gs.store.Sessions().GetActiveGameSession(roomID)
 }
 
 // generateSessionID セッションIDを生成
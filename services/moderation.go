@@ -0,0 +1,167 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"quivra-backend/models"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	// ErrPlayerBanned ルームへの参加を禁止された名前
+	ErrPlayerBanned = errors.New("player is banned from this room")
+	// ErrPlayerNotFound ルームにプレイヤーがいない
+	ErrPlayerNotFound = errors.New("player not found in room")
+	// ErrCannotModerateAdmin 管理者はキック・参加禁止・ミュートできない
+	ErrCannotModerateAdmin = errors.New("cannot moderate a room admin")
+	// ErrInvalidBanTarget 参加禁止の対象（プレイヤーIDまたは名前）が指定されていない
+	ErrInvalidBanTarget = errors.New("player id or name is required")
+)
+
+// maxBanNameLength 参加禁止にする名前の最大文字数（players.name と同じ）
+const maxBanNameLength = 50
+
+// banName 参加禁止の照合に使う名前
+// NormalizeAnswer と同じく全角/半角・ひらがな/カタカナ・大文字/小文字の違いと空白・句読点を無視する
+// （"Ｂｏｂ" や "Bob." で禁止をすり抜けられないように）。記号だけの名前は NFKC 正規化と小文字化のみ行う
+func banName(name string) string {
+	key := NormalizeAnswer(name)
+	if key == "" {
+		key = strings.ToLower(strings.TrimSpace(norm.NFKC.String(name)))
+	}
+	if runes := []rune(key); len(runes) > maxBanNameLength {
+		key = string(runes[:maxBanNameLength])
+	}
+	return key
+}
+
+// checkBanned 名前がルームへの参加を禁止されていれば ErrPlayerBanned
+func (rs *RoomService) checkBanned(roomID, name string) error {
+	banned, err := rs.store.Rooms().IsNameBanned(roomID, banName(name))
+	if err != nil {
+		return err
+	}
+	if banned {
+		return ErrPlayerBanned
+	}
+	return nil
+}
+
+// CheckPlayerBanned プレイヤーIDがルームへの参加を禁止されていれば ErrPlayerBanned
+// 参加禁止されたプレイヤーのトークンを持つクライアントが、名前を変えて参加し直すのを拒否するために使う
+func (rs *RoomService) CheckPlayerBanned(roomID, playerID string) error {
+	banned, err := rs.store.Rooms().IsPlayerBanned(roomID, playerID)
+	if err != nil {
+		return err
+	}
+	if banned {
+		return ErrPlayerBanned
+	}
+	return nil
+}
+
+// moderatablePlayer キック・参加禁止・ミュートの対象となるプレイヤーを取得（管理者は対象にできない）
+func (rs *RoomService) moderatablePlayer(roomID, playerID string) (*models.Player, error) {
+	player, err := rs.GetRoomPlayer(roomID, playerID)
+	if err != nil {
+		return nil, ErrPlayerNotFound
+	}
+	if player.IsAdmin {
+		return nil, ErrCannotModerateAdmin
+	}
+	return player, nil
+}
+
+// OnRemovePlayer キック・参加禁止でプレイヤーを削除する直前に呼ぶ関数を設定する（回答キューの判定中の回答権を閉じるため）
+func (rs *RoomService) OnRemovePlayer(fn func(roomID, playerID string)) {
+	rs.onRemovePlayer = fn
+}
+
+// RemovePlayer プレイヤーをルームから外す（キック）。発行済みのトークンは使えなくなるが、同じ名前で参加し直せる
+func (rs *RoomService) RemovePlayer(roomID, playerID string) (*models.Player, error) {
+	player, err := rs.moderatablePlayer(roomID, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := rs.deletePlayer(roomID, playerID); err != nil {
+		return nil, err
+	}
+	return player, nil
+}
+
+// BanPlayer プレイヤーの名前とプレイヤーIDをルームが存在する間参加禁止にし、ルームから外す
+func (rs *RoomService) BanPlayer(roomID, playerID string) (*models.Player, error) {
+	player, err := rs.moderatablePlayer(roomID, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	ban := &models.RoomBan{RoomID: roomID, Name: banName(player.Name), PlayerID: &player.ID}
+	if err := rs.store.Rooms().AddBan(ban); err != nil {
+		return nil, err
+	}
+	if err := rs.deletePlayer(roomID, playerID); err != nil {
+		return nil, err
+	}
+	return player, nil
+}
+
+// deletePlayer OnRemovePlayer で設定した関数を呼んでからプレイヤーを削除する
+func (rs *RoomService) deletePlayer(roomID, playerID string) error {
+	if rs.onRemovePlayer != nil {
+		rs.onRemovePlayer(roomID, playerID)
+	}
+	return rs.store.Players().DeletePlayer(roomID, playerID)
+}
+
+// BanName 名前をルームが存在する間参加禁止にする
+// その名前のプレイヤーが参加中であればルームから外して返す（いなければ nil）
+func (rs *RoomService) BanName(roomID, name string) (*models.Player, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidBanTarget
+	}
+	if utf8.RuneCountInString(name) > maxBanNameLength {
+		return nil, fmt.Errorf("%w: name must be at most %d characters", ErrInvalidBanTarget, maxBanNameLength)
+	}
+	if _, err := rs.store.Rooms().GetRoom(roomID); err != nil {
+		return nil, fmt.Errorf("room not found: %w", err)
+	}
+
+	players, err := rs.GetRoomPlayers(roomID)
+	if err != nil {
+		return nil, err
+	}
+	for _, player := range players {
+		if banName(player.Name) == banName(name) {
+			return rs.BanPlayer(roomID, player.ID)
+		}
+	}
+
+	if err := rs.store.Rooms().AddBan(&models.RoomBan{RoomID: roomID, Name: banName(name)}); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// GetRoomBans ルームの参加禁止の一覧
+func (rs *RoomService) GetRoomBans(roomID string) ([]models.RoomBan, error) {
+	return rs.store.Rooms().GetRoomBans(roomID)
+}
+
+// SetPlayerMuted プレイヤーのミュートを切り替える（ミュート中は早押し・回答できない）
+func (rs *RoomService) SetPlayerMuted(roomID, playerID string, muted bool) (*models.Player, error) {
+	player, err := rs.moderatablePlayer(roomID, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := rs.store.Players().SetPlayerMuted(roomID, playerID, muted); err != nil {
+		return nil, err
+	}
+	player.Muted = muted
+	return player, nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"quivra-backend/models"
+	"quivra-backend/repository/memory"
+)
+
+func TestBanPlayerNameVariants(t *testing.T) {
+	rs := NewRoomService(memory.NewStore())
+	room, err := rs.CreateRoom("room", false, "host", models.RoomSettings{})
+	if err != nil {
+		t.Fatalf("CreateRoom: %v", err)
+	}
+	bob, err := rs.AddPlayer(room.ID, "Bob")
+	if err != nil {
+		t.Fatalf("AddPlayer: %v", err)
+	}
+	if _, err := rs.BanPlayer(room.ID, bob.ID); err != nil {
+		t.Fatalf("BanPlayer: %v", err)
+	}
+	if _, err := rs.BanName(room.ID, "たろう"); err != nil {
+		t.Fatalf("BanName: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		banned bool
+	}{
+		{"Bob", true},
+		{" bob ", true},
+		{"Ｂｏｂ", true},
+		{"Bob.", true},
+		{"B o b", true},
+		{"タロウ", true},
+		{"ﾀﾛｳ", true},
+		{"Bobby", false},
+		{"Rob", false},
+	}
+	for _, tt := range tests {
+		_, err := rs.AddPlayer(room.ID, tt.name)
+		if got := errors.Is(err, ErrPlayerBanned); got != tt.banned {
+			t.Errorf("AddPlayer(%q) = %v, want banned: %v", tt.name, err, tt.banned)
+		}
+	}
+}
+
+func TestCheckPlayerBannedWithRevokedToken(t *testing.T) {
+	store := memory.NewStore()
+	rs := NewRoomService(store)
+	auth := NewAuthService(store, []byte("test-secret"), time.Hour, "")
+
+	room, err := rs.CreateRoom("room", false, "host", models.RoomSettings{})
+	if err != nil {
+		t.Fatalf("CreateRoom: %v", err)
+	}
+	bob, err := rs.AddPlayer(room.ID, "Bob")
+	if err != nil {
+		t.Fatalf("AddPlayer: %v", err)
+	}
+	kicked, err := rs.AddPlayer(room.ID, "Carol")
+	if err != nil {
+		t.Fatalf("AddPlayer: %v", err)
+	}
+	token, _, err := auth.IssueToken(room.ID, bob.ID)
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+	if _, err := rs.BanPlayer(room.ID, bob.ID); err != nil {
+		t.Fatalf("BanPlayer: %v", err)
+	}
+	if _, err := rs.RemovePlayer(room.ID, kicked.ID); err != nil {
+		t.Fatalf("RemovePlayer: %v", err)
+	}
+
+	// 参加禁止で失効したトークンからも、参加し直そうとしているのが誰かはわかる
+	if _, err := auth.Authenticate(token); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Authenticate after the ban = %v, want ErrTokenRevoked", err)
+	}
+	roomID, playerID, err := auth.PreviousPlayer(token)
+	if err != nil || roomID != room.ID || playerID != bob.ID {
+		t.Fatalf("PreviousPlayer = %s, %s, %v; want %s, %s", roomID, playerID, err, room.ID, bob.ID)
+	}
+	if err := rs.CheckPlayerBanned(room.ID, playerID); !errors.Is(err, ErrPlayerBanned) {
+		t.Errorf("CheckPlayerBanned(bob) = %v, want ErrPlayerBanned", err)
+	}
+
+	// キックされただけのプレイヤーは参加し直せる
+	if err := rs.CheckPlayerBanned(room.ID, kicked.ID); err != nil {
+		t.Errorf("CheckPlayerBanned(kicked) = %v, want nil", err)
+	}
+	if _, _, err := auth.PreviousPlayer(token + "x"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("PreviousPlayer with a bad signature = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"quivra-backend/models"
+	"quivra-backend/repository"
+)
+
+// MaxPackNameLength 問題セット名の最大文字数
+const MaxPackNameLength = 100
+
+var (
+	// ErrInvalidPack 問題セットの内容が不正
+	ErrInvalidPack = errors.New("invalid question pack")
+	// ErrPackNotFound 問題セットが存在しない
+	ErrPackNotFound = repository.ErrPackNotFound
+)
+
+type PackService struct {
+	store repository.Store
+}
+
+func NewPackService(store repository.Store) *PackService {
+	return &PackService{store: store}
+}
+
+// CreatePack 問題セットを作成
+func (ps *PackService) CreatePack(name, description string, shuffle bool, questionIDs []int) (*models.QuestionPack, error) {
+	pack := &models.QuestionPack{
+		Name:        strings.TrimSpace(name),
+		Description: strings.TrimSpace(description),
+		Shuffle:     shuffle,
+		QuestionIDs: questionIDs,
+	}
+	if err := ps.validatePack(pack); err != nil {
+		return nil, err
+	}
+
+	if err := ps.store.Packs().CreatePack(pack); err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
+
+// GetPacks 問題セット一覧を取得
+func (ps *PackService) GetPacks() ([]models.QuestionPack, error) {
+	return ps.store.Packs().GetPacks()
+}
+
+// GetPack 問題セットを取得
+func (ps *PackService) GetPack(id int) (*models.QuestionPack, error) {
+	return ps.store.Packs().GetPack(id)
+}
+
+// UpdatePack 問題セットの内容と収録する問題を置き換える
+func (ps *PackService) UpdatePack(id int, name, description string, shuffle bool, questionIDs []int) (*models.QuestionPack, error) {
+	pack := &models.QuestionPack{
+		ID:          id,
+		Name:        strings.TrimSpace(name),
+		Description: strings.TrimSpace(description),
+		Shuffle:     shuffle,
+		QuestionIDs: questionIDs,
+	}
+	if err := ps.validatePack(pack); err != nil {
+		return nil, err
+	}
+
+	if err := ps.store.Packs().UpdatePack(pack); err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
+
+// DeletePack 問題セットを削除
+func (ps *PackService) DeletePack(id int) error {
+	return ps.store.Packs().DeletePack(id)
+}
+
+// validatePack 名前・収録する問題を検証する（重複や存在しない問題は受け付けない）
+func (ps *PackService) validatePack(pack *models.QuestionPack) error {
+	if pack.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPack)
+	}
+	if utf8.RuneCountInString(pack.Name) > MaxPackNameLength {
+		return fmt.Errorf("%w: name must be at most %d characters", ErrInvalidPack, MaxPackNameLength)
+	}
+	if len(pack.QuestionIDs) == 0 {
+		return fmt.Errorf("%w: at least one question is required", ErrInvalidPack)
+	}
+
+	questions, err := ps.store.Questions().GetQuestions("", "")
+	if err != nil {
+		return err
+	}
+	exists := make(map[int]bool, len(questions))
+	for _, question := range questions {
+		exists[question.ID] = true
+	}
+
+	seen := make(map[int]bool, len(pack.QuestionIDs))
+	for _, questionID := range pack.QuestionIDs {
+		if seen[questionID] {
+			return fmt.Errorf("%w: question %d is listed more than once", ErrInvalidPack, questionID)
+		}
+		if !exists[questionID] {
+			return fmt.Errorf("%w: question %d does not exist", ErrInvalidPack, questionID)
+		}
+		seen[questionID] = true
+	}
+	return nil
+}
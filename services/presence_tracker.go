@@ -0,0 +1,160 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// プレイヤーの在席状態
+const (
+	PresenceOnline  = "online"  // 接続中
+	PresenceAway    = "away"    // 離席中（クライアントの申告）または切断後の再接続待ち
+	PresenceOffline = "offline" // 切断後、再接続の猶予時間を過ぎた
+)
+
+// PresenceTracker ルームごとのプレイヤーの在席状態を管理する
+type PresenceTracker struct {
+	mu    sync.Mutex
+	rooms map[string]map[string]*playerPresence // roomId -> playerId -> 在席状態
+}
+
+type playerPresence struct {
+	connections int  // 接続数（複数タブ・端末）
+	away        bool // クライアントが離席を申告している
+	status      string
+
+	disconnectedAt time.Time // 最後の接続が切れた時刻
+}
+
+func NewPresenceTracker() *PresenceTracker {
+	return &PresenceTracker{
+		rooms: make(map[string]map[string]*playerPresence),
+	}
+}
+
+// Connect 接続を記録する。状態が変わった場合は新しい状態と true を返す
+func (pt *PresenceTracker) Connect(roomID, playerID string) (string, bool) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	presence := pt.get(roomID, playerID)
+	presence.connections++
+	return pt.update(presence)
+}
+
+// Disconnect 切断を記録する。最後の接続が切れた場合は away（再接続待ち）になる
+func (pt *PresenceTracker) Disconnect(roomID, playerID string) (string, bool) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	presence := pt.get(roomID, playerID)
+	if presence.connections > 0 {
+		presence.connections--
+	}
+	if presence.connections == 0 {
+		presence.disconnectedAt = time.Now()
+	}
+	return pt.update(presence)
+}
+
+// SetAway クライアントからの離席・復帰の申告を記録する
+func (pt *PresenceTracker) SetAway(roomID, playerID string, away bool) (string, bool) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	presence := pt.get(roomID, playerID)
+	presence.away = away
+	return pt.update(presence)
+}
+
+// MarkOffline 最後の切断から grace 以上再接続がなければ offline にする。offline になった場合は true を返す
+func (pt *PresenceTracker) MarkOffline(roomID, playerID string, grace time.Duration) bool {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	presence := pt.get(roomID, playerID)
+	if presence.connections > 0 || presence.status == PresenceOffline {
+		return false
+	}
+	if time.Since(presence.disconnectedAt) < grace {
+		// 途中で再接続・再切断しており、新しい切断の猶予時間がまだ残っている
+		return false
+	}
+	presence.status = PresenceOffline
+	return true
+}
+
+// IsConnected プレイヤーが1つ以上の接続を持っているか
+func (pt *PresenceTracker) IsConnected(roomID, playerID string) bool {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	if players, exists := pt.rooms[roomID]; exists {
+		if presence, exists := players[playerID]; exists {
+			return presence.connections > 0
+		}
+	}
+	return false
+}
+
+// RoomPresence ルーム内の全プレイヤーの在席状態（記録のないプレイヤーは含まない）
+func (pt *PresenceTracker) RoomPresence(roomID string) map[string]string {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	statuses := make(map[string]string)
+	for playerID, presence := range pt.rooms[roomID] {
+		statuses[playerID] = presence.status
+	}
+	return statuses
+}
+
+// RemovePlayer ルームから外したプレイヤーの在席状態を破棄
+func (pt *PresenceTracker) RemovePlayer(roomID, playerID string) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	delete(pt.rooms[roomID], playerID)
+}
+
+// RemoveRoom ルームの在席状態を破棄
+func (pt *PresenceTracker) RemoveRoom(roomID string) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	delete(pt.rooms, roomID)
+}
+
+// get 在席状態を取得（なければ作成）。ロック取得済みであること
+func (pt *PresenceTracker) get(roomID, playerID string) *playerPresence {
+	players, exists := pt.rooms[roomID]
+	if !exists {
+		players = make(map[string]*playerPresence)
+		pt.rooms[roomID] = players
+	}
+
+	presence, exists := players[playerID]
+	if !exists {
+		presence = &playerPresence{status: PresenceOffline}
+		players[playerID] = presence
+	}
+	return presence
+}
+
+// update 接続数と申告から状態を再計算する。ロック取得済みであること
+func (pt *PresenceTracker) update(presence *playerPresence) (string, bool) {
+	status := PresenceOnline
+	if presence.connections == 0 || presence.away {
+		status = PresenceAway
+	}
+	if presence.connections == 0 && presence.status == PresenceOffline {
+		// 一度 offline になったプレイヤーは再接続するまで offline のまま
+		status = PresenceOffline
+	}
+
+	if status == presence.status {
+		return status, false
+	}
+	presence.status = status
+	return status, true
+}
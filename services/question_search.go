@@ -0,0 +1,194 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"quivra-backend/models"
+	"quivra-backend/repository"
+)
+
+// 問題一覧の1ページの件数
+const (
+	DefaultQuestionPageSize = 50
+	MaxQuestionPageSize     = 200
+)
+
+// maxSearchTerms 検索語の最大数
+const maxSearchTerms = 10
+
+// ErrInvalidQuestionQuery 問題一覧の検索条件・カーソルが不正
+var ErrInvalidQuestionQuery = errors.New("invalid question query")
+
+// QuestionListParams 問題一覧の検索条件（空の項目は絞り込まない）
+type QuestionListParams struct {
+	Search        string   // 空白区切りの検索語（すべてを問題文、SearchAnswers なら問題文または答えに含む）
+	SearchAnswers bool     // 検索語を答えにも照合する（正解を見られる利用者のみ）
+	Categories    []string // いずれかに一致
+	Difficulties  []string // いずれかに一致
+	Tags          []string // すべてを持つ
+	Sort          string   // created_at（デフォルト）/ updated_at / id
+	Order         string   // desc（デフォルト）/ asc
+	Cursor        string   // 前のページの NextCursor
+	Limit         int      // 0 なら DefaultQuestionPageSize
+}
+
+// QuestionPage 問題一覧の1ページ
+type QuestionPage struct {
+	Questions  []models.Question
+	Total      int    // 条件に合う全件数
+	NextCursor string // 次のページがなければ空
+	Limit      int
+}
+
+// questionCursor カーソルの中身（並び順が変わるとカーソルの位置が意味をなさないため並び順も含める）
+type questionCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	At    int64  `json:"t,omitempty"` // UnixNano
+	ID    int    `json:"i"`
+}
+
+// ListQuestions 論理削除していない問題を検索し、1ページ分を返す
+// ページ送りはカーソル方式で、前のページの NextCursor を同じ条件とともに渡す
+func (qs *QuestionService) ListQuestions(params QuestionListParams) (*QuestionPage, error) {
+	query, err := buildQuestionQuery(params)
+	if err != nil {
+		return nil, err
+	}
+	limit := query.Limit
+
+	// 1件多く取得して次のページがあるか判定する
+	query.Limit = limit + 1
+	questions, total, err := qs.store.Questions().SearchQuestions(query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &QuestionPage{Questions: questions, Total: total, Limit: limit}
+	if len(questions) > limit {
+		page.Questions = questions[:limit]
+		last := page.Questions[limit-1]
+		page.NextCursor = encodeQuestionCursor(questionCursor{
+			Sort:  query.Sort,
+			Order: orderName(query.Descending),
+			At:    questionSortTime(&last, query.Sort),
+			ID:    last.ID,
+		})
+	}
+	return page, nil
+}
+
+// buildQuestionQuery 一覧の検索条件を検証し、リポジトリの検索条件に変換する
+func buildQuestionQuery(params QuestionListParams) (repository.QuestionQuery, error) {
+	query := repository.QuestionQuery{
+		Terms:         strings.Fields(params.Search),
+		SearchAnswers: params.SearchAnswers,
+		Categories:    cleanFilter(params.Categories),
+		Tags:          cleanFilter(params.Tags),
+		Sort:          strings.ToLower(strings.TrimSpace(params.Sort)),
+		Limit:         params.Limit,
+	}
+	if len(query.Terms) > maxSearchTerms {
+		return query, fmt.Errorf("%w: at most %d search terms are allowed", ErrInvalidQuestionQuery, maxSearchTerms)
+	}
+	for _, difficulty := range cleanFilter(params.Difficulties) {
+		difficulty = strings.ToLower(difficulty)
+		if !isValidDifficulty(difficulty) {
+			return query, fmt.Errorf("%w: unknown difficulty %q (use easy, medium or hard)", ErrInvalidQuestionQuery, difficulty)
+		}
+		query.Difficulties = append(query.Difficulties, difficulty)
+	}
+
+	switch query.Sort {
+	case "":
+		query.Sort = repository.QuestionSortCreatedAt
+	case repository.QuestionSortCreatedAt, repository.QuestionSortUpdatedAt, repository.QuestionSortID:
+	default:
+		return query, fmt.Errorf("%w: unknown sort %q (use created_at, updated_at or id)", ErrInvalidQuestionQuery, params.Sort)
+	}
+	switch strings.ToLower(strings.TrimSpace(params.Order)) {
+	case "", "desc":
+		query.Descending = true
+	case "asc":
+	default:
+		return query, fmt.Errorf("%w: unknown order %q (use asc or desc)", ErrInvalidQuestionQuery, params.Order)
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultQuestionPageSize
+	}
+	if query.Limit < 0 || query.Limit > MaxQuestionPageSize {
+		return query, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuestionQuery, MaxQuestionPageSize)
+	}
+
+	if params.Cursor != "" {
+		cursor, err := decodeQuestionCursor(params.Cursor)
+		if err != nil {
+			return query, err
+		}
+		if cursor.Sort != query.Sort || cursor.Order != orderName(query.Descending) {
+			return query, fmt.Errorf("%w: cursor was issued for a different sort order", ErrInvalidQuestionQuery)
+		}
+		query.After = &repository.QuestionCursor{ID: cursor.ID}
+		if query.Sort != repository.QuestionSortID {
+			query.After.At = time.Unix(0, cursor.At)
+		}
+	}
+	return query, nil
+}
+
+// cleanFilter 絞り込みの値から空文字・重複を取り除く
+func cleanFilter(values []string) []string {
+	var cleaned []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value != "" && !containsString(cleaned, value) {
+			cleaned = append(cleaned, value)
+		}
+	}
+	return cleaned
+}
+
+// orderName 並び順の向きをカーソルに記録する名前にする
+func orderName(descending bool) string {
+	if descending {
+		return "desc"
+	}
+	return "asc"
+}
+
+// questionSortTime 並び順のキーとなる日時（編集していない問題の更新日時は作成日時）
+func questionSortTime(question *models.Question, sort string) int64 {
+	switch sort {
+	case repository.QuestionSortCreatedAt:
+		return question.CreatedAt.UnixNano()
+	case repository.QuestionSortUpdatedAt:
+		if question.UpdatedAt != nil {
+			return question.UpdatedAt.UnixNano()
+		}
+		return question.CreatedAt.UnixNano()
+	default:
+		return 0
+	}
+}
+
+// encodeQuestionCursor カーソルを URL にそのまま載せられる文字列にする
+func encodeQuestionCursor(cursor questionCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeQuestionCursor encodeQuestionCursor の逆変換
+func decodeQuestionCursor(value string) (questionCursor, error) {
+	var cursor questionCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || json.Unmarshal(data, &cursor) != nil || cursor.ID <= 0 {
+		return cursor, fmt.Errorf("%w: malformed cursor", ErrInvalidQuestionQuery)
+	}
+	return cursor, nil
+}
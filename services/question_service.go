@@ -1,119 +1,365 @@
 package services
 
 import (
-	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
 
-	"quivra-backend/database"
 	"quivra-backend/models"
+	"quivra-backend/repository"
 )
 
+// questionDifficulties 問題の難易度（questions.difficulty の ENUM と同じ順）
+var questionDifficulties = []string{"easy", "medium", "hard"}
+
+// 問題の各項目の最大文字数（questions / question_aliases / question_tags のカラム長）
+const (
+	maxAnswerLength   = 255
+	maxCategoryLength = 50
+	maxTagLength      = 50
+)
+
+// MaxQuestionTags 1問あたりの最大タグ数
+const MaxQuestionTags = 20
+
+var (
+	// ErrInvalidQuestion 問題の内容が不正
+	ErrInvalidQuestion = errors.New("invalid question")
+	// ErrQuestionNotFound 問題が存在しない（編集・削除では論理削除済みの問題も含む）
+	ErrQuestionNotFound = repository.ErrQuestionNotFound
+	// ErrQuestionRevisionConflict 編集元の版が古い（他の編集が先に保存された）
+	ErrQuestionRevisionConflict = repository.ErrQuestionRevisionConflict
+	// ErrQuestionNotDeleted 削除していない問題を元に戻そうとした
+	ErrQuestionNotDeleted = errors.New("question is not deleted")
+)
+
+// QuestionFieldError 問題の項目ごとの検証エラー
+type QuestionFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// QuestionValidationError 問題の検証エラー（Unwrap すると ErrInvalidQuestion）
+type QuestionValidationError struct {
+	Fields []QuestionFieldError
+}
+
+func (e *QuestionValidationError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrInvalidQuestion, e.Fields[0].Message)
+}
+
+func (e *QuestionValidationError) Unwrap() error {
+	return ErrInvalidQuestion
+}
+
+// QuestionUpdate 問題の編集内容（nil の項目は変更しない）
+type QuestionUpdate struct {
+	Question             *string
+	Answer               *string
+	Category             *string
+	Difficulty           *string
+	AnswerTolerance      *int
+	ClearAnswerTolerance bool // answer_tolerance を未指定（正解の長さから自動で決める）に戻す
+	Aliases              *[]string
+	Tags                 *[]string // タグは版管理の対象外で、タグだけの変更では版を進めない
+	Revision             *int      // 編集元の版。指定すると、他の編集で版が進んでいた場合に ErrQuestionRevisionConflict を返す
+}
+
 type QuestionService struct {
-	db *database.DB
+	store repository.Store
 }
 
-func NewQuestionService(db *database.DB) *QuestionService {
-	return &QuestionService{db: db}
+func NewQuestionService(store repository.Store) *QuestionService {
+	return &QuestionService{store: store}
 }
 
 // CreateQuestion 問題を作成
-func (qs *QuestionService) CreateQuestion(question, answer, category, difficulty string) (*models.Question, error) {
-	query := `INSERT INTO questions (question, answer, category, difficulty) VALUES (?, ?, ?, ?)`
-	result, err := qs.db.Exec(query, question, answer, category, difficulty)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create question: %w", err)
+func (qs *QuestionService) CreateQuestion(question, answer, category, difficulty string, answerTolerance *int, aliases, tags []string) (*models.Question, error) {
+	created := &models.Question{
+		Question:        question,
+		Answer:          answer,
+		AnswerTolerance: answerTolerance,
+		Aliases:         aliases,
+		Tags:            tags,
+		Category:        category,
+		Difficulty:      difficulty,
+	}
+	normalizeQuestion(created)
+	if fields := validateQuestion(created); len(fields) > 0 {
+		return nil, &QuestionValidationError{Fields: fields}
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get question ID: %w", err)
+	if err := qs.store.Questions().CreateQuestion(created); err != nil {
+		return nil, err
 	}
 
-	return &models.Question{
-		ID:         int(id),
-		Question:   question,
-		Answer:     answer,
-		Category:   category,
-		Difficulty: difficulty,
-	}, nil
+	return created, nil
 }
 
-// GetQuestions 問題一覧を取得
-func (qs *QuestionService) GetQuestions(category, difficulty string) ([]models.Question, error) {
-	query := `SELECT id, question, answer, category, difficulty, created_at FROM questions WHERE 1=1`
-	args := []interface{}{}
+// UpdateQuestion 問題を編集する。内容が変わった場合のみ版を1つ進め、編集前の版を履歴に残す
+// （出題済みのゲームセッションの結果には、出題したときの版の問題文・正解が表示される）
+func (qs *QuestionService) UpdateQuestion(id int, update QuestionUpdate) (*models.Question, error) {
+	current, err := qs.GetQuestion(id)
+	if err != nil {
+		return nil, err
+	}
+	if current.DeletedAt != nil {
+		return nil, ErrQuestionNotFound
+	}
+	if update.Revision != nil && *update.Revision != current.Revision {
+		return nil, ErrQuestionRevisionConflict
+	}
 
-	if category != "" {
-		query += ` AND category = ?`
-		args = append(args, category)
+	updated := *current
+	updated.Aliases = append([]string{}, current.Aliases...)
+	updated.Tags = append([]string{}, current.Tags...)
+	if update.Question != nil {
+		updated.Question = *update.Question
+	}
+	if update.Answer != nil {
+		updated.Answer = *update.Answer
+	}
+	if update.Category != nil {
+		updated.Category = *update.Category
+	}
+	if update.Difficulty != nil {
+		updated.Difficulty = *update.Difficulty
+	}
+	if update.ClearAnswerTolerance {
+		updated.AnswerTolerance = nil
+	} else if update.AnswerTolerance != nil {
+		tolerance := *update.AnswerTolerance
+		updated.AnswerTolerance = &tolerance
+	}
+	if update.Aliases != nil {
+		updated.Aliases = *update.Aliases
+	}
+	if update.Tags != nil {
+		updated.Tags = *update.Tags
 	}
 
-	if difficulty != "" {
-		query += ` AND difficulty = ?`
-		args = append(args, difficulty)
+	normalizeQuestion(&updated)
+	if fields := validateQuestion(&updated); len(fields) > 0 {
+		return nil, &QuestionValidationError{Fields: fields}
+	}
+	if sameQuestionContent(current, &updated) {
+		if equalStrings(current.Tags, updated.Tags) {
+			return current, nil
+		}
+		if err := qs.store.Questions().SetQuestionTags(id, updated.Tags); err != nil {
+			return nil, err
+		}
+		return &updated, nil
 	}
 
-	query += ` ORDER BY created_at DESC`
+	if err := qs.store.Questions().UpdateQuestion(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
 
-	rows, err := qs.db.Query(query, args...)
+// SetAliases 問題の別解を置き換える
+func (qs *QuestionService) SetAliases(questionID int, aliases []string) ([]string, error) {
+	question, err := qs.UpdateQuestion(questionID, QuestionUpdate{Aliases: &aliases})
 	if err != nil {
-		return nil, fmt.Errorf("failed to query questions: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
+	return question.Aliases, nil
+}
 
-	var questions []models.Question
-	for rows.Next() {
-		var question models.Question
-		err := rows.Scan(&question.ID, &question.Question, &question.Answer, &question.Category, &question.Difficulty, &question.CreatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan question: %w", err)
-		}
-		questions = append(questions, question)
+// DeleteQuestion 問題を論理削除する（一覧・出題候補・問題セットの候補から外れる。進行中のマッチの出題順には残る）
+func (qs *QuestionService) DeleteQuestion(id int) error {
+	return qs.store.Questions().DeleteQuestion(id)
+}
+
+// RestoreQuestion 論理削除した問題を元に戻す
+func (qs *QuestionService) RestoreQuestion(id int) (*models.Question, error) {
+	question, err := qs.GetQuestion(id)
+	if err != nil {
+		return nil, err
+	}
+	if question.DeletedAt == nil {
+		return nil, ErrQuestionNotDeleted
 	}
 
-	return questions, nil
+	if err := qs.store.Questions().RestoreQuestion(id); err != nil {
+		return nil, err
+	}
+	question.DeletedAt = nil
+	return question, nil
 }
 
-// GetQuestion 問題を取得
-func (qs *QuestionService) GetQuestion(id int) (*models.Question, error) {
-	var question models.Question
-	query := `SELECT id, question, answer, category, difficulty, created_at FROM questions WHERE id = ?`
-	err := qs.db.QueryRow(query, id).Scan(&question.ID, &question.Question, &question.Answer, &question.Category, &question.Difficulty, &question.CreatedAt)
+// GetQuestionRevisions 問題の全版を古い順に取得（最後が現在の版）
+func (qs *QuestionService) GetQuestionRevisions(id int) ([]models.QuestionRevision, error) {
+	question, err := qs.GetQuestion(id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("question not found")
-		}
-		return nil, fmt.Errorf("failed to get question: %w", err)
+		return nil, err
 	}
 
-	return &question, nil
+	revisions, err := qs.store.Questions().GetQuestionRevisions(id)
+	if err != nil {
+		return nil, err
+	}
+
+	validFrom := question.CreatedAt
+	if question.UpdatedAt != nil {
+		validFrom = *question.UpdatedAt
+	}
+	return append(revisions, models.QuestionRevision{
+		QuestionID:      question.ID,
+		Revision:        question.Revision,
+		Question:        question.Question,
+		Answer:          question.Answer,
+		AnswerTolerance: question.AnswerTolerance,
+		Aliases:         question.Aliases,
+		Category:        question.Category,
+		Difficulty:      question.Difficulty,
+		CreatedAt:       validFrom,
+	}), nil
 }
 
-// GetRandomQuestion ランダムな問題を取得
-func (qs *QuestionService) GetRandomQuestion(category, difficulty string) (*models.Question, error) {
-	query := `SELECT id, question, answer, category, difficulty, created_at FROM questions WHERE 1=1`
-	args := []interface{}{}
+// normalizeQuestion 前後の空白を除き、未指定のカテゴリ・難易度にデフォルト値を補って別解を整理する
+func normalizeQuestion(question *models.Question) {
+	question.Question = strings.TrimSpace(question.Question)
+	question.Answer = strings.TrimSpace(question.Answer)
+	question.Category = strings.TrimSpace(question.Category)
+	question.Difficulty = strings.ToLower(strings.TrimSpace(question.Difficulty))
+	if question.Category == "" {
+		question.Category = "general"
+	}
+	if question.Difficulty == "" {
+		question.Difficulty = "medium"
+	}
+	question.Aliases = cleanAliases(question.Answer, question.Aliases)
+	question.Tags = cleanTags(question.Tags)
+}
 
-	if category != "" {
-		query += ` AND category = ?`
-		args = append(args, category)
+// cleanTags 空文字・重複を取り除いて並べ替える
+func cleanTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	cleaned := []string{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		cleaned = append(cleaned, tag)
 	}
+	sort.Strings(cleaned)
+	return cleaned
+}
 
-	if difficulty != "" {
-		query += ` AND difficulty = ?`
-		args = append(args, difficulty)
+// validateQuestion 問題の各項目を検証し、不正な項目を返す（難易度は MySQL の ENUM に切り捨てられないよう既知の値のみ受け付ける）
+func validateQuestion(question *models.Question) []QuestionFieldError {
+	var fields []QuestionFieldError
+	fail := func(field, message string) {
+		fields = append(fields, QuestionFieldError{Field: field, Message: message})
 	}
 
-	query += ` ORDER BY RAND() LIMIT 1`
+	if question.Question == "" {
+		fail("question", "question is required")
+	}
+	if question.Answer == "" {
+		fail("answer", "answer is required")
+	} else if utf8.RuneCountInString(question.Answer) > maxAnswerLength {
+		fail("answer", fmt.Sprintf("answer must be at most %d characters", maxAnswerLength))
+	}
+	if utf8.RuneCountInString(question.Category) > maxCategoryLength {
+		fail("category", fmt.Sprintf("category must be at most %d characters", maxCategoryLength))
+	}
+	if !isValidDifficulty(question.Difficulty) {
+		fail("difficulty", fmt.Sprintf("unknown difficulty %q (use easy, medium or hard)", question.Difficulty))
+	}
+	if question.AnswerTolerance != nil && *question.AnswerTolerance < 0 {
+		fail("answer_tolerance", "answer tolerance must not be negative")
+	}
+	for _, alias := range question.Aliases {
+		if utf8.RuneCountInString(alias) > maxAnswerLength {
+			fail("aliases", fmt.Sprintf("aliases must be at most %d characters", maxAnswerLength))
+			break
+		}
+	}
+	if len(question.Tags) > MaxQuestionTags {
+		fail("tags", fmt.Sprintf("at most %d tags are allowed", MaxQuestionTags))
+	}
+	for _, tag := range question.Tags {
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			fail("tags", fmt.Sprintf("tags must be at most %d characters", maxTagLength))
+			break
+		}
+	}
+	return fields
+}
 
-	var question models.Question
-	err := qs.db.QueryRow(query, args...).Scan(&question.ID, &question.Question, &question.Answer, &question.Category, &question.Difficulty, &question.CreatedAt)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("no questions found")
+// sameQuestionContent 編集で内容が変わらないか（変わらなければ版を進めない。タグは比較しない）
+func sameQuestionContent(a, b *models.Question) bool {
+	if a.Question != b.Question || a.Answer != b.Answer || a.Category != b.Category || a.Difficulty != b.Difficulty {
+		return false
+	}
+	if (a.AnswerTolerance == nil) != (b.AnswerTolerance == nil) ||
+		(a.AnswerTolerance != nil && *a.AnswerTolerance != *b.AnswerTolerance) {
+		return false
+	}
+	return equalStrings(a.Aliases, b.Aliases)
+}
+
+// equalStrings 2つの文字列スライスが同じ順で同じ要素を持つか
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// cleanAliases 空文字・正解と同じもの・重複を取り除く
+func cleanAliases(answer string, aliases []string) []string {
+	seen := map[string]bool{strings.TrimSpace(answer): true}
+	cleaned := []string{}
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		if alias == "" || seen[alias] {
+			continue
 		}
-		return nil, fmt.Errorf("failed to get random question: %w", err)
+		seen[alias] = true
+		cleaned = append(cleaned, alias)
 	}
+	return cleaned
+}
+
+// GetQuestions 問題一覧を取得
+func (qs *QuestionService) GetQuestions(category, difficulty string) ([]models.Question, error) {
+	return qs.store.Questions().GetQuestions(category, difficulty)
+}
 
-	return &question, nil
+// GetQuestion 問題を取得
+func (qs *QuestionService) GetQuestion(id int) (*models.Question, error) {
+	return qs.store.Questions().GetQuestion(id)
+}
+
+// GetRandomQuestion ランダムな問題を取得
+func (qs *QuestionService) GetRandomQuestion(category, difficulty string) (*models.Question, error) {
+	return qs.store.Questions().GetRandomQuestion(category, difficulty)
+}
+
+// isValidDifficulty 問題の難易度として受け付ける値か
+func isValidDifficulty(difficulty string) bool {
+	return containsString(questionDifficulties, difficulty)
+}
+
+// containsString values に value が含まれるか
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
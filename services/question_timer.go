@@ -0,0 +1,148 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"quivra-backend/models"
+)
+
+// TimerTickInterval timer-tick イベントの送信間隔
+const TimerTickInterval = time.Second
+
+// QuestionTimer ルームごとの出題タイマーを管理する
+type QuestionTimer struct {
+	mu       sync.Mutex
+	timers   map[string]*roomTimer // roomId -> 実行中のタイマー
+	defaults map[string]time.Duration
+}
+
+type roomTimer struct {
+	stop     chan struct{}
+	deadline time.Time
+	total    time.Duration
+}
+
+// NewQuestionTimer 難易度別のデフォルト制限時間を指定してタイマーを作成
+func NewQuestionTimer(defaults map[string]time.Duration) *QuestionTimer {
+	return &QuestionTimer{
+		timers:   make(map[string]*roomTimer),
+		defaults: defaults,
+	}
+}
+
+// TimeLimit ルーム設定と難易度から制限時間を決定する（0はタイマーなし）
+func (qt *QuestionTimer) TimeLimit(settings models.TimerSettings, difficulty string) time.Duration {
+	if settings.Disabled {
+		return 0
+	}
+
+	seconds := 0
+	switch difficulty {
+	case "easy":
+		seconds = settings.Easy
+	case "medium":
+		seconds = settings.Medium
+	case "hard":
+		seconds = settings.Hard
+	}
+	if seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	return qt.defaults[difficulty]
+}
+
+// Start ルームのタイマーを開始する（既存のタイマーは停止）
+// onTick は残り時間ごとに、onExpire は時間切れ時に1度だけタイマーのgoroutineから呼ばれる
+func (qt *QuestionTimer) Start(roomID string, limit time.Duration, onTick func(remaining, total time.Duration), onExpire func()) {
+	timer := &roomTimer{
+		stop:     make(chan struct{}),
+		deadline: time.Now().Add(limit),
+		total:    limit,
+	}
+
+	qt.mu.Lock()
+	if current, exists := qt.timers[roomID]; exists {
+		close(current.stop)
+	}
+	qt.timers[roomID] = timer
+	qt.mu.Unlock()
+
+	go qt.run(roomID, timer, onTick, onExpire)
+}
+
+func (qt *QuestionTimer) run(roomID string, timer *roomTimer, onTick func(remaining, total time.Duration), onExpire func()) {
+	ticker := time.NewTicker(TimerTickInterval)
+	defer ticker.Stop()
+
+	expire := time.NewTimer(time.Until(timer.deadline))
+	defer expire.Stop()
+
+	onTick(timer.total, timer.total)
+
+	for {
+		select {
+		case <-timer.stop:
+			return
+
+		case <-ticker.C:
+			remaining := time.Until(timer.deadline)
+			if remaining > 0 {
+				onTick(remaining, timer.total)
+			}
+
+		case <-expire.C:
+			// キャンセルと競合した場合は時間切れ処理を行わない
+			qt.mu.Lock()
+			if qt.timers[roomID] != timer {
+				qt.mu.Unlock()
+				return
+			}
+			delete(qt.timers, roomID)
+			qt.mu.Unlock()
+
+			onExpire()
+			return
+		}
+	}
+}
+
+// Cancel ルームのタイマーを停止
+func (qt *QuestionTimer) Cancel(roomID string) {
+	qt.mu.Lock()
+	defer qt.mu.Unlock()
+
+	if timer, exists := qt.timers[roomID]; exists {
+		close(timer.stop)
+		delete(qt.timers, roomID)
+	}
+}
+
+// CancelAll 全ルームのタイマーを停止
+func (qt *QuestionTimer) CancelAll() {
+	qt.mu.Lock()
+	defer qt.mu.Unlock()
+
+	for roomID, timer := range qt.timers {
+		close(timer.stop)
+		delete(qt.timers, roomID)
+	}
+}
+
+// Remaining ルームのタイマーの残り時間を取得
+func (qt *QuestionTimer) Remaining(roomID string) (time.Duration, bool) {
+	qt.mu.Lock()
+	defer qt.mu.Unlock()
+
+	timer, exists := qt.timers[roomID]
+	if !exists {
+		return 0, false
+	}
+
+	remaining := time.Until(timer.deadline)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, true
+}
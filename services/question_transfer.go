@@ -0,0 +1,442 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"quivra-backend/models"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// 一括インポート・エクスポートのファイル形式
+const (
+	QuestionFormatCSV   = "csv"
+	QuestionFormatJSON  = "json"  // 問題オブジェクトの配列
+	QuestionFormatJSONL = "jsonl" // 1行に1問のJSONオブジェクト
+)
+
+// MaxImportQuestions 1回のインポートで受け付ける最大問題数
+const MaxImportQuestions = 5000
+
+// csvListSeparator CSVの aliases・tags 列で値を区切る文字
+const csvListSeparator = "|"
+
+// questionCSVColumns CSVの列（1行目のヘッダーで列の並びを指定する。question と answer は必須）
+var questionCSVColumns = []string{"question", "answer", "category", "difficulty", "answer_tolerance", "aliases", "tags"}
+
+var (
+	// ErrUnsupportedFormat 対応していないファイル形式
+	ErrUnsupportedFormat = errors.New("unsupported question format")
+	// ErrInvalidImport ファイルを読み込めない、または不正な行があるため何も登録しなかった
+	ErrInvalidImport = errors.New("invalid question import")
+)
+
+// QuestionRecord インポート・エクスポートする1問（POST /api/questions と同じ項目）
+type QuestionRecord struct {
+	Question        string   `json:"question"`
+	Answer          string   `json:"answer"`
+	Category        string   `json:"category,omitempty"`
+	Difficulty      string   `json:"difficulty,omitempty"`
+	AnswerTolerance *int     `json:"answer_tolerance,omitempty"`
+	Aliases         []string `json:"aliases,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+}
+
+// ImportRowError インポートで受け付けなかった行
+// Row は CSV・JSONL ではファイルの行番号、JSON では配列の何番目か（1始まり）
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportDuplicate 既存の問題、または同じファイルの前の行と問題文が重複したためスキップした行
+type ImportDuplicate struct {
+	Row            int    `json:"row"`
+	Question       string `json:"question"`
+	ExistingID     *int   `json:"existing_id,omitempty"`      // 既存の問題と重複した場合
+	DuplicateOfRow *int   `json:"duplicate_of_row,omitempty"` // ファイル内で重複した場合
+}
+
+// ImportReport インポートの結果
+type ImportReport struct {
+	Format      string            `json:"format"`
+	DryRun      bool              `json:"dry_run"`
+	Total       int               `json:"total"`    // 読み込んだ問題数
+	Imported    int               `json:"imported"` // 登録した（dry-run では登録できる）問題数
+	Skipped     int               `json:"skipped"`  // 重複によりスキップした問題数
+	QuestionIDs []int             `json:"question_ids,omitempty"`
+	Duplicates  []ImportDuplicate `json:"duplicates"`
+	Errors      []ImportRowError  `json:"errors"`
+}
+
+// importRow 読み込んだ1問と元の行番号
+type importRow struct {
+	row    int
+	record QuestionRecord
+}
+
+// ParseQuestionFormat ファイル形式の指定を正規化する（拡張子・"ndjson" も受け付ける）
+func ParseQuestionFormat(format string) (string, error) {
+	switch strings.ToLower(strings.TrimPrefix(strings.TrimSpace(format), ".")) {
+	case "csv":
+		return QuestionFormatCSV, nil
+	case "json":
+		return QuestionFormatJSON, nil
+	case "jsonl", "ndjson":
+		return QuestionFormatJSONL, nil
+	default:
+		return "", fmt.Errorf("%w: %q (use csv, json or jsonl)", ErrUnsupportedFormat, format)
+	}
+}
+
+// ImportQuestions 問題を一括でインポートする
+// 不正な行が1つでもあれば何も登録せず、ErrInvalidImport と行ごとのエラーを含む結果を返す。
+// 問題文が既存の問題やファイル内の前の行と同じ（questionDuplicateKey で比較）問題はスキップする。
+// dryRun の場合は検証のみ行い、登録しない
+func (qs *QuestionService) ImportQuestions(r io.Reader, format string, dryRun bool) (*ImportReport, error) {
+	format, err := ParseQuestionFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{
+		Format:     format,
+		DryRun:     dryRun,
+		Duplicates: []ImportDuplicate{},
+		Errors:     []ImportRowError{},
+	}
+
+	var rows []importRow
+	switch format {
+	case QuestionFormatCSV:
+		rows, report.Errors, err = readQuestionCSV(r)
+	case QuestionFormatJSON:
+		rows, report.Errors, err = readQuestionJSON(r)
+	case QuestionFormatJSONL:
+		rows, report.Errors, err = readQuestionJSONL(r)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	report.Total = len(rows) + len(report.Errors)
+	if report.Total > MaxImportQuestions {
+		return nil, fmt.Errorf("%w: at most %d questions can be imported at once", ErrInvalidImport, MaxImportQuestions)
+	}
+
+	existing, err := qs.store.Questions().GetQuestions("", "")
+	if err != nil {
+		return nil, err
+	}
+	existingIDs := make(map[string]int, len(existing))
+	for _, question := range existing {
+		existingIDs[questionDuplicateKey(question.Question)] = question.ID
+	}
+
+	seenRows := make(map[string]int, len(rows))
+	var questions []*models.Question
+	for _, row := range rows {
+		question, rowErrors := validateQuestionRecord(row)
+		if len(rowErrors) > 0 {
+			report.Errors = append(report.Errors, rowErrors...)
+			continue
+		}
+
+		key := questionDuplicateKey(question.Question)
+		if id, ok := existingIDs[key]; ok {
+			report.Duplicates = append(report.Duplicates, ImportDuplicate{Row: row.row, Question: question.Question, ExistingID: &id})
+			continue
+		}
+		if previous, ok := seenRows[key]; ok {
+			report.Duplicates = append(report.Duplicates, ImportDuplicate{Row: row.row, Question: question.Question, DuplicateOfRow: &previous})
+			continue
+		}
+		seenRows[key] = row.row
+		questions = append(questions, question)
+	}
+	report.Skipped = len(report.Duplicates)
+
+	if len(report.Errors) > 0 {
+		sort.SliceStable(report.Errors, func(i, j int) bool {
+			return report.Errors[i].Row < report.Errors[j].Row
+		})
+		return report, ErrInvalidImport
+	}
+
+	report.Imported = len(questions)
+	if dryRun || len(questions) == 0 {
+		return report, nil
+	}
+
+	if err := qs.store.Questions().CreateQuestions(questions); err != nil {
+		return nil, err
+	}
+	for _, question := range questions {
+		report.QuestionIDs = append(report.QuestionIDs, question.ID)
+	}
+	return report, nil
+}
+
+// questionDuplicateKey 問題文の重複チェック用のキー
+// NFKC正規化（全角/半角の統一）、前後の空白の除去・連続する空白の1つへの統一、小文字化のみ行う。
+// 記号・数字は残す（NormalizeAnswer のように句読点を除くと "3/4" と "34" のような別の問題が重複扱いになる）
+func questionDuplicateKey(question string) string {
+	return strings.ToLower(strings.Join(strings.Fields(norm.NFKC.String(question)), " "))
+}
+
+// ExportQuestions カテゴリ・難易度で絞り込んだ問題を format で書き出し、書き出した問題数を返す
+// 出力はそのまま ImportQuestions で読み込める
+func (qs *QuestionService) ExportQuestions(w io.Writer, format, category, difficulty string) (int, error) {
+	format, err := ParseQuestionFormat(format)
+	if err != nil {
+		return 0, err
+	}
+
+	questions, err := qs.GetQuestions(category, difficulty)
+	if err != nil {
+		return 0, err
+	}
+
+	// 作成順（インポートし直した場合も同じ順になる）
+	records := make([]QuestionRecord, 0, len(questions))
+	for i := len(questions) - 1; i >= 0; i-- {
+		question := questions[i]
+		records = append(records, QuestionRecord{
+			Question:        question.Question,
+			Answer:          question.Answer,
+			Category:        question.Category,
+			Difficulty:      question.Difficulty,
+			AnswerTolerance: question.AnswerTolerance,
+			Aliases:         question.Aliases,
+			Tags:            question.Tags,
+		})
+	}
+
+	switch format {
+	case QuestionFormatCSV:
+		err = writeQuestionCSV(w, records)
+	case QuestionFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(records)
+	case QuestionFormatJSONL:
+		encoder := json.NewEncoder(w)
+		for _, record := range records {
+			if err = encoder.Encode(record); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to export questions: %w", err)
+	}
+	return len(records), nil
+}
+
+// validateQuestionRecord 1問を検証し、デフォルト値を補った問題を返す
+func validateQuestionRecord(row importRow) (*models.Question, []ImportRowError) {
+	question := &models.Question{
+		Question:        row.record.Question,
+		Answer:          row.record.Answer,
+		AnswerTolerance: row.record.AnswerTolerance,
+		Aliases:         row.record.Aliases,
+		Tags:            row.record.Tags,
+		Category:        row.record.Category,
+		Difficulty:      row.record.Difficulty,
+	}
+	normalizeQuestion(question)
+
+	var rowErrors []ImportRowError
+	for _, field := range validateQuestion(question) {
+		rowErrors = append(rowErrors, ImportRowError{Row: row.row, Field: field.Field, Message: field.Message})
+	}
+	return question, rowErrors
+}
+
+// readQuestionCSV ヘッダー付きCSVを読み込む（UTF-8。Excel が付ける BOM は無視する）
+func readQuestionCSV(r io.Reader) ([]importRow, []ImportRowError, error) {
+	reader := csv.NewReader(skipBOM(r))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, []ImportRowError{}, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !containsString(questionCSVColumns, name) {
+			return nil, nil, fmt.Errorf("unknown CSV column %q (use %s)", name, strings.Join(questionCSVColumns, ", "))
+		}
+		if _, ok := columns[name]; ok {
+			return nil, nil, fmt.Errorf("duplicate CSV column %q", name)
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"question", "answer"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("CSV column %q is required", required)
+		}
+	}
+
+	var rows []importRow
+	rowErrors := []ImportRowError{}
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rowErrors = append(rowErrors, ImportRowError{Row: parseErr.StartLine, Message: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(fields) != len(header) {
+			rowErrors = append(rowErrors, ImportRowError{Row: line, Message: fmt.Sprintf("expected %d columns, got %d", len(header), len(fields))})
+			continue
+		}
+
+		value := func(column string) string {
+			if i, ok := columns[column]; ok {
+				return fields[i]
+			}
+			return ""
+		}
+		record := QuestionRecord{
+			Question:   value("question"),
+			Answer:     value("answer"),
+			Category:   value("category"),
+			Difficulty: value("difficulty"),
+		}
+		if aliases := value("aliases"); strings.TrimSpace(aliases) != "" {
+			record.Aliases = strings.Split(aliases, csvListSeparator)
+		}
+		if tags := value("tags"); strings.TrimSpace(tags) != "" {
+			record.Tags = strings.Split(tags, csvListSeparator)
+		}
+		if tolerance := strings.TrimSpace(value("answer_tolerance")); tolerance != "" {
+			parsed, err := strconv.Atoi(tolerance)
+			if err != nil {
+				rowErrors = append(rowErrors, ImportRowError{Row: line, Field: "answer_tolerance", Message: "answer tolerance must be an integer"})
+				continue
+			}
+			record.AnswerTolerance = &parsed
+		}
+		rows = append(rows, importRow{row: line, record: record})
+	}
+	return rows, rowErrors, nil
+}
+
+// readQuestionJSON 問題オブジェクトの配列を読み込む（配列の形が不正な場合はファイル全体をエラーにする）
+func readQuestionJSON(r io.Reader) ([]importRow, []ImportRowError, error) {
+	var items []json.RawMessage
+	if err := json.NewDecoder(skipBOM(r)).Decode(&items); err != nil {
+		if err == io.EOF {
+			return nil, []ImportRowError{}, nil
+		}
+		return nil, nil, fmt.Errorf("failed to parse JSON array: %w", err)
+	}
+
+	var rows []importRow
+	rowErrors := []ImportRowError{}
+	for i, item := range items {
+		record, err := decodeQuestionRecord(item)
+		if err != nil {
+			rowErrors = append(rowErrors, ImportRowError{Row: i + 1, Message: err.Error()})
+			continue
+		}
+		rows = append(rows, importRow{row: i + 1, record: record})
+	}
+	return rows, rowErrors, nil
+}
+
+// readQuestionJSONL 1行に1問のJSONを読み込む（空行は無視する）
+func readQuestionJSONL(r io.Reader) ([]importRow, []ImportRowError, error) {
+	scanner := bufio.NewScanner(skipBOM(r))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var rows []importRow
+	rowErrors := []ImportRowError{}
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		record, err := decodeQuestionRecord(text)
+		if err != nil {
+			rowErrors = append(rowErrors, ImportRowError{Row: line, Message: err.Error()})
+			continue
+		}
+		rows = append(rows, importRow{row: line, record: record})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read JSONL: %w", err)
+	}
+	return rows, rowErrors, nil
+}
+
+// decodeQuestionRecord 1問分のJSONオブジェクトを読み込む（未知のフィールドは誤記とみなしてエラーにする）
+func decodeQuestionRecord(data []byte) (QuestionRecord, error) {
+	var record QuestionRecord
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&record); err != nil {
+		return record, fmt.Errorf("invalid question object: %v", err)
+	}
+	return record, nil
+}
+
+// writeQuestionCSV ヘッダー付きCSVを書き出す
+func writeQuestionCSV(w io.Writer, records []QuestionRecord) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(questionCSVColumns); err != nil {
+		return err
+	}
+	for _, record := range records {
+		tolerance := ""
+		if record.AnswerTolerance != nil {
+			tolerance = strconv.Itoa(*record.AnswerTolerance)
+		}
+		err := writer.Write([]string{
+			record.Question,
+			record.Answer,
+			record.Category,
+			record.Difficulty,
+			tolerance,
+			strings.Join(record.Aliases, csvListSeparator),
+			strings.Join(record.Tags, csvListSeparator),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// skipBOM 先頭の UTF-8 BOM を読み飛ばす
+func skipBOM(r io.Reader) io.Reader {
+	buffered := bufio.NewReader(r)
+	if head, err := buffered.Peek(3); err == nil && bytes.Equal(head, []byte{0xEF, 0xBB, 0xBF}) {
+		buffered.Discard(3)
+	}
+	return buffered
+}
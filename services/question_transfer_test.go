@@ -0,0 +1,59 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"quivra-backend/repository/memory"
+)
+
+func TestImportQuestionsDuplicates(t *testing.T) {
+	qs := NewQuestionService(memory.NewStore())
+	existing, err := qs.CreateQuestion("3/4を小数にすると？", "0.75", "算数", "easy", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateQuestion: %v", err)
+	}
+
+	input := strings.Join([]string{
+		`{"question":"34を小数にすると？","answer":"34"}`,
+		`{"question":"3.5の2倍は？","answer":"7"}`,
+		`{"question":"35の2倍は？","answer":"70"}`,
+		`{"question":"３／４を小数にすると？","answer":"0.75"}`,
+		`{"question":"  Capital  of France? ","answer":"Paris"}`,
+		`{"question":"capital of france?","answer":"Paris"}`,
+	}, "\n")
+	report, err := qs.ImportQuestions(strings.NewReader(input), QuestionFormatJSONL, true)
+	if err != nil {
+		t.Fatalf("ImportQuestions: %v", err)
+	}
+
+	// 記号・数字だけが違う問題は別の問題として登録し、全角/半角・大文字/小文字・空白だけが違う問題はスキップする
+	if report.Imported != 4 || report.Skipped != 2 {
+		t.Fatalf("report = %+v, want 4 imported and 2 skipped", report)
+	}
+	first, second := report.Duplicates[0], report.Duplicates[1]
+	if first.Row != 4 || first.ExistingID == nil || *first.ExistingID != existing.ID {
+		t.Errorf("duplicates[0] = %+v, want row 4 duplicating question %d", first, existing.ID)
+	}
+	if second.Row != 6 || second.DuplicateOfRow == nil || *second.DuplicateOfRow != 5 {
+		t.Errorf("duplicates[1] = %+v, want row 6 duplicating row 5", second)
+	}
+}
+
+func TestQuestionDuplicateKey(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"3/4を小数にすると？", "34を小数にすると？", false},
+		{"3.5の2倍は？", "35の2倍は？", false},
+		{"とうきょうは？", "トウキョウは？", false},
+		{"ＡＢＣとは？", "abcとは?", true},
+		{" New  York は？", "new york は？", true},
+	}
+	for _, tt := range tests {
+		if got := questionDuplicateKey(tt.a) == questionDuplicateKey(tt.b); got != tt.same {
+			t.Errorf("questionDuplicateKey(%q) == questionDuplicateKey(%q): %v, want %v", tt.a, tt.b, got, tt.same)
+		}
+	}
+}
@@ -1,62 +1,127 @@
 package services
 
 import (
-	"database/sql"
+	crand "crypto/rand"
+	"errors"
 	"fmt"
 	"math/rand"
+	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
-	"quivra-backend/database"
 	"quivra-backend/models"
+	"quivra-backend/repository"
+)
+
+var (
+	// ErrPlayerNameTaken ルーム内に同じ名前のプレイヤーが既にいる
+	ErrPlayerNameTaken = errors.New("player name already exists in room")
+	// ErrTeamModeDisabled ルームでチーム戦が有効になっていない
+	ErrTeamModeDisabled = errors.New("team mode is not enabled for this room")
+	// ErrNoTeams チームが1つも作成されていない
+	ErrNoTeams = errors.New("room has no teams")
+	// ErrInvalidRoomSettings ルーム設定の値が不正
+	ErrInvalidRoomSettings = errors.New("invalid room settings")
+	// ErrSpectatingDisabled ルームで観戦を受け付けていない
+	ErrSpectatingDisabled = errors.New("spectating is disabled for this room")
+)
+
+// MaxTeamCount 自動振り分けで作成できるチーム数の上限
+const MaxTeamCount = 20
+
+// 同時に観戦できる接続数
+const (
+	DefaultMaxSpectators = 50   // ルーム設定で省略した場合
+	MaxSpectatorsLimit   = 1000 // ルーム設定で指定できる上限
 )
 
 type RoomService struct {
-	db *database.DB
+	store          repository.Store
+	onRemovePlayer func(roomID, playerID string)
 }
 
-func NewRoomService(db *database.DB) *RoomService {
-	return &RoomService{db: db}
+func NewRoomService(store repository.Store) *RoomService {
+	return &RoomService{store: store}
 }
 
 // CreateRoom ルームを作成
-func (rs *RoomService) CreateRoom(name string, isPublic bool, creatorName string) (*models.Room, error) {
-	roomID := generateRoomID()
-	creatorID := generatePlayerID()
+func (rs *RoomService) CreateRoom(name string, isPublic bool, creatorName string, settings models.RoomSettings) (*models.Room, error) {
+	if err := ValidateScoringSettings(settings.Scoring); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRoomSettings, err)
+	}
+	if err := ValidateLockoutSettings(settings.Lockout); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRoomSettings, err)
+	}
+	if err := ValidateSpectatorSettings(settings.Spectators); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRoomSettings, err)
+	}
+
+	room := &models.Room{
+		ID:        generateRoomID(),
+		Name:      name,
+		Status:    "waiting",
+		IsPublic:  isPublic,
+		CreatedBy: generatePlayerID(),
+		Settings:  settings,
+	}
 
 	// ルーム作成
-	query := `INSERT INTO rooms (id, name, status, is_public, created_by) VALUES (?, ?, 'waiting', ?, ?)`
-	_, err := rs.db.Exec(query, roomID, name, isPublic, creatorID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create room: %w", err)
+	if err := rs.store.Rooms().CreateRoom(room); err != nil {
+		return nil, err
 	}
 
 	// 作成者を管理者として追加
-	playerQuery := `INSERT INTO players (id, room_id, name, score, is_admin) VALUES (?, ?, ?, 0, TRUE)`
-	_, err = rs.db.Exec(playerQuery, creatorID, roomID, creatorName)
-	if err != nil {
+	creator := &models.Player{
+		ID:      room.CreatedBy,
+		RoomID:  room.ID,
+		Name:    creatorName,
+		Score:   0,
+		IsAdmin: true,
+	}
+	if err := rs.store.Players().CreatePlayer(creator); err != nil {
 		return nil, fmt.Errorf("failed to add creator as admin: %w", err)
 	}
 
-	return &models.Room{
-		ID:        roomID,
-		Name:      name,
-		Status:    "waiting",
-		IsPublic:  isPublic,
-		CreatedBy: creatorID,
-	}, nil
+	return room, nil
+}
+
+// ValidateSpectatorSettings 観戦の設定を検証
+func ValidateSpectatorSettings(settings models.SpectatorSettings) error {
+	if settings.Max < 0 || settings.Max > MaxSpectatorsLimit {
+		return fmt.Errorf("max spectators must be between 0 and %d", MaxSpectatorsLimit)
+	}
+	return nil
+}
+
+// SpectatorLimit 同時に観戦できる接続数（観戦を受け付けない場合は0）
+func SpectatorLimit(settings models.SpectatorSettings) int {
+	if settings.Disabled {
+		return 0
+	}
+	if settings.Max == 0 {
+		return DefaultMaxSpectators
+	}
+	return settings.Max
+}
+
+// CheckSpectatable ルームが存在し、観戦を受け付けているか確認
+func (rs *RoomService) CheckSpectatable(roomID string) error {
+	room, err := rs.GetRoom(roomID)
+	if err != nil {
+		return err
+	}
+	if SpectatorLimit(room.Settings.Spectators) == 0 {
+		return ErrSpectatingDisabled
+	}
+	return nil
 }
 
 // GetRoom ルーム情報を取得
 func (rs *RoomService) GetRoom(roomID string) (*models.Room, error) {
-	var room models.Room
-	query := `SELECT id, name, created_at, status, is_public, created_by FROM rooms WHERE id = ?`
-	err := rs.db.QueryRow(query, roomID).Scan(&room.ID, &room.Name, &room.CreatedAt, &room.Status, &room.IsPublic, &room.CreatedBy)
+	room, err := rs.store.Rooms().GetRoom(roomID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("room not found")
-		}
-		return nil, fmt.Errorf("failed to get room: %w", err)
+		return nil, err
 	}
 
 	// プレイヤー情報も取得
@@ -66,29 +131,17 @@ func (rs *RoomService) GetRoom(roomID string) (*models.Room, error) {
 	}
 	room.Players = players
 
-	return &room, nil
+	return room, nil
 }
 
 // GetRoomPlayers ルームのプレイヤー一覧を取得
 func (rs *RoomService) GetRoomPlayers(roomID string) ([]models.Player, error) {
-	query := `SELECT id, room_id, name, score, joined_at, is_admin FROM players WHERE room_id = ? ORDER BY joined_at`
-	rows, err := rs.db.Query(query, roomID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query players: %w", err)
-	}
-	defer rows.Close()
-
-	var players []models.Player
-	for rows.Next() {
-		var player models.Player
-		err := rows.Scan(&player.ID, &player.RoomID, &player.Name, &player.Score, &player.JoinedAt, &player.IsAdmin)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan player: %w", err)
-		}
-		players = append(players, player)
-	}
+	return rs.store.Players().GetRoomPlayers(roomID)
+}
 
-	return players, nil
+// GetRoomPlayer ルーム内のプレイヤーを取得
+func (rs *RoomService) GetRoomPlayer(roomID, playerID string) (*models.Player, error) {
+	return rs.store.Players().GetPlayer(roomID, playerID)
 }
 
 // AddPlayer プレイヤーをルームに追加
@@ -99,53 +152,66 @@ func (rs *RoomService) AddPlayer(roomID, playerName string) (*models.Player, err
 		return nil, fmt.Errorf("room not found: %w", err)
 	}
 
+	// 参加禁止の名前は拒否
+	if err := rs.checkBanned(roomID, playerName); err != nil {
+		return nil, err
+	}
+
 	// プレイヤー名の重複チェック
 	players, err := rs.GetRoomPlayers(roomID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get existing players: %w", err)
 	}
 
-	// 既存のプレイヤーがいる場合はそのプレイヤー情報を返す
+	// 名前が一致するだけで既存プレイヤー（管理者を含む）になりすませないよう、重複は拒否する
+	// 再接続はセッショントークンで行う
 	for _, player := range players {
 		if player.Name == playerName {
-			return &player, nil
+			return nil, ErrPlayerNameTaken
 		}
 	}
 
-	playerID := generatePlayerID()
-	query := `INSERT INTO players (id, room_id, name, score, is_admin) VALUES (?, ?, ?, 0, FALSE)`
-	_, err = rs.db.Exec(query, playerID, roomID, playerName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to add player: %w", err)
-	}
-
-	return &models.Player{
-		ID:      playerID,
+	player := &models.Player{
+		ID:      generatePlayerID(),
 		RoomID:  roomID,
 		Name:    playerName,
 		Score:   0,
 		IsAdmin: false,
-	}, nil
+	}
+	if err := rs.store.Players().CreatePlayer(player); err != nil {
+		return nil, err
+	}
+
+	return player, nil
 }
 
 // UpdateRoomStatus ルームの状態を更新
 func (rs *RoomService) UpdateRoomStatus(roomID, status string) error {
-	query := `UPDATE rooms SET status = ? WHERE id = ?`
-	_, err := rs.db.Exec(query, status, roomID)
-	if err != nil {
-		return fmt.Errorf("failed to update room status: %w", err)
-	}
-	return nil
+	return rs.store.Rooms().UpdateRoomStatus(roomID, status)
+}
+
+// DeleteRoom ルームを削除（プレイヤー・セッション等も連動して削除される）
+func (rs *RoomService) DeleteRoom(roomID string) error {
+	return rs.store.Rooms().DeleteRoom(roomID)
 }
 
 // UpdatePlayerScore プレイヤーのスコアを更新
 func (rs *RoomService) UpdatePlayerScore(playerID string, score int) error {
-	query := `UPDATE players SET score = ? WHERE id = ?`
-	_, err := rs.db.Exec(query, score, playerID)
+	return rs.store.Players().UpdatePlayerScore(playerID, score)
+}
+
+// AddPlayerScore プレイヤーのスコアに加算（負の値で減点）し、更新後のスコアを返す
+func (rs *RoomService) AddPlayerScore(roomID, playerID string, points int) (int, error) {
+	player, err := rs.GetRoomPlayer(roomID, playerID)
 	if err != nil {
-		return fmt.Errorf("failed to update player score: %w", err)
+		return 0, err
 	}
-	return nil
+
+	score := player.Score + points
+	if err := rs.UpdatePlayerScore(playerID, score); err != nil {
+		return 0, err
+	}
+	return score, nil
 }
 
 // generateRoomID ルームIDを生成（10文字の英数字）
@@ -162,28 +228,18 @@ func generateRoomID() string {
 
 // GetPublicRooms 公開ルーム一覧を取得
 func (rs *RoomService) GetPublicRooms() ([]models.Room, error) {
-	query := `SELECT id, name, created_at, status, is_public, created_by FROM rooms WHERE is_public = TRUE AND status = 'waiting' ORDER BY created_at DESC`
-	rows, err := rs.db.Query(query)
+	rooms, err := rs.store.Rooms().GetPublicRooms()
 	if err != nil {
-		return nil, fmt.Errorf("failed to query public rooms: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
-
-	var rooms []models.Room
-	for rows.Next() {
-		var room models.Room
-		err := rows.Scan(&room.ID, &room.Name, &room.CreatedAt, &room.Status, &room.IsPublic, &room.CreatedBy)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan room: %w", err)
-		}
 
+	for i := range rooms {
 		// プレイヤー数も取得
-		players, err := rs.GetRoomPlayers(room.ID)
+		players, err := rs.GetRoomPlayers(rooms[i].ID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get room players: %w", err)
 		}
-		room.Players = players
-		rooms = append(rooms, room)
+		rooms[i].Players = players
 	}
 
 	return rooms, nil
@@ -191,119 +247,237 @@ func (rs *RoomService) GetPublicRooms() ([]models.Room, error) {
 
 // IsPlayerAdmin プレイヤーが管理者かチェック
 func (rs *RoomService) IsPlayerAdmin(roomID, playerID string) (bool, error) {
-	query := `SELECT is_admin FROM players WHERE room_id = ? AND id = ?`
-	var isAdmin bool
-	err := rs.db.QueryRow(query, roomID, playerID).Scan(&isAdmin)
+	player, err := rs.store.Players().GetPlayer(roomID, playerID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return false, fmt.Errorf("player not found")
-		}
-		return false, fmt.Errorf("failed to check admin status: %w", err)
+		return false, err
 	}
-	return isAdmin, nil
+	return player.IsAdmin, nil
 }
 
 // GetRoomRanking ルームのランキングを取得
 func (rs *RoomService) GetRoomRanking(roomID string) ([]models.RoomRanking, error) {
-	query := `SELECT id, name, score FROM players WHERE room_id = ? ORDER BY score DESC, joined_at ASC`
-	rows, err := rs.db.Query(query, roomID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query ranking: %w", err)
+	return rs.store.Players().GetRoomRanking(roomID)
+}
+
+// ResetAllData 全データをリセット（管理者向け）
+func (rs *RoomService) ResetAllData() error {
+	if err := rs.store.ResetAll(); err != nil {
+		return err
 	}
-	defer rows.Close()
 
-	var rankings []models.RoomRanking
-	rank := 1
-	for rows.Next() {
-		var ranking models.RoomRanking
-		err := rows.Scan(&ranking.PlayerID, &ranking.Name, &ranking.Score)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan ranking: %w", err)
+	// サンプルデータを再挿入
+	if err := rs.SeedSampleData(); err != nil {
+		return fmt.Errorf("failed to insert sample data: %w", err)
+	}
+
+	return nil
+}
+
+// SeedSampleData サンプルデータを挿入
+func (rs *RoomService) SeedSampleData() error {
+	// サンプル問題を挿入
+	sampleQuestions := []models.Question{
+		{Question: "日本の首都は？", Answer: "東京", Category: "地理", Difficulty: "easy", Aliases: []string{"Tokyo", "東京都"}},
+		{Question: "1+1は？", Answer: "2", Category: "数学", Difficulty: "easy"},
+		{Question: "Go言語の作者は？", Answer: "ロブ・パイク", Category: "プログラミング", Difficulty: "medium", Aliases: []string{"Rob Pike", "パイク"}},
+		{Question: "世界で最も高い山は？", Answer: "エベレスト", Category: "地理", Difficulty: "easy", Aliases: []string{"チョモランマ", "Everest"}},
+		{Question: "2の3乗は？", Answer: "8", Category: "数学", Difficulty: "medium"},
+		{Question: "HTTPのデフォルトポートは？", Answer: "80", Category: "プログラミング", Difficulty: "medium"},
+		{Question: "光の速度は？", Answer: "約30万km/s", Category: "科学", Difficulty: "hard", Aliases: []string{"30万km/s", "秒速30万キロメートル"}},
+		{Question: "日本の国花は？", Answer: "桜", Category: "文化", Difficulty: "easy", Aliases: []string{"さくら", "サクラ"}},
+		{Question: "Pythonの作者は？", Answer: "グイド・ヴァン・ロッサム", Category: "プログラミング", Difficulty: "medium", Aliases: []string{"Guido van Rossum", "グイド"}},
+		{Question: "地球の衛星は？", Answer: "月", Category: "科学", Difficulty: "easy", Aliases: []string{"Moon"}},
+	}
+
+	for i := range sampleQuestions {
+		if err := rs.store.Questions().CreateQuestion(&sampleQuestions[i]); err != nil {
+			return fmt.Errorf("failed to insert sample question: %w", err)
 		}
-		ranking.Rank = rank
-		rankings = append(rankings, ranking)
-		rank++
 	}
 
-	return rankings, nil
+	return nil
 }
 
-// ResetAllData 全データをリセット（管理者向け）
-func (rs *RoomService) ResetAllData() error {
-	// 外部キー制約のため、削除順序に注意
-	// 1. buzz_queue テーブルをクリア
-	_, err := rs.db.Exec("DELETE FROM buzz_queue")
-	if err != nil {
-		return fmt.Errorf("failed to clear buzz_queue: %w", err)
+// CreateTeam チームを作成（チーム戦が有効なルームのみ）
+func (rs *RoomService) CreateTeam(roomID, name string) (*models.Team, error) {
+	if err := rs.requireTeamMode(roomID); err != nil {
+		return nil, err
 	}
 
-	// 2. game_sessions テーブルをクリア
-	_, err = rs.db.Exec("DELETE FROM game_sessions")
-	if err != nil {
-		return fmt.Errorf("failed to clear game_sessions: %w", err)
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > 50 {
+		return nil, fmt.Errorf("team name must be 1-50 characters")
 	}
 
-	// 3. players テーブルをクリア
-	_, err = rs.db.Exec("DELETE FROM players")
+	teamID, err := generateTeamID()
 	if err != nil {
-		return fmt.Errorf("failed to clear players: %w", err)
+		return nil, err
 	}
+	team := &models.Team{
+		ID:     teamID,
+		RoomID: roomID,
+		Name:   name,
+	}
+	if err := rs.store.Teams().CreateTeam(team); err != nil {
+		return nil, err
+	}
+	return team, nil
+}
 
-	// 4. rooms テーブルをクリア
-	_, err = rs.db.Exec("DELETE FROM rooms")
-	if err != nil {
-		return fmt.Errorf("failed to clear rooms: %w", err)
+// GetTeams ルームのチーム一覧を取得
+func (rs *RoomService) GetTeams(roomID string) ([]models.Team, error) {
+	return rs.store.Teams().GetRoomTeams(roomID)
+}
+
+// DeleteTeam チームを削除（メンバーは無所属になる）
+func (rs *RoomService) DeleteTeam(roomID, teamID string) error {
+	return rs.store.Teams().DeleteTeam(roomID, teamID)
+}
+
+// AssignPlayerTeam プレイヤーをチームに所属させる（teamID が nil の場合は無所属にする）
+func (rs *RoomService) AssignPlayerTeam(roomID, playerID string, teamID *string) error {
+	if err := rs.requireTeamMode(roomID); err != nil {
+		return err
+	}
+	if _, err := rs.store.Players().GetPlayer(roomID, playerID); err != nil {
+		return err
 	}
+	if teamID != nil {
+		if _, err := rs.store.Teams().GetTeam(roomID, *teamID); err != nil {
+			return err
+		}
+	}
+
+	return rs.store.Players().SetPlayerTeam(roomID, playerID, teamID)
+}
 
-	// 5. questions テーブルをクリア（オプション）
-	_, err = rs.db.Exec("DELETE FROM questions")
+// AutoBalanceTeams 管理者以外のプレイヤーをチームに均等に振り分ける
+// teamCount が既存のチーム数より多い場合は不足分のチームを作成する。
+// 実力が偏らないよう、スコア順にスネーク方式（1→N, N→1, ...）で割り当てる
+func (rs *RoomService) AutoBalanceTeams(roomID string, teamCount int) error {
+	if err := rs.requireTeamMode(roomID); err != nil {
+		return err
+	}
+	if teamCount < 0 || teamCount > MaxTeamCount {
+		return fmt.Errorf("team count must be between 0 and %d", MaxTeamCount)
+	}
+
+	teams, err := rs.GetTeams(roomID)
 	if err != nil {
-		return fmt.Errorf("failed to clear questions: %w", err)
+		return err
+	}
+	for i := len(teams); i < teamCount; i++ {
+		team, err := rs.CreateTeam(roomID, fmt.Sprintf("チーム%d", i+1))
+		if err != nil {
+			return fmt.Errorf("failed to create team: %w", err)
+		}
+		teams = append(teams, *team)
+	}
+	if len(teams) == 0 {
+		return ErrNoTeams
 	}
 
-	// 6. サンプルデータを再挿入
-	err = rs.insertSampleData()
+	players, err := rs.GetRoomPlayers(roomID)
 	if err != nil {
-		return fmt.Errorf("failed to insert sample data: %w", err)
+		return fmt.Errorf("failed to get room players: %w", err)
 	}
 
+	var members []models.Player
+	for _, player := range players {
+		if !player.IsAdmin {
+			members = append(members, player)
+		}
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		return members[i].Score > members[j].Score
+	})
+
+	for i, player := range members {
+		round, position := i/len(teams), i%len(teams)
+		if round%2 == 1 {
+			position = len(teams) - 1 - position
+		}
+		teamID := teams[position].ID
+		if err := rs.store.Players().SetPlayerTeam(roomID, player.ID, &teamID); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// insertSampleData サンプルデータを挿入
-func (rs *RoomService) insertSampleData() error {
-	// サンプル問題を挿入
-	sampleQuestions := []struct {
-		question   string
-		answer     string
-		category   string
-		difficulty string
-	}{
-		{"日本の首都は？", "東京", "地理", "easy"},
-		{"1+1は？", "2", "数学", "easy"},
-		{"Go言語の作者は？", "ロブ・パイク", "プログラミング", "medium"},
-		{"世界で最も高い山は？", "エベレスト", "地理", "easy"},
-		{"2の3乗は？", "8", "数学", "medium"},
-		{"HTTPのデフォルトポートは？", "80", "プログラミング", "medium"},
-		{"光の速度は？", "約30万km/s", "科学", "hard"},
-		{"日本の国花は？", "桜", "文化", "easy"},
-		{"Pythonの作者は？", "グイド・ヴァン・ロッサム", "プログラミング", "medium"},
-		{"地球の衛星は？", "月", "科学", "easy"},
-	}
-
-	for _, q := range sampleQuestions {
-		_, err := rs.db.Exec(
-			"INSERT INTO questions (question, answer, category, difficulty) VALUES (?, ?, ?, ?)",
-			q.question, q.answer, q.category, q.difficulty,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to insert sample question: %w", err)
+// GetTeamRanking チームのランキングを取得（チームのスコアはメンバーの合計）
+func (rs *RoomService) GetTeamRanking(roomID string) ([]models.TeamRanking, error) {
+	teams, err := rs.GetTeams(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	players, err := rs.GetRoomPlayers(roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get room players: %w", err)
+	}
+	playerTeams := make(map[string]string)
+	for _, player := range players {
+		if player.TeamID != nil {
+			playerTeams[player.ID] = *player.TeamID
 		}
 	}
 
+	ranking, err := rs.GetRoomRanking(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	teamRankings := make([]models.TeamRanking, len(teams))
+	indexes := make(map[string]int)
+	for i, team := range teams {
+		teamRankings[i] = models.TeamRanking{
+			TeamID:  team.ID,
+			Name:    team.Name,
+			Members: []models.RoomRanking{},
+		}
+		indexes[team.ID] = i
+	}
+	for _, entry := range ranking {
+		if i, exists := indexes[playerTeams[entry.PlayerID]]; exists {
+			teamRankings[i].Score += entry.Score
+			teamRankings[i].Members = append(teamRankings[i].Members, entry)
+		}
+	}
+
+	// 同点は作成順
+	sort.SliceStable(teamRankings, func(i, j int) bool {
+		return teamRankings[i].Score > teamRankings[j].Score
+	})
+	for i := range teamRankings {
+		teamRankings[i].Rank = i + 1
+	}
+	return teamRankings, nil
+}
+
+// requireTeamMode ルームでチーム戦が有効か確認
+func (rs *RoomService) requireTeamMode(roomID string) error {
+	room, err := rs.store.Rooms().GetRoom(roomID)
+	if err != nil {
+		return err
+	}
+	if !room.Settings.Teams.Enabled {
+		return ErrTeamModeDisabled
+	}
 	return nil
 }
 
+// generateTeamID チームIDを生成（自動振り分けで続けて作成しても重複しないよう、乱数から UUID 形式で作る）
+func generateTeamID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := crand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate team id: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40 // バージョン 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 のバリアント
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
 // generatePlayerID UUIDを生成
 func generatePlayerID() string {
 	return fmt.Sprintf("%d", time.Now().UnixNano())
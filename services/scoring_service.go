@@ -0,0 +1,230 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"quivra-backend/models"
+)
+
+var (
+	// ErrLockedOut 誤答が多すぎてこのマッチでは回答できない
+	ErrLockedOut = errors.New("locked out after too many wrong answers")
+	// ErrAlreadyAnswered 全員正解モードで、この問題には既に回答済み
+	ErrAlreadyAnswered = errors.New("already answered this question")
+)
+
+// defaultDifficultyPoints 難易度別の基本点のデフォルト値
+var defaultDifficultyPoints = map[string]int{
+	"easy":   50,
+	"medium": 100,
+	"hard":   200,
+}
+
+// defaultTimeBonus 回答時間ボーナスのデフォルト値（5秒以内で最大ボーナス）
+var defaultTimeBonus = []models.TimeBonusStep{
+	{Within: 5, Bonus: 50},
+	{Within: 10, Bonus: 25},
+}
+
+// ScoreResult 1回の回答による得点の変化
+type ScoreResult struct {
+	Points    int // 加点（誤答の場合は減点で負の値）
+	Streak    int // 連続正解数
+	LockedOut bool
+}
+
+// ScoringService ルームの得点ルールを適用し、連続正解数や誤答数を管理する
+// 連続正解数・誤答数はメモリ上にのみ保持し、永続化しない（再起動すると進行中のマッチでも 0 から数え直す）
+// マッチの開始・終了、ルームの削除、全データリセットで破棄する
+type ScoringService struct {
+	mu    sync.Mutex
+	rooms map[string]*roomScoring // roomId -> 得点の状態
+}
+
+type roomScoring struct {
+	answered map[string]bool // 現在の問題に回答したプレイヤー
+	correct  map[string]bool // 現在の問題に正解したプレイヤー
+	streaks  map[string]int  // 連続正解数
+	misses   map[string]int  // マッチ中の誤答数
+}
+
+func NewScoringService() *ScoringService {
+	return &ScoringService{
+		rooms: make(map[string]*roomScoring),
+	}
+}
+
+// ValidateScoringSettings 得点ルールの値を検証
+func ValidateScoringSettings(settings models.ScoringSettings) error {
+	switch settings.Mode {
+	case "", models.ScoringModeFirstCorrect, models.ScoringModeAllCorrect:
+	default:
+		return fmt.Errorf("unknown scoring mode: %s", settings.Mode)
+	}
+
+	for _, value := range []int{settings.Easy, settings.Medium, settings.Hard, settings.WrongPenalty, settings.StreakBonus, settings.StreakBonusMax, settings.LockoutAfterMisses} {
+		if value < 0 {
+			return fmt.Errorf("scoring values must not be negative")
+		}
+	}
+	for _, step := range settings.TimeBonus {
+		if step.Within <= 0 || step.Bonus < 0 {
+			return fmt.Errorf("time bonus steps need a positive within and a non-negative bonus")
+		}
+	}
+	return nil
+}
+
+// AllCorrectMode 正解者全員が得点するモードか
+func AllCorrectMode(settings models.ScoringSettings) bool {
+	return settings.Mode == models.ScoringModeAllCorrect
+}
+
+// BasePoints 難易度別の基本点
+func BasePoints(settings models.ScoringSettings, difficulty string) int {
+	points := 0
+	switch difficulty {
+	case "easy":
+		points = settings.Easy
+	case "medium":
+		points = settings.Medium
+	case "hard":
+		points = settings.Hard
+	}
+	if points > 0 {
+		return points
+	}
+
+	if points, exists := defaultDifficultyPoints[difficulty]; exists {
+		return points
+	}
+	return defaultDifficultyPoints["medium"]
+}
+
+// TimeBonus 回答時間によるボーナス
+func TimeBonus(settings models.ScoringSettings, timeToAnswer time.Duration) int {
+	steps := settings.TimeBonus
+	if steps == nil {
+		steps = defaultTimeBonus
+	}
+
+	for _, step := range steps {
+		if timeToAnswer <= time.Duration(step.Within)*time.Second {
+			return step.Bonus
+		}
+	}
+	return 0
+}
+
+// StartMatch マッチ開始時に連続正解数と誤答数をリセット
+func (ss *ScoringService) StartMatch(roomID string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.rooms[roomID] = newRoomScoring()
+}
+
+// StartQuestion 新しい問題の開始を記録する。前の問題に正解しなかったプレイヤーの連続正解は途切れる
+func (ss *ScoringService) StartQuestion(roomID string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	room := ss.get(roomID)
+	for playerID := range room.streaks {
+		if !room.correct[playerID] {
+			delete(room.streaks, playerID)
+		}
+	}
+	room.answered = make(map[string]bool)
+	room.correct = make(map[string]bool)
+}
+
+// CheckCanAnswer プレイヤーが早押し・回答できるか確認
+func (ss *ScoringService) CheckCanAnswer(roomID, playerID string, settings models.ScoringSettings) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	room := ss.get(roomID)
+	if settings.LockoutAfterMisses > 0 && room.misses[playerID] >= settings.LockoutAfterMisses {
+		return ErrLockedOut
+	}
+	if AllCorrectMode(settings) && room.answered[playerID] {
+		return ErrAlreadyAnswered
+	}
+	return nil
+}
+
+// RecordCorrect 正解を記録し、加点を計算する
+func (ss *ScoringService) RecordCorrect(roomID, playerID string, settings models.ScoringSettings, difficulty string, timeToAnswer time.Duration) ScoreResult {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	room := ss.get(roomID)
+	room.answered[playerID] = true
+	room.correct[playerID] = true
+	room.streaks[playerID]++
+	streak := room.streaks[playerID]
+
+	streakBonus := (streak - 1) * settings.StreakBonus
+	if settings.StreakBonusMax > 0 && streakBonus > settings.StreakBonusMax {
+		streakBonus = settings.StreakBonusMax
+	}
+
+	return ScoreResult{
+		Points: BasePoints(settings, difficulty) + TimeBonus(settings, timeToAnswer) + streakBonus,
+		Streak: streak,
+	}
+}
+
+// RecordWrong 誤答を記録し、減点を計算する。連続正解は途切れる
+func (ss *ScoringService) RecordWrong(roomID, playerID string, settings models.ScoringSettings) ScoreResult {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	room := ss.get(roomID)
+	room.answered[playerID] = true
+	room.misses[playerID]++
+	delete(room.streaks, playerID)
+
+	return ScoreResult{
+		Points:    -settings.WrongPenalty,
+		LockedOut: settings.LockoutAfterMisses > 0 && room.misses[playerID] >= settings.LockoutAfterMisses,
+	}
+}
+
+// RemoveRoom ルームの得点の状態を削除（マッチの終了・ルームの削除）
+func (ss *ScoringService) RemoveRoom(roomID string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	delete(ss.rooms, roomID)
+}
+
+// Reset 全ルームの得点の状態を削除（全データリセット用）
+func (ss *ScoringService) Reset() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.rooms = make(map[string]*roomScoring)
+}
+
+func (ss *ScoringService) get(roomID string) *roomScoring {
+	room, exists := ss.rooms[roomID]
+	if !exists {
+		room = newRoomScoring()
+		ss.rooms[roomID] = room
+	}
+	return room
+}
+
+func newRoomScoring() *roomScoring {
+	return &roomScoring{
+		answered: make(map[string]bool),
+		correct:  make(map[string]bool),
+		streaks:  make(map[string]int),
+		misses:   make(map[string]int),
+	}
+}
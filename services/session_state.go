@@ -0,0 +1,121 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"quivra-backend/models"
+	"quivra-backend/repository"
+)
+
+// ゲームセッション（1問）の状態（game_sessions.status）
+//
+//	waiting → question ⇄ buzzed → answered
+//	            └────────┴──→ finished
+const (
+	SessionWaiting  = "waiting"  // 作成済みで、まだ出題していない
+	SessionQuestion = "question" // 出題中で回答を受け付けている
+	SessionBuzzed   = "buzzed"   // 早押ししたプレイヤーの回答を判定中
+	SessionAnswered = "answered" // 正解者が出て終了した
+	SessionFinished = "finished" // 正解者なしで終了した（時間切れ・スキップ・マッチ終了）
+)
+
+// sessionTransitions 許可する状態遷移
+var sessionTransitions = map[string][]string{
+	SessionWaiting:  {SessionQuestion, SessionFinished},
+	SessionQuestion: {SessionBuzzed, SessionFinished},
+	SessionBuzzed:   {SessionQuestion, SessionAnswered, SessionFinished},
+	SessionAnswered: {},
+	SessionFinished: {},
+}
+
+var (
+	// ErrInvalidSessionTransition ゲームセッションの現在の状態では受け付けられないイベント
+	ErrInvalidSessionTransition = errors.New("invalid game session transition")
+	// ErrSessionStatusChanged 更新する前に他のイベントがゲームセッションの状態を変えた
+	ErrSessionStatusChanged = repository.ErrSessionStatusChanged
+)
+
+// SessionStateError イベントがゲームセッションの状態と合わず受け付けられなかった
+// Err は ErrInvalidSessionTransition または ErrSessionStatusChanged
+type SessionStateError struct {
+	SessionID string
+	Status    string // 判定に使った状態
+	Action    string // 受け付けられなかった操作（start / answer / judge / end）
+	Err       error
+}
+
+func (e *SessionStateError) Error() string {
+	return fmt.Sprintf("cannot %s game session %s in status %s: %v", e.Action, e.SessionID, e.Status, e.Err)
+}
+
+func (e *SessionStateError) Unwrap() error {
+	return e.Err
+}
+
+// SessionStateMachine ゲームセッションの状態遷移を検証し、楽観的排他（UPDATE ... WHERE status = ?）で更新する
+type SessionStateMachine struct {
+	sessions repository.SessionRepository
+}
+
+func NewSessionStateMachine(sessions repository.SessionRepository) *SessionStateMachine {
+	return &SessionStateMachine{sessions: sessions}
+}
+
+// CanTransition from から to への遷移が許可されているか
+func (sm *SessionStateMachine) CanTransition(from, to string) bool {
+	for _, allowed := range sessionTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition セッションを to に遷移させる。成功すると session も更新する
+// 遷移表にない場合は ErrInvalidSessionTransition、読み込んだ後に状態が変わっていた場合は ErrSessionStatusChanged を返す
+func (sm *SessionStateMachine) Transition(session *models.GameSession, action, to string, change models.GameSessionChange) error {
+	if !sm.CanTransition(session.Status, to) {
+		return &SessionStateError{SessionID: session.ID, Status: session.Status, Action: action, Err: ErrInvalidSessionTransition}
+	}
+
+	if to == SessionAnswered || to == SessionFinished {
+		now := time.Now()
+		change.EndedAt = &now
+	}
+
+	err := sm.sessions.UpdateGameSessionStatus(session.ID, session.Status, to, change)
+	if errors.Is(err, repository.ErrSessionStatusChanged) {
+		return &SessionStateError{SessionID: session.ID, Status: session.Status, Action: action, Err: ErrSessionStatusChanged}
+	}
+	if err != nil {
+		return err
+	}
+
+	session.Status = to
+	if change.QuestionID != nil {
+		session.QuestionID = change.QuestionID
+	}
+	if change.QuestionRevision != nil {
+		session.QuestionRevision = change.QuestionRevision
+	}
+	if change.BuzzedPlayerID != nil {
+		session.BuzzedPlayerID = change.BuzzedPlayerID
+	}
+	if change.StartedAt != nil {
+		session.StartedAt = *change.StartedAt
+	}
+	session.EndedAt = change.EndedAt
+	return nil
+}
+
+// Require セッションが states のいずれかであることを確認する（状態を変えない操作の前提条件）
+func (sm *SessionStateMachine) Require(session *models.GameSession, action string, states ...string) error {
+	for _, state := range states {
+		if session.Status == state {
+			return nil
+		}
+	}
+	return &SessionStateError{SessionID: session.ID, Status: session.Status, Action: action, Err: ErrInvalidSessionTransition}
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"quivra-backend/models"
+	"quivra-backend/repository/memory"
+)
+
+func TestSessionStateMachineCanTransition(t *testing.T) {
+	allowed := map[[2]string]bool{
+		{SessionWaiting, SessionQuestion}:  true,
+		{SessionWaiting, SessionFinished}:  true,
+		{SessionQuestion, SessionBuzzed}:   true,
+		{SessionQuestion, SessionFinished}: true,
+		{SessionBuzzed, SessionQuestion}:   true,
+		{SessionBuzzed, SessionAnswered}:   true,
+		{SessionBuzzed, SessionFinished}:   true,
+	}
+
+	sm := NewSessionStateMachine(memory.NewStore().Sessions())
+	states := []string{SessionWaiting, SessionQuestion, SessionBuzzed, SessionAnswered, SessionFinished}
+	for _, from := range states {
+		for _, to := range states {
+			if got := sm.CanTransition(from, to); got != allowed[[2]string{from, to}] {
+				t.Errorf("CanTransition(%s, %s) = %v, want %v", from, to, got, !got)
+			}
+		}
+	}
+}
+
+// newTestSession インメモリストレージに status のゲームセッションを作成する
+func newTestSession(t *testing.T, status string) (*SessionStateMachine, *models.GameSession) {
+	t.Helper()
+	store := memory.NewStore()
+	session := &models.GameSession{ID: "session", RoomID: "room", Status: status}
+	if err := store.Sessions().CreateGameSession(session); err != nil {
+		t.Fatalf("CreateGameSession: %v", err)
+	}
+	return NewSessionStateMachine(store.Sessions()), session
+}
+
+func TestSessionStateMachineTransition(t *testing.T) {
+	tests := []struct {
+		from    string
+		to      string
+		wantErr error
+	}{
+		{SessionWaiting, SessionQuestion, nil},
+		{SessionWaiting, SessionBuzzed, ErrInvalidSessionTransition},
+		{SessionQuestion, SessionBuzzed, nil},
+		{SessionQuestion, SessionAnswered, ErrInvalidSessionTransition},
+		{SessionBuzzed, SessionQuestion, nil},
+		{SessionBuzzed, SessionAnswered, nil},
+		{SessionBuzzed, SessionFinished, nil},
+		{SessionAnswered, SessionFinished, ErrInvalidSessionTransition},
+		{SessionFinished, SessionQuestion, ErrInvalidSessionTransition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"→"+tt.to, func(t *testing.T) {
+			sm, session := newTestSession(t, tt.from)
+
+			err := sm.Transition(session, "test", tt.to, models.GameSessionChange{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Transition = %v, want %v", err, tt.wantErr)
+			}
+
+			wantStatus := tt.to
+			if tt.wantErr != nil {
+				wantStatus = tt.from
+				var stateErr *SessionStateError
+				if !errors.As(err, &stateErr) || stateErr.Status != tt.from || stateErr.Action != "test" {
+					t.Errorf("error = %#v, want a SessionStateError for %s", err, tt.from)
+				}
+			}
+			if session.Status != wantStatus {
+				t.Errorf("session.Status = %s, want %s", session.Status, wantStatus)
+			}
+			stored, err := sm.sessions.GetGameSession(session.ID)
+			if err != nil {
+				t.Fatalf("GetGameSession: %v", err)
+			}
+			if stored.Status != wantStatus {
+				t.Errorf("stored status = %s, want %s", stored.Status, wantStatus)
+			}
+
+			// 終了した場合のみ終了時刻を記録する
+			ended := wantStatus == SessionAnswered || wantStatus == SessionFinished
+			if tt.wantErr == nil && (stored.EndedAt != nil) != ended {
+				t.Errorf("EndedAt = %v, want set: %v", stored.EndedAt, ended)
+			}
+		})
+	}
+}
+
+func TestSessionStateMachineStatusChanged(t *testing.T) {
+	sm, session := newTestSession(t, SessionQuestion)
+
+	// 同じセッションを読み込んだ2つのイベントのうち、後から更新するほうは失敗する
+	stale := *session
+	playerID := "alice"
+	if err := sm.Transition(session, "answer", SessionBuzzed, models.GameSessionChange{BuzzedPlayerID: &playerID}); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+	err := sm.Transition(&stale, "end", SessionFinished, models.GameSessionChange{})
+	if !errors.Is(err, ErrSessionStatusChanged) {
+		t.Fatalf("Transition from a stale status = %v, want ErrSessionStatusChanged", err)
+	}
+	if stale.Status != SessionQuestion || stale.EndedAt != nil {
+		t.Errorf("stale session was modified: %+v", stale)
+	}
+
+	stored, err := sm.sessions.GetGameSession(session.ID)
+	if err != nil {
+		t.Fatalf("GetGameSession: %v", err)
+	}
+	if stored.Status != SessionBuzzed || stored.BuzzedPlayerID == nil || *stored.BuzzedPlayerID != playerID {
+		t.Errorf("stored session = %+v, want buzzed by %s", stored, playerID)
+	}
+}
+
+func TestSessionStateMachineRequire(t *testing.T) {
+	sm, session := newTestSession(t, SessionBuzzed)
+
+	if err := sm.Require(session, "answer", SessionQuestion, SessionBuzzed); err != nil {
+		t.Errorf("Require(question, buzzed) = %v", err)
+	}
+	if err := sm.Require(session, "answer", SessionQuestion); !errors.Is(err, ErrInvalidSessionTransition) {
+		t.Errorf("Require(question) = %v, want ErrInvalidSessionTransition", err)
+	}
+}
+
+func TestGameServiceSessionFlow(t *testing.T) {
+	gs := NewGameService(memory.NewStore())
+
+	session, err := gs.CreateGameSession("room", "match", 1)
+	if err != nil {
+		t.Fatalf("CreateGameSession: %v", err)
+	}
+	if err := gs.CheckAcceptingAnswers(session); !errors.Is(err, ErrInvalidSessionTransition) {
+		t.Errorf("CheckAcceptingAnswers before the question = %v, want ErrInvalidSessionTransition", err)
+	}
+
+	question := &models.Question{ID: 7, Revision: 3}
+	if err := gs.StartQuestion(session, question); err != nil {
+		t.Fatalf("StartQuestion: %v", err)
+	}
+	if session.QuestionID == nil || *session.QuestionID != 7 || session.QuestionRevision == nil || *session.QuestionRevision != 3 {
+		t.Errorf("session after StartQuestion = %+v, want question 7 at revision 3", session)
+	}
+	if err := gs.StartQuestion(session, question); !errors.Is(err, ErrInvalidSessionTransition) {
+		t.Errorf("StartQuestion twice = %v, want ErrInvalidSessionTransition", err)
+	}
+
+	// 誤答なら回答の受付に戻る
+	if err := gs.BeginAnswer(session, "alice"); err != nil {
+		t.Fatalf("BeginAnswer(alice): %v", err)
+	}
+	if err := gs.BeginAnswer(session, "bob"); !errors.Is(err, ErrInvalidSessionTransition) {
+		t.Errorf("BeginAnswer while judging = %v, want ErrInvalidSessionTransition", err)
+	}
+	if err := gs.FinishAnswer(session, "alice", false); err != nil {
+		t.Fatalf("FinishAnswer(alice): %v", err)
+	}
+	if err := gs.CheckAcceptingAnswers(session); err != nil {
+		t.Errorf("CheckAcceptingAnswers after a wrong answer = %v", err)
+	}
+
+	if err := gs.BeginAnswer(session, "bob"); err != nil {
+		t.Fatalf("BeginAnswer(bob): %v", err)
+	}
+	if err := gs.FinishAnswer(session, "bob", true); err != nil {
+		t.Fatalf("FinishAnswer(bob): %v", err)
+	}
+	if session.Status != SessionAnswered || session.BuzzedPlayerID == nil || *session.BuzzedPlayerID != "bob" {
+		t.Errorf("session after the correct answer = %+v, want answered by bob", session)
+	}
+
+	// 正解者が出た後の時間切れは受け付けない
+	if err := gs.EndQuestion(session); !errors.Is(err, ErrInvalidSessionTransition) {
+		t.Errorf("EndQuestion after answered = %v, want ErrInvalidSessionTransition", err)
+	}
+	if _, err := gs.GetActiveGameSession("room"); err == nil {
+		t.Error("GetActiveGameSession returned an answered session")
+	}
+}
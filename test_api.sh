@@ -3,6 +3,8 @@
 # Quivra Backend API テストスクリプト
 
 BASE_URL="http://localhost:8080/api"
+# 問題の作成にはサーバーと同じオペレーターのキーが必要
+ADMIN_API_KEY="${ADMIN_API_KEY:?ADMIN_API_KEY を設定してください}"
 
 echo "=== Quivra Backend API テスト ==="
 
@@ -10,7 +12,7 @@ echo "=== Quivra Backend API テスト ==="
 echo "1. ルーム作成テスト"
 ROOM_RESPONSE=$(curl -s -X POST "$BASE_URL/rooms" \
   -H "Content-Type: application/json" \
-  -d '{"name": "テストルーム"}')
+  -d '{"name": "テストルーム", "creator_name": "管理者"}')
 
 echo "ルーム作成レスポンス: $ROOM_RESPONSE"
 
@@ -31,6 +33,7 @@ curl -s -X GET "$BASE_URL/rooms/$ROOM_ID" | jq '.'
 echo -e "\n3. 問題作成テスト"
 curl -s -X POST "$BASE_URL/questions" \
   -H "Content-Type: application/json" \
+  -H "Authorization: Bearer $ADMIN_API_KEY" \
   -d '{
     "question": "Go言語の作者は誰ですか？",
     "answer": "ロブ・パイク",
@@ -44,13 +47,15 @@ curl -s -X GET "$BASE_URL/questions" | jq '.'
 
 # 5. ルーム参加（HTTP API経由）
 echo -e "\n5. ルーム参加テスト"
-curl -s -X POST "$BASE_URL/rooms/join" \
+JOIN_RESPONSE=$(curl -s -X POST "$BASE_URL/rooms/join" \
   -H "Content-Type: application/json" \
   -d "{
     \"roomId\": \"$ROOM_ID\",
     \"playerName\": \"テストプレイヤー1\"
-  }" | jq '.'
+  }")
+echo "$JOIN_RESPONSE" | jq '.'
+TOKEN=$(echo "$JOIN_RESPONSE" | jq -r '.token')
 
 echo -e "\n=== テスト完了 ==="
 echo "WebSocketテストは手動で行ってください:"
-echo "ws://localhost:8080/ws"
+echo "ws://localhost:8080/ws?token=$TOKEN"
@@ -2,7 +2,9 @@ const WebSocket = require('ws');
 
 console.log('WebSocket client test starting...');
 
-const ws = new WebSocket('ws://localhost:8080/ws');
+// 使い方: node test_ws_client.js <セッショントークン>
+const token = process.argv[2] || '';
+const ws = new WebSocket('ws://localhost:8080/ws?token=' + encodeURIComponent(token));
 
 ws.on('open', function open() {
   console.log('WebSocket connected successfully!');
@@ -1,160 +1,111 @@
 package websocket
 
 import (
-	"encoding/json"
 	"log"
+	"strconv"
 	"sync"
+	"time"
 
 	"quivra-backend/models"
 
 	"github.com/gorilla/websocket"
 )
 
-type Connection struct {
-	Conn     *websocket.Conn
-	Send     chan []byte
-	PlayerID string
-	RoomID   string
-}
+const (
+	// writeWait 1回の書き込みの制限時間
+	writeWait = 10 * time.Second
 
-type Hub struct {
-	// 登録された接続
-	connections map[*Connection]bool
+	// pongWait この時間内に pong（または何らかのメッセージ）が届かなければ接続が死んでいるとみなす
+	pongWait = 60 * time.Second
 
-	// ルーム別の接続
-	rooms map[string]map[*Connection]bool
+	// pingPeriod ping の送信間隔（pongWait より短くする）。pong までの時間で往復遅延も測定する
+	pingPeriod = 5 * time.Second
 
-	// 接続からのメッセージを登録
-	register chan *Connection
+	// maxRTTSample これより遅い pong は測定値として使わない
+	maxRTTSample = 10 * time.Second
 
-	// 接続の登録解除
-	unregister chan *Connection
+	// maxMessageSize クライアントから受け付けるメッセージの最大サイズ
+	maxMessageSize = 8192
+)
 
-	// 接続にメッセージをブロードキャスト
-	broadcast chan []byte
+// 接続の役割（送信するイベントの内容を役割ごとに変える）
+const (
+	RolePlayer    = "player"
+	RoleAdmin     = "admin"     // ルームの管理者。出題中の問題の正解も受け取る
+	RoleSpectator = "spectator" // 観戦者。プレイヤーとしては参加しない
+	RoleDisplay   = "display"   // ビッグスクリーン表示用。受信専用でイベントは送れない
+)
 
-	// ルーム別ブロードキャスト
-	roomBroadcast chan RoomMessage
+type Connection struct {
+	Conn     *websocket.Conn
+	Send     chan []byte
+	PlayerID string
+	RoomID   string
+	Token    string // 接続時に検証したセッショントークン（管理者イベントで再検証する）
+	Role     string // 接続時に決めた役割（RolePlayer / RoleAdmin / RoleSpectator / RoleDisplay）
 
-	// 接続の保護
-	mu sync.RWMutex
+	rttMu sync.Mutex
+	rtt   time.Duration // ping/pong による往復遅延の推定値（指数移動平均）
 }
 
-type RoomMessage struct {
-	RoomID  string
-	Message []byte
-}
+// RTT 接続の往復遅延の推定値（まだ測定できていない場合は0）
+func (c *Connection) RTT() time.Duration {
+	c.rttMu.Lock()
+	defer c.rttMu.Unlock()
 
-func NewHub() *Hub {
-	return &Hub{
-		connections:   make(map[*Connection]bool),
-		rooms:         make(map[string]map[*Connection]bool),
-		register:      make(chan *Connection),
-		unregister:    make(chan *Connection),
-		broadcast:     make(chan []byte),
-		roomBroadcast: make(chan RoomMessage),
-	}
+	return c.rtt
 }
 
-func (h *Hub) Run() {
-	for {
-		select {
-		case connection := <-h.register:
-			h.mu.Lock()
-			h.connections[connection] = true
-
-			// ルームに接続を追加
-			if connection.RoomID != "" {
-				if h.rooms[connection.RoomID] == nil {
-					h.rooms[connection.RoomID] = make(map[*Connection]bool)
-				}
-				h.rooms[connection.RoomID][connection] = true
-			}
-			h.mu.Unlock()
-
-		case connection := <-h.unregister:
-			h.mu.Lock()
-			if _, ok := h.connections[connection]; ok {
-				delete(h.connections, connection)
-				close(connection.Send)
-
-				// ルームから接続を削除
-				if connection.RoomID != "" {
-					if room, exists := h.rooms[connection.RoomID]; exists {
-						delete(room, connection)
-						if len(room) == 0 {
-							delete(h.rooms, connection.RoomID)
-						}
-					}
-				}
-			}
-			h.mu.Unlock()
-
-		case message := <-h.broadcast:
-			h.mu.RLock()
-			for connection := range h.connections {
-				select {
-				case connection.Send <- message:
-				default:
-					close(connection.Send)
-					delete(h.connections, connection)
-				}
-			}
-			h.mu.RUnlock()
-
-		case roomMsg := <-h.roomBroadcast:
-			h.mu.RLock()
-			if room, exists := h.rooms[roomMsg.RoomID]; exists {
-				for connection := range room {
-					select {
-					case connection.Send <- roomMsg.Message:
-					default:
-						close(connection.Send)
-						delete(h.connections, connection)
-						delete(room, connection)
-					}
-				}
-			}
-			h.mu.RUnlock()
-		}
-	}
+// seesAnswers 出題中の問題の正解を送ってよい接続か
+func (c *Connection) seesAnswers() bool {
+	return c.Role == RoleAdmin
 }
 
-func (h *Hub) SendToRoom(roomID string, message interface{}) {
-	msg, err := json.Marshal(message)
+// recordPong ping に埋め込んだ送信時刻から往復遅延を測定し、推定値を更新する
+func (c *Connection) recordPong(payload string) {
+	sentAt, err := strconv.ParseInt(payload, 10, 64)
 	if err != nil {
-		log.Printf("Error marshaling message: %v", err)
 		return
 	}
-
-	h.roomBroadcast <- RoomMessage{
-		RoomID:  roomID,
-		Message: msg,
+	sample := time.Since(time.Unix(0, sentAt))
+	if sample <= 0 || sample > maxRTTSample {
+		return
 	}
-}
 
-func (h *Hub) GetRoomConnections(roomID string) []*Connection {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	c.rttMu.Lock()
+	defer c.rttMu.Unlock()
 
-	var connections []*Connection
-	if room, exists := h.rooms[roomID]; exists {
-		for conn := range room {
-			connections = append(connections, conn)
-		}
+	if c.rtt == 0 {
+		c.rtt = sample
+		return
 	}
-	return connections
+	c.rtt = (c.rtt*7 + sample) / 8
+}
+
+// writePing 送信時刻を埋め込んだ ping を送る
+func (c *Connection) writePing() error {
+	c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.Conn.WriteMessage(websocket.PingMessage, []byte(strconv.FormatInt(time.Now().UnixNano(), 10)))
 }
 
 func (c *Connection) ReadPump(hub *Hub, wsHandler *WSHandler) {
 	defer func() {
 		log.Printf("WebSocket connection closed, unregistering...")
-		hub.unregister <- c
+		hub.Unregister(c)
 		c.Conn.Close()
+		wsHandler.handleDisconnect(c)
 	}()
 
 	log.Printf("WebSocket ReadPump started")
 
+	// 読み込み期限を設定し、pong を受け取るたびに延長する
+	c.Conn.SetReadLimit(maxMessageSize)
+	c.Conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.Conn.SetPongHandler(func(payload string) error {
+		c.recordPong(payload)
+		return c.Conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
 	for {
 		var msg models.WSMessage
 		err := c.Conn.ReadJSON(&msg)
@@ -164,6 +115,7 @@ func (c *Connection) ReadPump(hub *Hub, wsHandler *WSHandler) {
 			}
 			break
 		}
+		c.Conn.SetReadDeadline(time.Now().Add(pongWait))
 
 		log.Printf("WebSocket message received: %+v", msg)
 		// メッセージの処理
@@ -172,19 +124,41 @@ func (c *Connection) ReadPump(hub *Hub, wsHandler *WSHandler) {
 }
 
 func (c *Connection) WritePump() {
-	defer c.Conn.Close()
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.Conn.Close()
+	}()
 
 	log.Printf("WebSocket WritePump started")
 
-	// WebSocketのWritePumpはチャンネルが閉じられるまでメッセージを待機
-	for message := range c.Send {
-		log.Printf("WebSocket sending message: %s", string(message))
-		if err := c.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
-			log.Printf("WebSocket write error: %v", err)
-			return
-		}
+	// 早押しの遅延補正に使えるよう、接続直後にも往復遅延を測定する
+	if err := c.writePing(); err != nil {
+		log.Printf("WebSocket ping error: %v", err)
+		return
 	}
 
-	log.Printf("WebSocket send channel closed")
-	c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+	// チャンネルが閉じられるまでメッセージを送信し、定期的に ping を送る
+	for {
+		select {
+		case message, ok := <-c.Send:
+			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				log.Printf("WebSocket send channel closed")
+				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+
+			if err := c.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				log.Printf("WebSocket write error: %v", err)
+				return
+			}
+
+		case <-ticker.C:
+			if err := c.writePing(); err != nil {
+				log.Printf("WebSocket ping error: %v", err)
+				return
+			}
+		}
+	}
 }
@@ -0,0 +1,150 @@
+package websocket
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"quivra-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandleDisplay ビッグスクリーン表示用の接続（ルームの表示用キーで認証する受信専用の接続）
+func (wsh *WSHandler) HandleDisplay(c *gin.Context) {
+	roomID := c.Query("roomId")
+	key := c.Query("key")
+	if bearer, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); found {
+		key = strings.TrimSpace(bearer)
+	}
+	if !wsh.authService.VerifyDisplayKey(roomID, key) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid display key"})
+		return
+	}
+	if _, err := wsh.roomService.GetRoom(roomID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade error: %v", err)
+		c.JSON(500, gin.H{"error": "WebSocket upgrade failed"})
+		return
+	}
+
+	log.Printf("Display connection established for room %s", roomID)
+
+	connection := &Connection{
+		Conn:   conn,
+		Send:   make(chan []byte, 256),
+		RoomID: roomID,
+		Role:   RoleDisplay,
+	}
+	wsh.hub.register <- connection
+
+	// 接続直後に現在の状態を送る（再接続時も同じ。取りこぼしたイベントの再送は行わない）
+	if data, err := wsh.displayState(roomID, nil); err != nil {
+		log.Printf("Error getting display state: %v", err)
+	} else {
+		wsh.sendEvent(connection, "display-state", data)
+	}
+
+	go connection.WritePump()
+	go connection.ReadPump(wsh.hub, wsh)
+}
+
+// broadcastDisplayState ルームの表示用接続に現在の状態を送信
+func (wsh *WSHandler) broadcastDisplayState(roomID string) {
+	wsh.sendDisplayState(roomID, nil)
+}
+
+// sendDisplayState ルームの表示用接続に状態を送信（room が nil なら現在の状態を組み立てる）
+func (wsh *WSHandler) sendDisplayState(roomID string, room *models.RoomUpdatedData) {
+	if wsh.hub.DisplayCount(roomID) == 0 {
+		return
+	}
+	data, err := wsh.displayState(roomID, room)
+	if err != nil {
+		log.Printf("Error getting display state: %v", err)
+		return
+	}
+	wsh.hub.SendToDisplays(roomID, models.WSMessage{
+		Event: "display-state",
+		Data:  data,
+	})
+}
+
+// displayState room-updated の内容に制限時間の残りと早押しの順位（遅延補正後の押下時刻順）を加える
+// 表示用接続はプレイヤーにも見える画面のため、出題中の問題の正解は含めない
+func (wsh *WSHandler) displayState(roomID string, room *models.RoomUpdatedData) (*models.DisplayStateData, error) {
+	if room == nil {
+		state, err := wsh.roomState(roomID)
+		if err != nil {
+			return nil, err
+		}
+		room = state
+	}
+	queue, err := wsh.queueEntries(roomID, room.Players)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &models.DisplayStateData{
+		Room:      room.Redacted(),
+		BuzzOrder: []models.DisplayBuzzEntry{},
+	}
+	if remaining, running := wsh.questionTimer.Remaining(roomID); running {
+		data.RemainingMs = remaining.Milliseconds()
+	}
+	for i, entry := range queue {
+		data.BuzzOrder = append(data.BuzzOrder, models.DisplayBuzzEntry{
+			QueueEntry: entry,
+			Position:   i + 1,
+			BehindMs:   entry.PressedAt.Sub(queue[0].PressedAt).Milliseconds(),
+		})
+	}
+	return data, nil
+}
+
+// sendDisplayCue ルームの表示用接続に演出のきっかけを送信
+func (wsh *WSHandler) sendDisplayCue(roomID string, cue models.DisplayCueData) {
+	if wsh.hub.DisplayCount(roomID) == 0 {
+		return
+	}
+	wsh.hub.SendToDisplays(roomID, models.WSMessage{
+		Event: "display-cue",
+		Data:  cue,
+	})
+}
+
+// revealOnDisplays 問題の終了時に、表示用接続へ正解の公開とランキングの表示を続けて送る
+func (wsh *WSHandler) revealOnDisplays(roomID string, question *models.Question, playerID string) {
+	if question == nil {
+		return
+	}
+	wsh.sendDisplayCue(roomID, models.DisplayCueData{
+		Cue:           models.DisplayCueReveal,
+		QuestionID:    question.ID,
+		CorrectAnswer: question.Answer,
+		PlayerID:      playerID,
+	})
+	wsh.sendLeaderboardCue(roomID, false)
+}
+
+// sendLeaderboardCue 表示用接続にランキングの表示を送る（final はマッチ・ゲームの終了時）
+func (wsh *WSHandler) sendLeaderboardCue(roomID string, final bool) {
+	if wsh.hub.DisplayCount(roomID) == 0 {
+		return
+	}
+	ranking, err := wsh.roomService.GetRoomRanking(roomID)
+	if err != nil {
+		log.Printf("Error getting ranking: %v", err)
+		return
+	}
+	wsh.sendDisplayCue(roomID, models.DisplayCueData{
+		Cue:     models.DisplayCueLeaderboard,
+		Ranking: ranking,
+		Final:   final,
+	})
+}
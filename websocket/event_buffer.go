@@ -0,0 +1,54 @@
+package websocket
+
+// RoomEventBufferSize 再接続時に再送できるルームイベントの最大件数（接続の送信バッファより小さくする）
+const RoomEventBufferSize = 128
+
+type bufferedEvent struct {
+	seq      int64
+	message  []byte
+	redacted []byte // 正解を伏せたプレイヤー向けの内容（全接続に同じ内容を送るイベントでは nil）
+}
+
+// messageFor 接続の役割に応じて送る内容
+func (e bufferedEvent) messageFor(connection *Connection) []byte {
+	if e.redacted != nil && !connection.seesAnswers() {
+		return e.redacted
+	}
+	return e.message
+}
+
+// roomEventBuffer ルームに送信したイベントをシーケンス番号付きで保持するリングバッファ
+type roomEventBuffer struct {
+	lastSeq int64
+	events  []bufferedEvent // 古い順
+}
+
+// append 採番済みのイベントを保持する
+func (b *roomEventBuffer) append(event bufferedEvent) {
+	if len(b.events) >= RoomEventBufferSize {
+		b.events = append(b.events[:0], b.events[1:]...)
+	}
+	b.events = append(b.events, event)
+}
+
+// since lastSeq より後のイベントを返す。古いイベントが既に捨てられていて再送できない場合は false
+func (b *roomEventBuffer) since(lastSeq int64) ([]bufferedEvent, bool) {
+	if lastSeq > b.lastSeq {
+		// サーバー再起動などでシーケンス番号が巻き戻っている
+		return nil, false
+	}
+	if lastSeq == b.lastSeq {
+		return nil, true
+	}
+	if len(b.events) == 0 || b.events[0].seq > lastSeq+1 {
+		return nil, false
+	}
+
+	var events []bufferedEvent
+	for _, event := range b.events {
+		if event.seq > lastSeq {
+			events = append(events, event)
+		}
+	}
+	return events, true
+}
@@ -2,8 +2,11 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"quivra-backend/models"
@@ -13,6 +16,9 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+// ReconnectGracePeriod 切断後も回答キューの順番を保持する時間
+const ReconnectGracePeriod = 30 * time.Second
+
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		log.Printf("WebSocket origin check: %s", r.Header.Get("Origin"))
@@ -23,67 +29,246 @@ var upgrader = websocket.Upgrader{
 }
 
 type WSHandler struct {
-	hub              *Hub
-	roomService      *services.RoomService
-	questionService  *services.QuestionService
-	gameService      *services.GameService
-	buzzManager      *services.BuzzManager
-	buzzQueueService *services.BuzzQueueService
+	hub             *Hub
+	roomService     *services.RoomService
+	questionService *services.QuestionService
+	gameService     *services.GameService
+	buzzService     *services.BuzzService
+	questionTimer   *services.QuestionTimer
+	authService     *services.AuthService
+	presence        *services.PresenceTracker
+	scoring         *services.ScoringService
 }
 
-func NewWSHandler(hub *Hub, roomService *services.RoomService, questionService *services.QuestionService, gameService *services.GameService, buzzManager *services.BuzzManager, buzzQueueService *services.BuzzQueueService) *WSHandler {
-	return &WSHandler{
-		hub:              hub,
-		roomService:      roomService,
-		questionService:  questionService,
-		gameService:      gameService,
-		buzzManager:      buzzManager,
-		buzzQueueService: buzzQueueService,
+func NewWSHandler(hub *Hub, roomService *services.RoomService, questionService *services.QuestionService, gameService *services.GameService, buzzService *services.BuzzService, questionTimer *services.QuestionTimer, authService *services.AuthService, presence *services.PresenceTracker, scoring *services.ScoringService) *WSHandler {
+	wsh := &WSHandler{
+		hub:             hub,
+		roomService:     roomService,
+		questionService: questionService,
+		gameService:     gameService,
+		buzzService:     buzzService,
+		questionTimer:   questionTimer,
+		authService:     authService,
+		presence:        presence,
+		scoring:         scoring,
 	}
+
+	// 早押しの状態が変わるたびにルームへ通知する
+	buzzService.OnStateChange(wsh.broadcastBuzzState)
+	// キック・参加禁止でプレイヤーを削除する前に、回答キューから外して判定中の回答権を閉じる
+	roomService.OnRemovePlayer(wsh.withdrawPlayer)
+	return wsh
 }
 
 func (wsh *WSHandler) HandleWebSocket(c *gin.Context) {
 	log.Printf("WebSocket connection attempt from %s", c.ClientIP())
-	log.Printf("Request headers: %v", c.Request.Header)
+
+	// セッショントークンを検証（ブラウザはヘッダーを付けられないためクエリパラメータも受け付ける）
+	token := c.Query("token")
+	if bearer, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); found {
+		token = strings.TrimSpace(bearer)
+	}
+	claims, err := wsh.authService.Authenticate(token)
+	if err != nil {
+		log.Printf("WebSocket authentication failed: %v", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 再接続の場合は最後に受信したイベントのシーケンス番号を受け取る
+	lastSeq := int64(-1)
+	if value := c.Query("lastSeq"); value != "" {
+		lastSeq, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || lastSeq < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid lastSeq"})
+			return
+		}
+	}
+
+	// 管理者の接続には出題中の問題の正解も送る
+	role := RolePlayer
+	if claims.IsSpectator() {
+		role = RoleSpectator
+	} else if isAdmin, err := wsh.roomService.IsPlayerAdmin(claims.RoomID, claims.PlayerID); err == nil && isAdmin {
+		role = RoleAdmin
+	}
+
+	connection := &Connection{
+		Send:     make(chan []byte, 256),
+		PlayerID: claims.PlayerID,
+		RoomID:   claims.RoomID,
+		Token:    token,
+		Role:     role,
+	}
+
+	// 観戦者は接続数の上限を確認して先に登録する（上限に達していれば接続を拒否）
+	if role == RoleSpectator {
+		room, err := wsh.roomService.GetRoom(claims.RoomID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+			return
+		}
+		if !wsh.hub.AddSpectator(connection, services.SpectatorLimit(room.Settings.Spectators)) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Spectator limit reached"})
+			return
+		}
+	}
 
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade error: %v", err)
+		if role == RoleSpectator {
+			wsh.hub.Unregister(connection)
+		}
 		c.JSON(500, gin.H{"error": "WebSocket upgrade failed"})
 		return
 	}
+	connection.Conn = conn
 
 	log.Printf("WebSocket connection established successfully")
 
-	connection := &Connection{
-		Conn:     conn,
-		Send:     make(chan []byte, 256),
-		PlayerID: "",
-		RoomID:   "",
+	if lastSeq >= 0 {
+		wsh.resume(connection, lastSeq)
+	} else if role != RoleSpectator {
+		wsh.hub.register <- connection
 	}
 
-	wsh.hub.register <- connection
+	if role == RoleSpectator {
+		// 観戦者数を更新
+		wsh.broadcastRoomUpdate(connection.RoomID)
+	} else if status, changed := wsh.presence.Connect(connection.RoomID, connection.PlayerID); changed {
+		wsh.broadcastPresence(connection.RoomID, connection.PlayerID, status)
+	}
 
 	go connection.WritePump()
 	go connection.ReadPump(wsh.hub, wsh)
 }
 
+// resume 再接続した接続に取りこぼしたイベントを再送する。再送できない場合は現在の状態を送る
+func (wsh *WSHandler) resume(conn *Connection, lastSeq int64) {
+	replayed, ok := wsh.hub.Resume(conn, lastSeq)
+	if ok {
+		log.Printf("Resumed player %s in room %s: replayed %d events", conn.PlayerID, conn.RoomID, replayed)
+		wsh.sendEvent(conn, "resumed", models.ResumedData{
+			Replayed: replayed,
+			Seq:      lastSeq + int64(replayed),
+		})
+		return
+	}
+
+	log.Printf("Resume gap too large for player %s in room %s, sending snapshot", conn.PlayerID, conn.RoomID)
+	wsh.sendSnapshot(conn)
+}
+
+// sendSnapshot ルームの現在の状態をまとめて送信
+func (wsh *WSHandler) sendSnapshot(conn *Connection) {
+	// 先にシーケンス番号を取得し、スナップショットがその時点以降の状態であることを保証する
+	seq := wsh.hub.LatestSeq(conn.RoomID)
+
+	room, err := wsh.roomState(conn.RoomID)
+	if err != nil {
+		log.Printf("Error getting room state: %v", err)
+		wsh.sendError(conn, "Failed to restore room state")
+		return
+	}
+	queue, err := wsh.queueWithPlayers(conn.RoomID)
+	if err != nil {
+		log.Printf("Error getting queue: %v", err)
+		wsh.sendError(conn, "Failed to restore room state")
+		return
+	}
+	if !conn.seesAnswers() {
+		*room = room.Redacted()
+	}
+
+	wsh.sendEvent(conn, "state-snapshot", models.StateSnapshotData{
+		Seq:   seq,
+		Room:  *room,
+		Queue: queue,
+	})
+}
+
+// handleDisconnect 最後の接続が切れたら away にし、猶予時間内に再接続しなければ offline にして回答キューから外す
+func (wsh *WSHandler) handleDisconnect(conn *Connection) {
+	if conn.Role == RoleSpectator {
+		// 観戦者数を更新
+		wsh.broadcastRoomUpdate(conn.RoomID)
+		return
+	}
+	if conn.PlayerID == "" || conn.RoomID == "" {
+		return
+	}
+
+	roomID, playerID := conn.RoomID, conn.PlayerID
+	// キック・参加禁止でルームから外されたプレイヤーの在席状態は追跡しない
+	if _, err := wsh.roomService.GetRoomPlayer(roomID, playerID); err != nil {
+		return
+	}
+	if status, changed := wsh.presence.Disconnect(roomID, playerID); changed {
+		wsh.broadcastPresence(roomID, playerID, status)
+	}
+
+	time.AfterFunc(ReconnectGracePeriod, func() {
+		if !wsh.presence.MarkOffline(roomID, playerID, ReconnectGracePeriod) {
+			return
+		}
+		wsh.broadcastPresence(roomID, playerID, services.PresenceOffline)
+
+		removed, err := wsh.buzzService.RemoveFromQueue(roomID, playerID)
+		if err != nil {
+			log.Printf("Error removing disconnected player from queue: %v", err)
+			return
+		}
+		if !removed {
+			return
+		}
+		log.Printf("Removed player %s from queue in room %s after disconnect", playerID, roomID)
+		wsh.broadcastQueueUpdate(roomID)
+	})
+}
+
 func (wsh *WSHandler) HandleMessage(conn *Connection, msg models.WSMessage) {
+	// 表示用接続は受信専用で、ゲームのイベントは送れない
+	if conn.Role == RoleDisplay {
+		wsh.sendError(conn, "Display connections cannot send events")
+		return
+	}
+
 	switch msg.Event {
 	case "join-room":
 		wsh.handleJoinRoom(conn, msg.Data)
+	case "set-presence":
+		wsh.handleSetPresence(conn, msg.Data)
 	case "buzz-in":
 		wsh.handleBuzzIn(conn, msg.Data)
 	case "submit-answer":
 		wsh.handleSubmitAnswer(conn, msg.Data)
 	case "start-game":
 		wsh.handleStartGame(conn, msg.Data)
+	case "next-question":
+		wsh.handleNextQuestion(conn, msg.Data)
 	case "judge-answer":
 		wsh.handleJudgeAnswer(conn, msg.Data)
 	case "reset-queue":
 		wsh.handleResetQueue(conn, msg.Data)
 	case "end-game":
 		wsh.handleEndGame(conn, msg.Data)
+	case "delete-room":
+		wsh.handleDeleteRoom(conn, msg.Data)
+	case "create-team":
+		wsh.handleCreateTeam(conn, msg.Data)
+	case "delete-team":
+		wsh.handleDeleteTeam(conn, msg.Data)
+	case "assign-team":
+		wsh.handleAssignTeam(conn, msg.Data)
+	case "auto-balance-teams":
+		wsh.handleAutoBalanceTeams(conn, msg.Data)
+	case "kick-player":
+		wsh.handleKickPlayer(conn, msg.Data)
+	case "ban-player":
+		wsh.handleBanPlayer(conn, msg.Data)
+	case "mute-player":
+		wsh.handleMutePlayer(conn, msg.Data)
 	default:
 		log.Printf("Unknown event: %s", msg.Event)
 	}
@@ -105,22 +290,32 @@ func (wsh *WSHandler) handleJoinRoom(conn *Connection, data interface{}) {
 		return
 	}
 
-	// プレイヤーをルームに追加
-	player, err := wsh.roomService.AddPlayer(joinData.RoomID, joinData.PlayerName)
+	// プレイヤーはトークン発行時（POST /api/rooms/join）に登録済み。トークンのルーム以外には参加できない
+	if !wsh.authorize(conn, joinData.RoomID) {
+		return
+	}
+	if conn.Role == RoleSpectator {
+		// 観戦者は接続時に参加済み（プレイヤー一覧には載らない）
+		wsh.sendSuccess(conn, "Successfully joined room as spectator", map[string]interface{}{
+			"roomId": joinData.RoomID,
+			"role":   RoleSpectator,
+			"seq":    wsh.hub.LatestSeq(joinData.RoomID),
+		})
+		return
+	}
+	player, err := wsh.roomService.GetRoomPlayer(joinData.RoomID, conn.PlayerID)
 	if err != nil {
-		log.Printf("Error adding player to room: %v", err)
-		wsh.sendError(conn, "Room not found or player name already exists")
+		log.Printf("Error getting player: %v", err)
+		wsh.sendError(conn, "Room not found or player has been removed")
 		return
 	}
 
-	// 接続情報を更新
-	conn.PlayerID = player.ID
-	conn.RoomID = joinData.RoomID
-
 	// 成功メッセージを送信
 	wsh.sendSuccess(conn, "Successfully joined room", map[string]interface{}{
-		"playerId": player.ID,
-		"roomId":   joinData.RoomID,
+		"playerId":   player.ID,
+		"playerName": player.Name,
+		"roomId":     joinData.RoomID,
+		"seq":        wsh.hub.LatestSeq(joinData.RoomID),
 	})
 
 	// ルーム状態を更新して全プレイヤーに送信
@@ -128,6 +323,8 @@ func (wsh *WSHandler) handleJoinRoom(conn *Connection, data interface{}) {
 }
 
 func (wsh *WSHandler) handleBuzzIn(conn *Connection, data interface{}) {
+	receivedAt := time.Now()
+
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		log.Printf("Error marshaling buzz in data: %v", err)
@@ -140,50 +337,157 @@ func (wsh *WSHandler) handleBuzzIn(conn *Connection, data interface{}) {
 		return
 	}
 
-	// 回答キューに追加
-	err = wsh.buzzQueueService.AddToQueue(buzzData.RoomID, conn.PlayerID)
+	if !wsh.authorizePlayer(conn, buzzData.RoomID) {
+		return
+	}
+
+	// 早押しの処理中はルームとプレイヤーを1度だけ取得して使い回す
+	room, err := wsh.roomService.GetRoom(buzzData.RoomID)
 	if err != nil {
-		log.Printf("Error adding to queue: %v", err)
-		wsh.sendError(conn, "Failed to add to buzz queue")
+		log.Printf("Error getting room: %v", err)
+		wsh.sendError(conn, "Room not found")
+		return
+	}
+	if rejection := wsh.buzzRejection(room, conn.PlayerID); rejection != nil {
+		wsh.sendEvent(conn, "buzz-rejected", rejection)
 		return
 	}
 
-	// キューを取得して全プレイヤーに送信
-	queue, err := wsh.buzzQueueService.GetQueue(buzzData.RoomID)
+	// 回答キューに追加（接続の遅延とクライアントの押下時刻で補正）
+	press := services.BuzzPress{
+		ReceivedAt:      receivedAt,
+		RTT:             conn.RTT(),
+		ClientPressedAt: buzzData.PressedAt,
+		ClientSentAt:    buzzData.SentAt,
+	}
+	if room.Settings.Teams.Enabled && room.Settings.Teams.OneBuzzerPerTeam {
+		for _, player := range room.Players {
+			if player.ID == conn.PlayerID {
+				press.TeamID = player.TeamID
+				break
+			}
+		}
+	}
+	err = wsh.buzzService.AddToQueue(buzzData.RoomID, conn.PlayerID, press)
 	if err != nil {
-		log.Printf("Error getting queue: %v", err)
+		log.Printf("Error adding to queue: %v", err)
+		rejection := &models.BuzzRejectedData{
+			Reason:  models.BuzzRejectInternalError,
+			Message: "Failed to add to buzz queue",
+		}
+		switch {
+		case errors.Is(err, services.ErrBuzzNotOpen):
+			rejection.Reason, rejection.Message = models.BuzzRejectNotAccepting, "Buzzing is not open"
+		case errors.Is(err, services.ErrAlreadyInQueue):
+			rejection.Reason, rejection.Message = models.BuzzRejectAlreadyInQueue, "Already in the buzz queue"
+		case errors.Is(err, services.ErrTeammateInQueue):
+			rejection.Reason, rejection.Message = models.BuzzRejectTeammateInQueue, "A teammate is already in the buzz queue"
+		}
+		wsh.sendEvent(conn, "buzz-rejected", rejection)
 		return
 	}
 
-	// プレイヤー情報を取得
-	players, err := wsh.roomService.GetRoomPlayers(buzzData.RoomID)
+	// キュー更新を全プレイヤーに送信
+	wsh.sendQueueUpdate(buzzData.RoomID, room.Players)
+}
+
+// handleSetPresence クライアントからの離席・復帰の申告（タブが非表示になった場合など）
+func (wsh *WSHandler) handleSetPresence(conn *Connection, data interface{}) {
+	jsonData, err := json.Marshal(data)
 	if err != nil {
-		log.Printf("Error getting players: %v", err)
+		log.Printf("Error marshaling set presence data: %v", err)
+		return
+	}
+
+	var presenceData models.SetPresenceData
+	if err := json.Unmarshal(jsonData, &presenceData); err != nil {
+		log.Printf("Error unmarshaling set presence data: %v", err)
+		return
+	}
+
+	if presenceData.Status != services.PresenceOnline && presenceData.Status != services.PresenceAway {
+		wsh.sendError(conn, "Invalid presence status")
+		return
+	}
+	if !wsh.authorizePlayer(conn, presenceData.RoomID) {
 		return
 	}
 
-	// キューにプレイヤー情報を追加
-	var queueWithPlayers []map[string]interface{}
+	away := presenceData.Status == services.PresenceAway
+	if status, changed := wsh.presence.SetAway(conn.RoomID, conn.PlayerID, away); changed {
+		wsh.broadcastPresence(conn.RoomID, conn.PlayerID, status)
+	}
+}
+
+// broadcastPresence 在席状態の変化を全プレイヤーに送信
+func (wsh *WSHandler) broadcastPresence(roomID, playerID, status string) {
+	wsh.hub.SendToRoom(roomID, models.WSMessage{
+		Event: "presence-updated",
+		Data: models.PresenceUpdatedData{
+			PlayerID: playerID,
+			Status:   status,
+		},
+	})
+}
+
+// queueWithPlayers 回答キューにプレイヤー名を付けて取得
+func (wsh *WSHandler) queueWithPlayers(roomID string) ([]models.QueueEntry, error) {
+	players, err := wsh.roomService.GetRoomPlayers(roomID)
+	if err != nil {
+		return nil, err
+	}
+	return wsh.queueEntries(roomID, players)
+}
+
+// queueEntries 取得済みのプレイヤー一覧を使って回答キューにプレイヤー名を付ける
+func (wsh *WSHandler) queueEntries(roomID string, players []models.Player) ([]models.QueueEntry, error) {
+	queue, err := wsh.buzzService.GetQueue(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []models.QueueEntry{}
 	for _, buzz := range queue {
 		for _, player := range players {
 			if player.ID == buzz.PlayerID {
-				queueWithPlayers = append(queueWithPlayers, map[string]interface{}{
-					"player_id": buzz.PlayerID,
-					"name":      player.Name,
-					"buzzed_at": buzz.BuzzedAt,
+				entries = append(entries, models.QueueEntry{
+					PlayerID:  buzz.PlayerID,
+					Name:      player.Name,
+					BuzzedAt:  buzz.BuzzedAt,
+					PressedAt: buzz.PressedAt,
 				})
 				break
 			}
 		}
 	}
+	return entries, nil
+}
 
-	// キュー更新を全プレイヤーに送信
-	wsh.hub.SendToRoom(buzzData.RoomID, models.WSMessage{
+// broadcastQueueUpdate 回答キューを全プレイヤーに送信
+func (wsh *WSHandler) broadcastQueueUpdate(roomID string) {
+	players, err := wsh.roomService.GetRoomPlayers(roomID)
+	if err != nil {
+		log.Printf("Error getting room players: %v", err)
+		return
+	}
+	wsh.sendQueueUpdate(roomID, players)
+}
+
+// sendQueueUpdate 取得済みのプレイヤー一覧を使って回答キューを全プレイヤーに送信
+func (wsh *WSHandler) sendQueueUpdate(roomID string, players []models.Player) {
+	queue, err := wsh.queueEntries(roomID, players)
+	if err != nil {
+		log.Printf("Error getting queue: %v", err)
+		return
+	}
+
+	wsh.hub.SendToRoom(roomID, models.WSMessage{
 		Event: "queue-updated",
 		Data: map[string]interface{}{
-			"queue": queueWithPlayers,
+			"queue": queue,
 		},
 	})
+	wsh.broadcastDisplayState(roomID)
 }
 
 func (wsh *WSHandler) handleSubmitAnswer(conn *Connection, data interface{}) {
@@ -199,10 +503,25 @@ func (wsh *WSHandler) handleSubmitAnswer(conn *Connection, data interface{}) {
 		return
 	}
 
+	if !wsh.authorizePlayer(conn, answerData.RoomID) {
+		return
+	}
+	room, err := wsh.roomService.GetRoom(answerData.RoomID)
+	if err != nil {
+		log.Printf("Error getting room: %v", err)
+		wsh.sendError(conn, "Room not found")
+		return
+	}
+	if rejection := wsh.buzzRejection(room, conn.PlayerID); rejection != nil {
+		wsh.sendError(conn, rejection.Message)
+		return
+	}
+
 	// アクティブなゲームセッションを取得
 	session, err := wsh.gameService.GetActiveGameSession(answerData.RoomID)
 	if err != nil {
 		log.Printf("Error getting active game session: %v", err)
+		wsh.sendError(conn, "No active question")
 		return
 	}
 
@@ -216,55 +535,267 @@ func (wsh *WSHandler) handleSubmitAnswer(conn *Connection, data interface{}) {
 		}
 	}
 
-	// 回答の正誤判定
+	// 回答の正誤判定（表記ゆれを吸収して照合）
 	correct := false
 	correctAnswer := ""
+	var match services.AnswerMatch
 	if question != nil {
-		correct = answerData.Answer == question.Answer
+		accepted := append([]string{question.Answer}, question.Aliases...)
+		match = services.MatchAcceptedAnswers(answerData.Answer, accepted, question.AnswerTolerance)
+		correct = match.Matched
 		correctAnswer = question.Answer
 	}
 
-	points := 0
-	if correct {
-		// スコア計算（回答時間を考慮）
-		timeToAnswer := time.Since(session.StartedAt)
-		points = wsh.gameService.CalculateScore(question.Difficulty, timeToAnswer)
-
-		// スコアを更新
-		players, err := wsh.roomService.GetRoomPlayers(answerData.RoomID)
-		if err == nil {
-			for _, player := range players {
-				if player.ID == conn.PlayerID {
-					newScore := player.Score + points
-					wsh.roomService.UpdatePlayerScore(player.ID, newScore)
-					break
-				}
-			}
+	// 早い者勝ちのモードでは、回答できるのは回答キューの先頭のプレイヤーのみ（判定中は他の判定を受け付けない）
+	// 回答時間は早押しした時刻（全員正解モードでは回答を受信した時刻）までで測る
+	rules := room.Settings.Scoring
+	allCorrect := services.AllCorrectMode(rules)
+	answeredAt := time.Now()
+	if allCorrect {
+		if err := wsh.gameService.CheckAcceptingAnswers(session); err != nil {
+			wsh.sendSessionError(conn, err)
+			return
+		}
+	} else {
+		pressedAt, ok := wsh.beginAnswer(conn, answerData.RoomID, session, conn.PlayerID)
+		if !ok {
+			return
 		}
+		answeredAt = pressedAt
 	}
 
-	// ゲームセッションを終了
-	wsh.gameService.EndQuestion(session.ID, correct)
+	if !allCorrect {
+		// 正解なら問題を終了し、誤答なら早押しを制限してキューの次のプレイヤーに回答権を移す
+		// 判定中に時間切れ・キックで回答権が取り消されていれば、得点は反映しない
+		var lockout *models.LockoutSettings
+		if !correct {
+			lockout = &room.Settings.Lockout
+		}
+		if !wsh.finishAnswer(answerData.RoomID, session, conn.PlayerID, correct, lockout) {
+			wsh.sendError(conn, answerTurnEndedMessage)
+			return
+		}
+		if correct {
+			wsh.questionTimer.Cancel(answerData.RoomID)
+		}
+	} else if !correct {
+		if err := wsh.buzzService.LockOut(answerData.RoomID, conn.PlayerID, room.Settings.Lockout); err != nil {
+			log.Printf("Error locking out player: %v", err)
+		}
+	}
 
-	// 早押し状態をリセット
-	wsh.buzzManager.ResetBuzz(answerData.RoomID)
+	// ルームの得点ルールで加点・減点
+	score := wsh.applyScore(answerData.RoomID, conn.PlayerID, room.Settings, correct, answerTime(session, answeredAt), question)
 
-	// 結果を送信
 	result := models.QuestionResultData{
-		Correct:       correct,
-		CorrectAnswer: correctAnswer,
-		Points:        points,
+		Correct:         correct,
+		CorrectAnswer:   correctAnswer,
+		Points:          score.Points,
+		PlayerID:        conn.PlayerID,
+		SubmittedAnswer: answerData.Answer,
+		Confidence:      match.Confidence,
+		Streak:          score.Streak,
 	}
 
-	wsh.hub.SendToRoom(answerData.RoomID, models.WSMessage{
-		Event: "question-result",
-		Data:  result,
-	})
+	// 全員正解モードでは問題を続行し、結果は本人の全接続（複数のタブ・端末）にだけ返す（正解は時間切れで公開）
+	if allCorrect {
+		if !correct {
+			result.CorrectAnswer = ""
+		}
+		wsh.hub.SendToPlayer(answerData.RoomID, conn.PlayerID, models.WSMessage{
+			Event: "question-result",
+			Data:  result,
+		})
+		wsh.broadcastRoomUpdate(answerData.RoomID)
+		return
+	}
+
+	// 結果を送信（誤答で問題が続く場合、正解は管理者にのみ送る）
+	message := models.WSMessage{Event: "question-result", Data: result}
+	if correct {
+		wsh.hub.SendToRoom(answerData.RoomID, message)
+		wsh.revealOnDisplays(answerData.RoomID, question, conn.PlayerID)
+	} else {
+		redacted := result
+		redacted.CorrectAnswer = ""
+		wsh.hub.SendToRoomRedacted(answerData.RoomID, message, models.WSMessage{Event: "question-result", Data: redacted})
+	}
+
+	// 正解の場合は次の問題へ進む
+	if correct && wsh.advanceMatch(answerData.RoomID) {
+		return
+	}
 
 	// ルーム状態を更新
+	if !correct {
+		wsh.broadcastQueueUpdate(answerData.RoomID)
+	}
 	wsh.broadcastRoomUpdate(answerData.RoomID)
 }
 
+// beginAnswer キューの先頭のプレイヤーの回答の判定を始め（早押しの状態とゲームセッションの両方を判定中にする）、
+// そのプレイヤーの補正後の押下時刻を返す。判定を始められなければ理由を conn に送って false を返す
+func (wsh *WSHandler) beginAnswer(conn *Connection, roomID string, session *models.GameSession, playerID string) (time.Time, bool) {
+	pressedAt, err := wsh.buzzService.BeginJudging(roomID, playerID)
+	if err != nil {
+		wsh.sendError(conn, judgingErrorMessage(err))
+		return time.Time{}, false
+	}
+
+	if err := wsh.gameService.BeginAnswer(session, playerID); err != nil {
+		// 問題が既に終了しているなど。早押しの判定中の状態を戻す
+		log.Printf("Error beginning answer: %v", err)
+		if err := wsh.buzzService.FinishJudging(roomID, playerID, false); err != nil {
+			log.Printf("Error finishing judging: %v", err)
+		}
+		wsh.sendSessionError(conn, err)
+		return time.Time{}, false
+	}
+	return pressedAt, true
+}
+
+// answerTurnEndedMessage 判定を終える前に時間切れ・キックなどで回答権がなくなっていた
+const answerTurnEndedMessage = "The answer turn ended before the answer was judged (time up or the player was removed)"
+
+// finishAnswer 回答の判定を終える。endQuestion なら正解者として問題を終了し、そうでなければ次のプレイヤーに移る
+// lockout を指定すると（誤答）、次のプレイヤーに移るのと同時に回答したプレイヤーの早押しを制限する
+// 判定中に時間切れ・キックなどで回答権が取り消されていた場合は false（得点・結果は反映しない）
+func (wsh *WSHandler) finishAnswer(roomID string, session *models.GameSession, playerID string, endQuestion bool, lockout *models.LockoutSettings) bool {
+	var err error
+	if lockout != nil {
+		err = wsh.buzzService.FinishWrongAnswer(roomID, playerID, *lockout)
+	} else {
+		err = wsh.buzzService.FinishJudging(roomID, playerID, endQuestion)
+	}
+	if err != nil {
+		log.Printf("Error finishing judging: %v", err)
+		// 回答権を取り消した側がゲームセッションを戻していなければ、回答の受付に戻す
+		if err := wsh.gameService.FinishAnswer(session, playerID, false); err != nil && !errors.Is(err, services.ErrSessionStatusChanged) {
+			log.Printf("Error finishing answer: %v", err)
+		}
+		return false
+	}
+	if err := wsh.gameService.FinishAnswer(session, playerID, endQuestion); err != nil {
+		log.Printf("Error finishing answer: %v", err)
+	}
+	return true
+}
+
+// judgingErrorMessage 回答の判定を始められなかった理由
+func judgingErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, services.ErrNotAnswerer):
+		return "Player not in queue or not next in line"
+	case errors.Is(err, services.ErrInvalidBuzzTransition):
+		return "An answer is already being judged or the question is closed"
+	default:
+		return "Cannot judge the answer now"
+	}
+}
+
+// broadcastBuzzState 早押しの状態の変化を全プレイヤーに送信
+func (wsh *WSHandler) broadcastBuzzState(roomID string, change models.BuzzStateChangedData) {
+	wsh.hub.SendToRoom(roomID, models.WSMessage{
+		Event: "buzz-state-changed",
+		Data:  change,
+	})
+}
+
+// roomSettings ルームのゲーム設定を取得（取得できない場合はデフォルト）
+func (wsh *WSHandler) roomSettings(roomID string) models.RoomSettings {
+	room, err := wsh.roomService.GetRoom(roomID)
+	if err != nil {
+		log.Printf("Error getting room: %v", err)
+		return models.RoomSettings{}
+	}
+	return room.Settings
+}
+
+// buzzRejection プレイヤーが早押し・回答できない理由を返す（できる場合は nil）
+func (wsh *WSHandler) buzzRejection(room *models.Room, playerID string) *models.BuzzRejectedData {
+	roomID := room.ID
+
+	for _, player := range room.Players {
+		if player.ID == playerID && player.Muted {
+			return &models.BuzzRejectedData{
+				Reason:  models.BuzzRejectMuted,
+				Message: "Muted by the room admin",
+			}
+		}
+	}
+
+	if status, err := wsh.buzzService.Status(roomID); err != nil || !status.AcceptsBuzz() {
+		return &models.BuzzRejectedData{
+			Reason:  models.BuzzRejectNotAccepting,
+			Message: "Buzzing is not open",
+		}
+	}
+
+	if lockout, locked := wsh.buzzService.CheckLockout(roomID, playerID); locked {
+		rejection := &models.BuzzRejectedData{
+			Reason:  models.BuzzRejectLockedOut,
+			Message: "Locked out after a wrong answer",
+		}
+		if lockout.RetryAfter > 0 {
+			rejection.RetryAfter = secondsCeil(lockout.RetryAfter)
+		} else {
+			rejection.QuestionsRemaining = lockout.QuestionsRemaining
+		}
+		return rejection
+	}
+
+	err := wsh.scoring.CheckCanAnswer(roomID, playerID, room.Settings.Scoring)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, services.ErrLockedOut):
+		return &models.BuzzRejectedData{
+			Reason:  models.BuzzRejectTooManyMisses,
+			Message: "Locked out after too many wrong answers",
+		}
+	case errors.Is(err, services.ErrAlreadyAnswered):
+		return &models.BuzzRejectedData{
+			Reason:  models.BuzzRejectAlreadyAnswered,
+			Message: "Already answered this question",
+		}
+	default:
+		return &models.BuzzRejectedData{
+			Reason:  models.BuzzRejectInternalError,
+			Message: "Cannot answer now",
+		}
+	}
+}
+
+// applyScore 回答の正誤を得点ルールに従ってスコアに反映する（判定を終えた後に呼ぶ。誤答による早押し制限は呼び出し側で行う）
+// timeToAnswer は出題から回答（早押し）までの時間で、回答時間ボーナスに使う
+func (wsh *WSHandler) applyScore(roomID, playerID string, settings models.RoomSettings, correct bool, timeToAnswer time.Duration, question *models.Question) services.ScoreResult {
+	var score services.ScoreResult
+	if correct {
+		difficulty := ""
+		if question != nil {
+			difficulty = question.Difficulty
+		}
+		score = wsh.scoring.RecordCorrect(roomID, playerID, settings.Scoring, difficulty, timeToAnswer)
+	} else {
+		score = wsh.scoring.RecordWrong(roomID, playerID, settings.Scoring)
+	}
+
+	if score.Points != 0 {
+		if _, err := wsh.roomService.AddPlayerScore(roomID, playerID, score.Points); err != nil {
+			log.Printf("Error updating player score: %v", err)
+		}
+	}
+	return score
+}
+
+// answerTime 出題から at（早押しの押下・回答の受信）までの時間
+func answerTime(session *models.GameSession, at time.Time) time.Duration {
+	if elapsed := at.Sub(session.StartedAt); elapsed > 0 {
+		return elapsed
+	}
+	return 0
+}
+
 func (wsh *WSHandler) handleStartGame(conn *Connection, data interface{}) {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
@@ -278,6 +809,39 @@ func (wsh *WSHandler) handleStartGame(conn *Connection, data interface{}) {
 		return
 	}
 
+	// 管理者権限チェック
+	if !wsh.authorizeAdmin(conn, startData.RoomID) {
+		return
+	}
+
+	// 出題順を決める（条件が不正なら進行中のマッチはそのまま）
+	questionIDs, err := wsh.gameService.PlanMatchQuestions(services.MatchQuestionSpec{
+		Count:         startData.QuestionCount,
+		PackID:        startData.PackID,
+		Categories:    startData.Categories,
+		DifficultyMix: startData.DifficultyMix,
+	})
+	if err != nil {
+		log.Printf("Error planning match questions: %v", err)
+		wsh.sendError(conn, matchSpecErrorMessage(err))
+		return
+	}
+
+	// 進行中のマッチがあれば終了させる
+	if current, err := wsh.gameService.GetActiveMatch(startData.RoomID); err == nil {
+		if err := wsh.gameService.FinishMatch(current.ID); err != nil {
+			log.Printf("Error finishing previous match: %v", err)
+		}
+	}
+
+	// マッチを作成
+	match, err := wsh.gameService.CreateMatch(startData.RoomID, questionIDs)
+	if err != nil {
+		log.Printf("Error creating match: %v", err)
+		wsh.sendError(conn, "Failed to start game")
+		return
+	}
+
 	// ルームの状態を更新
 	err = wsh.roomService.UpdateRoomStatus(startData.RoomID, "playing")
 	if err != nil {
@@ -285,102 +849,414 @@ func (wsh *WSHandler) handleStartGame(conn *Connection, data interface{}) {
 		return
 	}
 
-	// ゲームセッションを作成
-	session, err := wsh.gameService.CreateGameSession(startData.RoomID)
+	// 連続正解数・誤答数と早押し制限をリセットして1問目を出題
+	wsh.scoring.StartMatch(startData.RoomID)
+	if err := wsh.buzzService.ResetRoom(startData.RoomID); err != nil {
+		log.Printf("Error resetting buzz state: %v", err)
+	}
+	wsh.startNextQuestion(startData.RoomID, match.ID)
+}
+
+// matchSpecErrorMessage 出題条件のエラーをクライアント向けのメッセージに変換
+func matchSpecErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, services.ErrPackNotFound):
+		return "Question pack not found"
+	case errors.Is(err, services.ErrNoMatchingQuestions):
+		return "No questions match the selected pack and filters"
+	case errors.Is(err, services.ErrInvalidMatchSpec):
+		return "Invalid question settings"
+	default:
+		return "Failed to start game"
+	}
+}
+
+// handleNextQuestion 次の問題へ進む（管理者のみ）
+func (wsh *WSHandler) handleNextQuestion(conn *Connection, data interface{}) {
+	jsonData, err := json.Marshal(data)
 	if err != nil {
-		log.Printf("Error creating game session: %v", err)
+		log.Printf("Error marshaling next question data: %v", err)
+		return
+	}
+
+	var nextData models.NextQuestionData
+	if err := json.Unmarshal(jsonData, &nextData); err != nil {
+		log.Printf("Error unmarshaling next question data: %v", err)
+		return
+	}
+
+	// 管理者権限チェック
+	if !wsh.authorizeAdmin(conn, nextData.RoomID) {
+		return
+	}
+
+	if !wsh.advanceMatch(nextData.RoomID) {
+		wsh.sendError(conn, "No active match")
+	}
+}
+
+// advanceMatch 進行中のマッチを次の問題へ進める。マッチがなければfalseを返す
+func (wsh *WSHandler) advanceMatch(roomID string) bool {
+	match, err := wsh.gameService.GetActiveMatch(roomID)
+	if err != nil {
+		return false
+	}
+
+	wsh.startNextQuestion(roomID, match.ID)
+	return true
+}
+
+// startNextQuestion マッチの次の問題を出題する（残りがなければマッチを終了）
+func (wsh *WSHandler) startNextQuestion(roomID, matchID string) {
+	// 回答中の問題があれば終了
+	if session, err := wsh.gameService.GetActiveGameSession(roomID); err == nil {
+		if err := wsh.gameService.EndQuestion(session); err != nil {
+			log.Printf("Error ending question: %v", err)
+		}
+	}
+	if err := wsh.buzzService.CloseQuestion(roomID); err != nil {
+		log.Printf("Error closing question: %v", err)
+	}
+
+	number, err := wsh.gameService.AdvanceMatch(matchID)
+	if err != nil {
+		if !errors.Is(err, services.ErrNoRemainingQuestions) {
+			log.Printf("Error advancing match: %v", err)
+		}
+		wsh.finishMatch(roomID, matchID)
 		return
 	}
 
-	// ランダムな問題を取得
-	question, err := wsh.questionService.GetRandomQuestion("", "")
+	// マッチ開始時に決めた出題順で問題を取得
+	questionID, err := wsh.gameService.GetMatchQuestionID(matchID, number)
 	if err != nil {
-		log.Printf("Error getting random question: %v", err)
+		log.Printf("Error getting match question: %v", err)
+		wsh.finishMatch(roomID, matchID)
+		return
+	}
+	question, err := wsh.questionService.GetQuestion(questionID)
+	if err != nil {
+		log.Printf("Error getting question: %v", err)
+		wsh.finishMatch(roomID, matchID)
+		return
+	}
+
+	// ゲームセッションを作成（マッチは既に次の問題に進んでいるため、失敗したらマッチを終了する）
+	session, err := wsh.gameService.CreateGameSession(roomID, matchID, number)
+	if err != nil {
+		log.Printf("Error creating game session: %v", err)
+		wsh.finishMatch(roomID, matchID)
 		return
 	}
 
 	// 問題を開始
-	err = wsh.gameService.StartQuestion(session.ID, question.ID)
+	err = wsh.gameService.StartQuestion(session, question)
 	if err != nil {
 		log.Printf("Error starting question: %v", err)
+		// 出題できなかったセッションが回答中のまま残らないように終了する
+		if err := wsh.gameService.EndQuestion(session); err != nil {
+			log.Printf("Error ending question: %v", err)
+		}
+		wsh.finishMatch(roomID, matchID)
 		return
 	}
 
-	// 早押し状態を設定
-	wsh.buzzManager.SetBuzzState(startData.RoomID, true, question.ID)
+	// 早押しの受付を開始（問題数による早押し制限を1問分進める）
+	if err := wsh.buzzService.OpenQuestion(roomID, question.ID); err != nil {
+		log.Printf("Error opening question: %v", err)
+	}
+	wsh.scoring.StartQuestion(roomID)
+
+	// 制限時間のタイマーを開始
+	wsh.startQuestionTimer(roomID, session.ID, question)
 
 	// ルーム状態を更新
-	wsh.broadcastRoomUpdate(startData.RoomID)
+	wsh.broadcastRoomUpdate(roomID)
+	wsh.sendDisplayCue(roomID, models.DisplayCueData{
+		Cue:        models.DisplayCueQuestion,
+		QuestionID: question.ID,
+	})
 }
 
-func (wsh *WSHandler) broadcastRoomUpdate(roomID string) {
-	// ルーム情報を取得
+// startQuestionTimer 問題の制限時間タイマーを開始
+func (wsh *WSHandler) startQuestionTimer(roomID, sessionID string, question *models.Question) {
 	room, err := wsh.roomService.GetRoom(roomID)
 	if err != nil {
 		log.Printf("Error getting room: %v", err)
 		return
 	}
 
-	// 早押し状態を取得
-	buzzState, exists := wsh.buzzManager.GetBuzzState(roomID)
-	canBuzz := exists && buzzState.CanBuzz && buzzState.BuzzedBy == ""
+	limit := wsh.questionTimer.TimeLimit(room.Settings.Timer, question.Difficulty)
+	if limit <= 0 {
+		wsh.questionTimer.Cancel(roomID)
+		return
+	}
+
+	wsh.questionTimer.Start(roomID, limit,
+		func(remaining, total time.Duration) {
+			wsh.hub.SendToRoomUnsequenced(roomID, models.WSMessage{
+				Event: "timer-tick",
+				Data: models.TimerTickData{
+					QuestionID: question.ID,
+					Remaining:  secondsCeil(remaining),
+					Total:      secondsCeil(total),
+				},
+			})
+		},
+		func() {
+			wsh.handleTimeUp(roomID, sessionID, question)
+		},
+	)
+}
+
+// handleTimeUp 制限時間切れ：早押しを締め切り、正解を公開する
+func (wsh *WSHandler) handleTimeUp(roomID, sessionID string, question *models.Question) {
+	// 既に次の問題へ進んでいる場合は何もしない
+	session, err := wsh.gameService.GetActiveGameSession(roomID)
+	if err != nil || session.ID != sessionID {
+		return
+	}
+
+	// 判定と競合して既に正解で終了していれば（状態が変わっていれば）何もしない
+	if err := wsh.gameService.EndQuestion(session); err != nil {
+		log.Printf("Error ending question: %v", err)
+		return
+	}
+	if err := wsh.buzzService.CloseQuestion(roomID); err != nil {
+		log.Printf("Error closing question: %v", err)
+	}
+
+	wsh.hub.SendToRoom(roomID, models.WSMessage{
+		Event: "time-up",
+		Data: models.TimeUpData{
+			QuestionID:    question.ID,
+			CorrectAnswer: question.Answer,
+		},
+	})
+	wsh.revealOnDisplays(roomID, question, "")
+
+	// ルーム状態を更新
+	wsh.broadcastRoomUpdate(roomID)
+}
+
+// secondsCeil 残り時間を秒単位に切り上げる
+func secondsCeil(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// finishMatch マッチを終了して結果を全プレイヤーに送信
+func (wsh *WSHandler) finishMatch(roomID, matchID string) {
+	wsh.questionTimer.Cancel(roomID)
+	if err := wsh.gameService.FinishMatch(matchID); err != nil {
+		log.Printf("Error finishing match: %v", err)
+		return
+	}
+
+	if err := wsh.buzzService.ResetRoom(roomID); err != nil {
+		log.Printf("Error resetting buzz state: %v", err)
+	}
+	wsh.scoring.RemoveRoom(roomID)
+	if err := wsh.roomService.UpdateRoomStatus(roomID, "finished"); err != nil {
+		log.Printf("Error updating room status: %v", err)
+	}
+
+	summary, err := wsh.matchSummary(roomID, matchID)
+	if err != nil {
+		log.Printf("Error getting match summary: %v", err)
+		return
+	}
+
+	wsh.hub.SendToRoom(roomID, models.WSMessage{
+		Event: "match-ended",
+		Data:  summary,
+	})
+	wsh.sendLeaderboardCue(roomID, true)
+
+	// ルーム状態を更新
+	wsh.broadcastRoomUpdate(roomID)
+}
+
+// matchSummary マッチ結果にランキングを付与して取得
+func (wsh *WSHandler) matchSummary(roomID, matchID string) (*models.MatchSummary, error) {
+	summary, err := wsh.gameService.GetMatchSummary(matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	ranking, err := wsh.roomService.GetRoomRanking(roomID)
+	if err != nil {
+		return nil, err
+	}
+	summary.Ranking = ranking
+
+	if room, err := wsh.roomService.GetRoom(roomID); err == nil && room.Settings.Teams.Enabled {
+		teamRanking, err := wsh.roomService.GetTeamRanking(roomID)
+		if err != nil {
+			return nil, err
+		}
+		summary.TeamRanking = teamRanking
+	}
+
+	return summary, nil
+}
+
+// broadcastRoomUpdate ルームの状態を送信（出題中の問題の正解は管理者にのみ送る）
+func (wsh *WSHandler) broadcastRoomUpdate(roomID string) {
+	updateData, err := wsh.roomState(roomID)
+	if err != nil {
+		log.Printf("Error getting room: %v", err)
+		return
+	}
+
+	wsh.hub.SendToRoomRedacted(roomID,
+		models.WSMessage{Event: "room-updated", Data: updateData},
+		models.WSMessage{Event: "room-updated", Data: updateData.Redacted()},
+	)
+	wsh.sendDisplayState(roomID, updateData)
+}
+
+// roomState room-updated で送るルームの現在の状態を組み立てる（管理者向け。プレイヤーには Redacted を送る）
+func (wsh *WSHandler) roomState(roomID string) (*models.RoomUpdatedData, error) {
+	// ルーム情報を取得
+	room, err := wsh.roomService.GetRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 早押しの状態を取得
+	buzzStatus, err := wsh.buzzService.Status(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 接続状態を付与（一度も接続していないプレイヤーは offline）
+	statuses := wsh.presence.RoomPresence(roomID)
+	for i := range room.Players {
+		room.Players[i].Presence = services.PresenceOffline
+		if status, exists := statuses[room.Players[i].ID]; exists {
+			room.Players[i].Presence = status
+		}
+	}
 
-	// ルーム更新イベントを送信
 	updateData := models.RoomUpdatedData{
 		Players:   room.Players,
 		GameState: room.Status,
-		CanBuzz:   canBuzz,
+		CanBuzz:   buzzStatus.AcceptsBuzz(),
+		BuzzState: buzzStatus.State,
 	}
 
+	// チーム戦の場合はチームのランキングを追加
+	if room.Settings.Teams.Enabled {
+		teams, err := wsh.roomService.GetTeamRanking(roomID)
+		if err != nil {
+			return nil, err
+		}
+		updateData.Teams = teams
+	}
+
+	// マッチの進行状況を追加
+	if match, err := wsh.gameService.GetActiveMatch(roomID); err == nil {
+		updateData.QuestionNumber = match.CurrentNumber
+		updateData.TotalQuestions = match.TotalQuestions
+	}
+	if remaining, running := wsh.questionTimer.Remaining(roomID); running {
+		updateData.TimeRemaining = secondsCeil(remaining)
+	}
+	updateData.Spectators = wsh.hub.SpectatorCount(roomID)
+
 	// 現在の問題がある場合は追加
-	if buzzState != nil && buzzState.QuestionID > 0 {
-		question, err := wsh.questionService.GetQuestion(buzzState.QuestionID)
+	if buzzStatus.QuestionID > 0 {
+		question, err := wsh.questionService.GetQuestion(buzzStatus.QuestionID)
 		if err == nil {
-			updateData.CurrentQuestion = question
+			public, solution := question.Public(), question.Solution()
+			updateData.CurrentQuestion = &public
+			updateData.CurrentAnswer = &solution
 		}
 	}
 
-	wsh.hub.SendToRoom(roomID, models.WSMessage{
-		Event: "room-updated",
-		Data:  updateData,
-	})
+	return &updateData, nil
+}
+
+// authorize 接続のトークンが有効なままで、対象ルームに所属しているか確認する
+func (wsh *WSHandler) authorize(conn *Connection, roomID string) bool {
+	if _, err := wsh.authService.Authenticate(conn.Token); err != nil {
+		wsh.sendError(conn, "Session expired or revoked")
+		return false
+	}
+	if roomID == "" || roomID != conn.RoomID {
+		wsh.sendError(conn, "Not a member of this room")
+		return false
+	}
+	return true
+}
+
+// authorizePlayer authorize に加えてプレイヤーの接続か確認する（観戦者は早押し・回答できない）
+func (wsh *WSHandler) authorizePlayer(conn *Connection, roomID string) bool {
+	if !wsh.authorize(conn, roomID) {
+		return false
+	}
+	if conn.Role == RoleSpectator {
+		wsh.sendError(conn, "Spectators cannot take part in the game")
+		return false
+	}
+	return true
+}
+
+// authorizeAdmin authorize に加えてルームの管理者か確認する
+func (wsh *WSHandler) authorizeAdmin(conn *Connection, roomID string) bool {
+	if !wsh.authorize(conn, roomID) {
+		return false
+	}
+
+	isAdmin, err := wsh.roomService.IsPlayerAdmin(roomID, conn.PlayerID)
+	if err != nil || !isAdmin {
+		wsh.sendError(conn, "Admin privileges required")
+		return false
+	}
+	return true
 }
 
 // sendError エラーメッセージを送信
 func (wsh *WSHandler) sendError(conn *Connection, message string) {
-	errorMsg := models.WSMessage{
-		Event: "error",
-		Data: map[string]interface{}{
-			"message": message,
-		},
-	}
+	wsh.sendEvent(conn, "error", map[string]interface{}{
+		"message": message,
+	})
+}
 
-	msgBytes, err := json.Marshal(errorMsg)
-	if err != nil {
-		log.Printf("Error marshaling error message: %v", err)
+// sendSessionError ゲームセッションの状態と合わないイベントのエラーを送信（現在の状態を添える）
+func (wsh *WSHandler) sendSessionError(conn *Connection, err error) {
+	var stateErr *services.SessionStateError
+	if !errors.As(err, &stateErr) {
+		wsh.sendError(conn, "Failed to update the question")
 		return
 	}
 
-	conn.Send <- msgBytes
+	message := "The question is not accepting this action"
+	if errors.Is(err, services.ErrSessionStatusChanged) {
+		message = "The question changed state before this action was applied"
+	}
+	wsh.sendEvent(conn, "error", map[string]interface{}{
+		"message": message,
+		"code":    "invalid_state",
+		"status":  stateErr.Status,
+		"action":  stateErr.Action,
+	})
 }
 
 // sendSuccess 成功メッセージを送信
 func (wsh *WSHandler) sendSuccess(conn *Connection, message string, data map[string]interface{}) {
-	successMsg := models.WSMessage{
-		Event: "success",
-		Data: map[string]interface{}{
-			"message": message,
-			"data":    data,
-		},
-	}
-
-	msgBytes, err := json.Marshal(successMsg)
-	if err != nil {
-		log.Printf("Error marshaling success message: %v", err)
-		return
-	}
+	wsh.sendEvent(conn, "success", map[string]interface{}{
+		"message": message,
+		"data":    data,
+	})
+}
 
-	conn.Send <- msgBytes
+// sendEvent 接続にだけイベントを送信（シーケンス番号は付かない。切断済みの接続には送らない）
+func (wsh *WSHandler) sendEvent(conn *Connection, event string, data interface{}) {
+	wsh.hub.SendToConnection(conn, models.WSMessage{
+		Event: event,
+		Data:  data,
+	})
 }
 
 // handleJudgeAnswer 管理者による回答判定
@@ -402,56 +1278,81 @@ func (wsh *WSHandler) handleJudgeAnswer(conn *Connection, data interface{}) {
 	}
 
 	// 管理者権限チェック
-	isAdmin, err := wsh.roomService.IsPlayerAdmin(judgeData.RoomID, conn.PlayerID)
-	if err != nil || !isAdmin {
-		wsh.sendError(conn, "Admin privileges required")
+	if !wsh.authorizeAdmin(conn, judgeData.RoomID) {
 		return
 	}
 
-	// 次の回答者を取得
-	nextPlayer, err := wsh.buzzQueueService.GetNextPlayer(judgeData.RoomID)
+	session, err := wsh.gameService.GetActiveGameSession(judgeData.RoomID)
 	if err != nil {
-		log.Printf("Error getting next player: %v", err)
+		log.Printf("Error getting active game session: %v", err)
+		wsh.sendError(conn, "No active question")
+		return
+	}
+	var question *models.Question
+	if session.QuestionID != nil {
+		if question, err = wsh.questionService.GetQuestion(*session.QuestionID); err != nil {
+			log.Printf("Error getting question: %v", err)
+		}
+	}
+
+	// 判定されたプレイヤーがキューの先頭にいるかチェック（同じ回答を二重に判定しない）
+	pressedAt, ok := wsh.beginAnswer(conn, judgeData.RoomID, session, judgeData.PlayerID)
+	if !ok {
 		return
 	}
+	wsh.judgeAnswer(conn, judgeData.RoomID, judgeData.PlayerID, judgeData.Correct, session, question, pressedAt)
+}
 
-	// 判定されたプレイヤーがキューにいるかチェック
-	if nextPlayer.PlayerID != judgeData.PlayerID {
-		wsh.sendError(conn, "Player not in queue or not next in line")
+// judgeAnswer 判定を始めた回答に管理者の判定を反映し、結果をルームに送信する
+// pressedAt は beginAnswer が返した回答者の押下時刻
+func (wsh *WSHandler) judgeAnswer(conn *Connection, roomID, playerID string, correct bool, session *models.GameSession, question *models.Question, pressedAt time.Time) {
+	// 正解なら問題を終了し、不正解（全員正解モードでは正解も）なら次のプレイヤーに移る（不正解なら早押しも制限）
+	// 判定中に時間切れ・キックで回答権が取り消されていれば、得点は反映しない
+	settings := wsh.roomSettings(roomID)
+	endQuestion := correct && !services.AllCorrectMode(settings.Scoring)
+	var lockout *models.LockoutSettings
+	if !correct {
+		lockout = &settings.Lockout
+	}
+	if !wsh.finishAnswer(roomID, session, playerID, endQuestion, lockout) {
+		wsh.sendError(conn, answerTurnEndedMessage)
 		return
 	}
+	if endQuestion {
+		wsh.questionTimer.Cancel(roomID)
+	}
 
-	if judgeData.Correct {
-		// 正解の場合：ポイントを付与
-		players, err := wsh.roomService.GetRoomPlayers(judgeData.RoomID)
-		if err == nil {
-			for _, player := range players {
-				if player.ID == judgeData.PlayerID {
-					newScore := player.Score + 10 // 基本ポイント
-					wsh.roomService.UpdatePlayerScore(player.ID, newScore)
-					break
-				}
-			}
-		}
+	// ルームの得点ルールで加点・減点（回答時間は判定の操作ではなく早押しの押下までで測る）
+	score := wsh.applyScore(roomID, playerID, settings, correct, answerTime(session, pressedAt), question)
 
-		// キューをクリア
-		wsh.buzzQueueService.ClearQueue(judgeData.RoomID)
-	} else {
-		// 不正解の場合：次のプレイヤーに移る
-		wsh.buzzQueueService.RemoveFromQueue(judgeData.RoomID, judgeData.PlayerID)
+	// 結果を全プレイヤーに送信（問題が終了した場合は正解を公開する）
+	result := map[string]interface{}{
+		"correct":   correct,
+		"player_id": playerID,
+		"points":    score.Points,
+		"streak":    score.Streak,
 	}
-
-	// 結果を全プレイヤーに送信
-	wsh.hub.SendToRoom(judgeData.RoomID, models.WSMessage{
+	if endQuestion && question != nil {
+		result["correct_answer"] = question.Answer
+	}
+	wsh.hub.SendToRoom(roomID, models.WSMessage{
 		Event: "judge-result",
-		Data: map[string]interface{}{
-			"correct":   judgeData.Correct,
-			"player_id": judgeData.PlayerID,
-		},
+		Data:  result,
 	})
+	if endQuestion {
+		wsh.revealOnDisplays(roomID, question, playerID)
+	}
+
+	// 正解で問題が終了した場合は次の問題へ進む
+	if endQuestion && wsh.advanceMatch(roomID) {
+		return
+	}
 
 	// ルーム状態を更新
-	wsh.broadcastRoomUpdate(judgeData.RoomID)
+	if !endQuestion {
+		wsh.broadcastQueueUpdate(roomID)
+	}
+	wsh.broadcastRoomUpdate(roomID)
 }
 
 // handleResetQueue キューリセット（管理者のみ）
@@ -471,14 +1372,12 @@ func (wsh *WSHandler) handleResetQueue(conn *Connection, data interface{}) {
 	}
 
 	// 管理者権限チェック
-	isAdmin, err := wsh.roomService.IsPlayerAdmin(resetData.RoomID, conn.PlayerID)
-	if err != nil || !isAdmin {
-		wsh.sendError(conn, "Admin privileges required")
+	if !wsh.authorizeAdmin(conn, resetData.RoomID) {
 		return
 	}
 
 	// キューをクリア
-	err = wsh.buzzQueueService.ClearQueue(resetData.RoomID)
+	err = wsh.buzzService.ClearQueue(resetData.RoomID)
 	if err != nil {
 		log.Printf("Error clearing queue: %v", err)
 		wsh.sendError(conn, "Failed to reset queue")
@@ -492,6 +1391,7 @@ func (wsh *WSHandler) handleResetQueue(conn *Connection, data interface{}) {
 			"message": "Queue has been reset",
 		},
 	})
+	wsh.broadcastDisplayState(resetData.RoomID)
 }
 
 // handleEndGame ゲーム終了（管理者のみ）
@@ -511,12 +1411,25 @@ func (wsh *WSHandler) handleEndGame(conn *Connection, data interface{}) {
 	}
 
 	// 管理者権限チェック
-	isAdmin, err := wsh.roomService.IsPlayerAdmin(endData.RoomID, conn.PlayerID)
-	if err != nil || !isAdmin {
-		wsh.sendError(conn, "Admin privileges required")
+	if !wsh.authorizeAdmin(conn, endData.RoomID) {
 		return
 	}
 
+	// 進行中のマッチを終了
+	wsh.questionTimer.Cancel(endData.RoomID)
+	var summary *models.MatchSummary
+	if match, err := wsh.gameService.GetActiveMatch(endData.RoomID); err == nil {
+		if err := wsh.gameService.FinishMatch(match.ID); err != nil {
+			log.Printf("Error finishing match: %v", err)
+		} else if summary, err = wsh.matchSummary(endData.RoomID, match.ID); err != nil {
+			log.Printf("Error getting match summary: %v", err)
+		}
+	}
+	if err := wsh.buzzService.ResetRoom(endData.RoomID); err != nil {
+		log.Printf("Error resetting buzz state: %v", err)
+	}
+	wsh.scoring.RemoveRoom(endData.RoomID)
+
 	// ルーム状態を終了に更新
 	err = wsh.roomService.UpdateRoomStatus(endData.RoomID, "finished")
 	if err != nil {
@@ -532,10 +1445,65 @@ func (wsh *WSHandler) handleEndGame(conn *Connection, data interface{}) {
 	}
 
 	// ゲーム終了とランキングを全プレイヤーに送信
+	endedData := map[string]interface{}{
+		"ranking": ranking,
+	}
+	if room, err := wsh.roomService.GetRoom(endData.RoomID); err == nil && room.Settings.Teams.Enabled {
+		if teamRanking, err := wsh.roomService.GetTeamRanking(endData.RoomID); err == nil {
+			endedData["team_ranking"] = teamRanking
+		}
+	}
+	if summary != nil {
+		endedData["summary"] = summary
+	}
 	wsh.hub.SendToRoom(endData.RoomID, models.WSMessage{
 		Event: "game-ended",
+		Data:  endedData,
+	})
+	wsh.sendLeaderboardCue(endData.RoomID, true)
+}
+
+// handleDeleteRoom ルーム削除（管理者のみ）
+func (wsh *WSHandler) handleDeleteRoom(conn *Connection, data interface{}) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Error marshaling delete room data: %v", err)
+		return
+	}
+
+	var deleteData models.DeleteRoomData
+	if err := json.Unmarshal(jsonData, &deleteData); err != nil {
+		log.Printf("Error unmarshaling delete room data: %v", err)
+		return
+	}
+
+	// 管理者権限チェック
+	if !wsh.authorizeAdmin(conn, deleteData.RoomID) {
+		return
+	}
+
+	// タイマーと早押し状態を破棄してからルームを削除
+	wsh.questionTimer.Cancel(deleteData.RoomID)
+	if err := wsh.buzzService.ResetRoom(deleteData.RoomID); err != nil {
+		log.Printf("Error resetting buzz state: %v", err)
+	}
+
+	err = wsh.roomService.DeleteRoom(deleteData.RoomID)
+	if err != nil {
+		log.Printf("Error deleting room: %v", err)
+		wsh.sendError(conn, "Failed to delete room")
+		return
+	}
+
+	// 削除を全プレイヤーに送信
+	wsh.hub.SendToRoom(deleteData.RoomID, models.WSMessage{
+		Event: "room-deleted",
 		Data: map[string]interface{}{
-			"ranking": ranking,
+			"room_id": deleteData.RoomID,
 		},
 	})
+	wsh.hub.ForgetRoom(deleteData.RoomID)
+	wsh.presence.RemoveRoom(deleteData.RoomID)
+	wsh.buzzService.ForgetRoom(deleteData.RoomID)
+	wsh.scoring.RemoveRoom(deleteData.RoomID)
 }
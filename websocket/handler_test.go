@@ -0,0 +1,141 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"quivra-backend/models"
+	"quivra-backend/repository/memory"
+	"quivra-backend/services"
+)
+
+// newTestHandler インメモリストレージで WSHandler を組み立てる（制限時間のタイマーは使わない）
+func newTestHandler(t *testing.T) *WSHandler {
+	t.Helper()
+	store := memory.NewStore()
+	hub := NewHub()
+	go hub.Run()
+
+	buzzService := services.NewBuzzService(store, 0)
+	go buzzService.Run()
+	t.Cleanup(buzzService.Close)
+
+	return NewWSHandler(
+		hub,
+		services.NewRoomService(store),
+		services.NewQuestionService(store),
+		services.NewGameService(store),
+		buzzService,
+		services.NewQuestionTimer(nil),
+		services.NewAuthService(store, []byte("test-secret"), time.Hour, ""),
+		services.NewPresenceTracker(),
+		services.NewScoringService(),
+	)
+}
+
+// startTestQuestion settings のルームに1人参加させて1問目を出題し、そのプレイヤーを回答キューに入れる
+func startTestQuestion(t *testing.T, wsh *WSHandler, settings models.RoomSettings) (*models.Room, *models.Player, *models.Question, *models.GameSession, *Connection) {
+	t.Helper()
+	room, err := wsh.roomService.CreateRoom("room", false, "host", settings)
+	if err != nil {
+		t.Fatalf("CreateRoom: %v", err)
+	}
+	player, err := wsh.roomService.AddPlayer(room.ID, "alice")
+	if err != nil {
+		t.Fatalf("AddPlayer: %v", err)
+	}
+	question, err := wsh.questionService.CreateQuestion("日本の首都は？", "東京", "地理", "easy", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateQuestion: %v", err)
+	}
+	match, err := wsh.gameService.CreateMatch(room.ID, []int{question.ID})
+	if err != nil {
+		t.Fatalf("CreateMatch: %v", err)
+	}
+	wsh.scoring.StartMatch(room.ID)
+	wsh.startNextQuestion(room.ID, match.ID)
+
+	session, err := wsh.gameService.GetActiveGameSession(room.ID)
+	if err != nil {
+		t.Fatalf("GetActiveGameSession: %v", err)
+	}
+	if err := wsh.buzzService.AddToQueue(room.ID, player.ID, services.BuzzPress{ReceivedAt: time.Now()}); err != nil {
+		t.Fatalf("AddToQueue: %v", err)
+	}
+	admin := &Connection{Send: make(chan []byte, 16), RoomID: room.ID, Role: RoleAdmin}
+	return room, player, question, session, admin
+}
+
+func TestJudgeAnswerAfterTimeUp(t *testing.T) {
+	for _, correct := range []bool{true, false} {
+		name := "wrong"
+		if correct {
+			name = "correct"
+		}
+		t.Run(name, func(t *testing.T) {
+			wsh := newTestHandler(t)
+
+			settings := models.RoomSettings{
+				Timer:   models.TimerSettings{Disabled: true},
+				Scoring: models.ScoringSettings{WrongPenalty: 5, StreakBonus: 1, LockoutAfterMisses: 1},
+				Lockout: models.LockoutSettings{Mode: "seconds", Seconds: 60},
+			}
+			room, player, question, session, admin := startTestQuestion(t, wsh, settings)
+			pressedAt, ok := wsh.beginAnswer(admin, room.ID, session, player.ID)
+			if !ok {
+				t.Fatal("beginAnswer failed")
+			}
+
+			// 判定中に制限時間が切れてから、管理者の判定が届く
+			wsh.handleTimeUp(room.ID, session.ID, question)
+			wsh.judgeAnswer(admin, room.ID, player.ID, correct, session, question, pressedAt)
+
+			// 回答権が取り消された回答は、得点・連続正解・誤答数・早押し制限のいずれにも反映しない
+			stored, err := wsh.roomService.GetRoomPlayer(room.ID, player.ID)
+			if err != nil {
+				t.Fatalf("GetRoomPlayer: %v", err)
+			}
+			if stored.Score != 0 {
+				t.Errorf("score = %d, want 0", stored.Score)
+			}
+			if lockout, locked := wsh.buzzService.CheckLockout(room.ID, player.ID); locked {
+				t.Errorf("player is locked out: %+v", lockout)
+			}
+			if err := wsh.scoring.CheckCanAnswer(room.ID, player.ID, settings.Scoring); err != nil {
+				t.Errorf("CheckCanAnswer = %v, want the miss not recorded", err)
+			}
+			if score := wsh.scoring.RecordCorrect(room.ID, player.ID, settings.Scoring, "", 0); score.Streak != 1 {
+				t.Errorf("streak after the next correct answer = %d, want 1", score.Streak)
+			}
+		})
+	}
+}
+
+func TestJudgeAnswerTimeBonusUsesPressTime(t *testing.T) {
+	wsh := newTestHandler(t)
+
+	settings := models.RoomSettings{
+		Timer: models.TimerSettings{Disabled: true},
+		Scoring: models.ScoringSettings{
+			Easy:      10,
+			TimeBonus: []models.TimeBonusStep{{Within: 5, Bonus: 3}},
+		},
+	}
+	room, player, question, session, admin := startTestQuestion(t, wsh, settings)
+	if _, ok := wsh.beginAnswer(admin, room.ID, session, player.ID); !ok {
+		t.Fatal("beginAnswer failed")
+	}
+
+	// 出題の1秒後に早押しした回答を10秒後に判定しても、回答時間ボーナスが付く
+	session.StartedAt = time.Now().Add(-10 * time.Second)
+	pressedAt := session.StartedAt.Add(time.Second)
+	wsh.judgeAnswer(admin, room.ID, player.ID, true, session, question, pressedAt)
+
+	stored, err := wsh.roomService.GetRoomPlayer(room.ID, player.ID)
+	if err != nil {
+		t.Fatalf("GetRoomPlayer: %v", err)
+	}
+	if stored.Score != 13 {
+		t.Errorf("score = %d, want 13 (10 points and the time bonus)", stored.Score)
+	}
+}
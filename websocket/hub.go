@@ -0,0 +1,391 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+
+	"quivra-backend/models"
+)
+
+type Hub struct {
+	// 登録された接続
+	connections map[*Connection]bool
+
+	// ルーム別の接続
+	rooms map[string]map[*Connection]bool
+
+	// 接続からのメッセージを登録
+	register chan *Connection
+
+	// ルーム別ブロードキャスト
+	roomBroadcast chan RoomMessage
+
+	// 特定の接続・プレイヤー・役割宛ての送信
+	direct chan directMessage
+
+	// ルーム別の送信済みイベント（再接続時の再送用）
+	events map[string]*roomEventBuffer
+
+	// 接続の保護
+	mu sync.RWMutex
+}
+
+type RoomMessage struct {
+	RoomID  string
+	Message models.WSMessage
+	// Redacted 正解を伏せたプレイヤー向けの内容（nil なら全接続に Message を送る）
+	Redacted *models.WSMessage
+}
+
+// directMessage ルームの一部の接続にのみ送るメッセージ
+// ルームのシーケンス番号は付けず、再接続時にも再送しない
+type directMessage struct {
+	connection *Connection // 特定の接続宛て（nil ならルームの接続のうち match に合うもの宛て）
+	roomID     string
+	match      func(*Connection) bool
+	message    models.WSMessage
+	disconnect bool // 送信後に接続を切断する（キック・参加禁止）
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		connections:   make(map[*Connection]bool),
+		rooms:         make(map[string]map[*Connection]bool),
+		register:      make(chan *Connection),
+		roomBroadcast: make(chan RoomMessage),
+		direct:        make(chan directMessage),
+		events:        make(map[string]*roomEventBuffer),
+	}
+}
+
+func (h *Hub) Run() {
+	for {
+		select {
+		case connection := <-h.register:
+			h.mu.Lock()
+			h.connections[connection] = true
+
+			// ルームに接続を追加
+			if connection.RoomID != "" {
+				if h.rooms[connection.RoomID] == nil {
+					h.rooms[connection.RoomID] = make(map[*Connection]bool)
+				}
+				h.rooms[connection.RoomID][connection] = true
+			}
+			h.mu.Unlock()
+
+		case roomMsg := <-h.roomBroadcast:
+			// 採番・バッファへの追加・送信を同じロック内で行い、再接続時の再送と順序が前後しないようにする
+			h.mu.Lock()
+			event, err := h.sequence(roomMsg)
+			if err != nil {
+				log.Printf("Error marshaling message: %v", err)
+				h.mu.Unlock()
+				continue
+			}
+			for connection := range h.rooms[roomMsg.RoomID] {
+				h.deliver(connection, event.messageFor(connection))
+			}
+			h.mu.Unlock()
+
+		case direct := <-h.direct:
+			message, err := json.Marshal(direct.message)
+			if err != nil {
+				log.Printf("Error marshaling message: %v", err)
+				continue
+			}
+			h.mu.Lock()
+			if direct.connection != nil {
+				// 登録解除済み（Send が閉じられた）接続には送らない
+				if h.connections[direct.connection] {
+					h.deliver(direct.connection, message)
+				}
+			} else {
+				for connection := range h.rooms[direct.roomID] {
+					if direct.match(connection) {
+						h.deliver(connection, message)
+						if direct.disconnect {
+							// Send を閉じると WritePump が送信済みのメッセージの後に close を送って切断する
+							h.remove(connection)
+						}
+					}
+				}
+			}
+			h.mu.Unlock()
+		}
+	}
+}
+
+// deliver 接続の送信バッファにメッセージを入れる。バッファが詰まっている接続は切断する（h.mu のロック取得済みであること）
+func (h *Hub) deliver(connection *Connection, message []byte) {
+	select {
+	case connection.Send <- message:
+	default:
+		h.remove(connection)
+	}
+}
+
+// remove 接続の登録を解除して Send を閉じる（登録解除済みなら何もしない。h.mu のロック取得済みであること）
+func (h *Hub) remove(connection *Connection) {
+	if _, ok := h.connections[connection]; !ok {
+		return
+	}
+	delete(h.connections, connection)
+	close(connection.Send)
+
+	// ルームから接続を削除
+	if room, exists := h.rooms[connection.RoomID]; exists {
+		delete(room, connection)
+		if len(room) == 0 {
+			delete(h.rooms, connection.RoomID)
+		}
+	}
+}
+
+// Unregister 接続の登録を解除する（戻った時点でルームの接続数に反映されている）
+func (h *Hub) Unregister(connection *Connection) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(connection)
+}
+
+// AddSpectator 観戦者の接続を登録する。ルームで観戦中の接続数が limit に達している場合は登録せず false を返す
+func (h *Hub) AddSpectator(connection *Connection, limit int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.countRole(connection.RoomID, RoleSpectator) >= limit {
+		return false
+	}
+	h.connections[connection] = true
+	if h.rooms[connection.RoomID] == nil {
+		h.rooms[connection.RoomID] = make(map[*Connection]bool)
+	}
+	h.rooms[connection.RoomID][connection] = true
+	return true
+}
+
+// SpectatorCount ルームで観戦中の接続数
+func (h *Hub) SpectatorCount(roomID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.countRole(roomID, RoleSpectator)
+}
+
+// DisplayCount ルームの表示用接続の数
+func (h *Hub) DisplayCount(roomID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.countRole(roomID, RoleDisplay)
+}
+
+// countRole ルームの接続のうち役割が role のものの数（h.mu のロック取得済みであること）
+func (h *Hub) countRole(roomID, role string) int {
+	count := 0
+	for connection := range h.rooms[roomID] {
+		if connection.Role == role {
+			count++
+		}
+	}
+	return count
+}
+
+// SendToRoom ルームの全接続にイベントを送信（シーケンス番号はHubで採番する）
+func (h *Hub) SendToRoom(roomID string, message models.WSMessage) {
+	h.roomBroadcast <- RoomMessage{
+		RoomID:  roomID,
+		Message: message,
+	}
+}
+
+// SendToRoomUnsequenced ルームの全接続にシーケンス番号を付けずにイベントを送信（再接続時にも再送しない）
+// タイマーの残り時間のように頻繁に送り、次の送信や再接続時のスナップショットで置き換わるイベントに使う
+// （再送用のバッファを埋めて他のイベントを押し出さないようにするため）
+func (h *Hub) SendToRoomUnsequenced(roomID string, message models.WSMessage) {
+	h.sendWhere(roomID, message, func(*Connection) bool {
+		return true
+	})
+}
+
+// SendToRoomRedacted ルームの管理者の接続には message を、それ以外の接続には正解を伏せた redacted を送信する
+// （どちらも同じシーケンス番号のイベントとして扱い、再接続時も接続の役割に応じた内容を再送する）
+func (h *Hub) SendToRoomRedacted(roomID string, message, redacted models.WSMessage) {
+	h.roomBroadcast <- RoomMessage{
+		RoomID:   roomID,
+		Message:  message,
+		Redacted: &redacted,
+	}
+}
+
+// SendToConnection 1つの接続にイベントを送信（エラーや再接続の応答など、送信元の接続への返信に使う）
+func (h *Hub) SendToConnection(connection *Connection, message models.WSMessage) {
+	h.direct <- directMessage{connection: connection, message: message}
+}
+
+// SendToPlayer プレイヤーの全接続（複数のタブ・端末）にイベントを送信
+func (h *Hub) SendToPlayer(roomID, playerID string, message models.WSMessage) {
+	h.sendWhere(roomID, message, func(connection *Connection) bool {
+		return connection.PlayerID == playerID
+	})
+}
+
+// SendToAdmins ルームの管理者の全接続にイベントを送信
+func (h *Hub) SendToAdmins(roomID string, message models.WSMessage) {
+	h.sendWhere(roomID, message, func(connection *Connection) bool {
+		return connection.Role == RoleAdmin
+	})
+}
+
+// SendToSpectators ルームの観戦者の全接続にイベントを送信
+func (h *Hub) SendToSpectators(roomID string, message models.WSMessage) {
+	h.sendWhere(roomID, message, func(connection *Connection) bool {
+		return connection.Role == RoleSpectator
+	})
+}
+
+// SendToDisplays ルームの表示用接続にイベントを送信
+func (h *Hub) SendToDisplays(roomID string, message models.WSMessage) {
+	h.sendWhere(roomID, message, func(connection *Connection) bool {
+		return connection.Role == RoleDisplay
+	})
+}
+
+// SendToRoomExcept ルームのうち、プレイヤーの接続以外にイベントを送信
+func (h *Hub) SendToRoomExcept(roomID, playerID string, message models.WSMessage) {
+	h.sendWhere(roomID, message, func(connection *Connection) bool {
+		return connection.PlayerID != playerID
+	})
+}
+
+// DisconnectPlayer プレイヤーの全接続に最後のイベントを送って切断する（キック・参加禁止）
+// それまでに送信したルームのイベントの後に届く
+func (h *Hub) DisconnectPlayer(roomID, playerID string, message models.WSMessage) {
+	h.direct <- directMessage{
+		roomID: roomID,
+		match: func(connection *Connection) bool {
+			return connection.PlayerID == playerID
+		},
+		message:    message,
+		disconnect: true,
+	}
+}
+
+// sendWhere ルームの接続のうち match に合うものにイベントを送信（判定と送信は Hub の goroutine で行う）
+func (h *Hub) sendWhere(roomID string, message models.WSMessage, match func(*Connection) bool) {
+	h.direct <- directMessage{roomID: roomID, match: match, message: message}
+}
+
+// sequence ルームのシーケンス番号を採番してバッファに保持する（h.mu のロック取得済みであること）
+func (h *Hub) sequence(roomMsg RoomMessage) (bufferedEvent, error) {
+	buffer, exists := h.events[roomMsg.RoomID]
+	if !exists {
+		buffer = &roomEventBuffer{}
+		h.events[roomMsg.RoomID] = buffer
+	}
+
+	event := bufferedEvent{seq: buffer.lastSeq + 1}
+	roomMsg.Message.Seq = event.seq
+	message, err := json.Marshal(roomMsg.Message)
+	if err != nil {
+		return event, err
+	}
+	event.message = message
+	if roomMsg.Redacted != nil {
+		roomMsg.Redacted.Seq = event.seq
+		if event.redacted, err = json.Marshal(roomMsg.Redacted); err != nil {
+			return event, err
+		}
+	}
+
+	buffer.lastSeq++
+	buffer.append(event)
+	return event, nil
+}
+
+// Resume 再接続した接続を登録し、lastSeq より後のルームイベントを再送する
+// バッファから再送しきれない場合は登録のみ行い false を返す（呼び出し側でスナップショットを送る）
+func (h *Hub) Resume(connection *Connection, lastSeq int64) (int, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.connections[connection] = true
+	if h.rooms[connection.RoomID] == nil {
+		h.rooms[connection.RoomID] = make(map[*Connection]bool)
+	}
+	h.rooms[connection.RoomID][connection] = true
+
+	buffer, exists := h.events[connection.RoomID]
+	if !exists {
+		return 0, lastSeq == 0
+	}
+
+	events, ok := buffer.since(lastSeq)
+	if !ok {
+		return 0, false
+	}
+	for i, event := range events {
+		select {
+		case connection.Send <- event.messageFor(connection):
+		default:
+			return i, false
+		}
+	}
+	return len(events), true
+}
+
+// LatestSeq ルームで最後に送信したイベントのシーケンス番号
+func (h *Hub) LatestSeq(roomID string) int64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if buffer, exists := h.events[roomID]; exists {
+		return buffer.lastSeq
+	}
+	return 0
+}
+
+// ForgetRoom 削除されたルームのイベントバッファを破棄
+func (h *Hub) ForgetRoom(roomID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.events, roomID)
+}
+
+// JoinRoom 接続をルームに所属させる（既に別ルームにいる場合は移動する）
+func (h *Hub) JoinRoom(connection *Connection, roomID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if connection.RoomID != "" {
+		if room, exists := h.rooms[connection.RoomID]; exists {
+			delete(room, connection)
+			if len(room) == 0 {
+				delete(h.rooms, connection.RoomID)
+			}
+		}
+	}
+
+	connection.RoomID = roomID
+	if h.rooms[roomID] == nil {
+		h.rooms[roomID] = make(map[*Connection]bool)
+	}
+	h.rooms[roomID][connection] = true
+}
+
+func (h *Hub) GetRoomConnections(roomID string) []*Connection {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var connections []*Connection
+	if room, exists := h.rooms[roomID]; exists {
+		for conn := range room {
+			connections = append(connections, conn)
+		}
+	}
+	return connections
+}
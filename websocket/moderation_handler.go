@@ -0,0 +1,227 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+
+	"quivra-backend/models"
+	"quivra-backend/services"
+)
+
+// handleKickPlayer プレイヤーをルームから外す（管理者のみ）
+func (wsh *WSHandler) handleKickPlayer(conn *Connection, data interface{}) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Error marshaling kick player data: %v", err)
+		return
+	}
+
+	var kickData models.KickPlayerData
+	if err := json.Unmarshal(jsonData, &kickData); err != nil {
+		log.Printf("Error unmarshaling kick player data: %v", err)
+		return
+	}
+
+	// 管理者権限チェック
+	if !wsh.authorizeAdmin(conn, kickData.RoomID) {
+		return
+	}
+
+	if _, err := wsh.KickPlayer(kickData.RoomID, kickData.PlayerID); err != nil {
+		log.Printf("Error kicking player: %v", err)
+		wsh.sendModerationError(conn, err, "Failed to kick player")
+	}
+}
+
+// handleBanPlayer プレイヤーまたは名前をルームへの参加禁止にする（管理者のみ）
+func (wsh *WSHandler) handleBanPlayer(conn *Connection, data interface{}) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Error marshaling ban player data: %v", err)
+		return
+	}
+
+	var banData models.BanPlayerData
+	if err := json.Unmarshal(jsonData, &banData); err != nil {
+		log.Printf("Error unmarshaling ban player data: %v", err)
+		return
+	}
+
+	// 管理者権限チェック
+	if !wsh.authorizeAdmin(conn, banData.RoomID) {
+		return
+	}
+
+	var player *models.Player
+	if banData.PlayerID != "" {
+		player, err = wsh.BanPlayer(banData.RoomID, banData.PlayerID)
+	} else {
+		player, err = wsh.BanName(banData.RoomID, banData.Name)
+	}
+	if err != nil {
+		log.Printf("Error banning player: %v", err)
+		wsh.sendModerationError(conn, err, "Failed to ban player")
+		return
+	}
+
+	// 参加していない名前を禁止した場合はルームへの通知がないため、管理者に結果を返す
+	if player == nil {
+		wsh.sendSuccess(conn, "Name banned", map[string]interface{}{
+			"name": banData.Name,
+		})
+	}
+}
+
+// handleMutePlayer プレイヤーのミュートを切り替える（管理者のみ）
+func (wsh *WSHandler) handleMutePlayer(conn *Connection, data interface{}) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Error marshaling mute player data: %v", err)
+		return
+	}
+
+	var muteData models.MutePlayerData
+	if err := json.Unmarshal(jsonData, &muteData); err != nil {
+		log.Printf("Error unmarshaling mute player data: %v", err)
+		return
+	}
+
+	// 管理者権限チェック
+	if !wsh.authorizeAdmin(conn, muteData.RoomID) {
+		return
+	}
+
+	muted := muteData.Muted == nil || *muteData.Muted
+	if _, err := wsh.MutePlayer(muteData.RoomID, muteData.PlayerID, muted); err != nil {
+		log.Printf("Error muting player: %v", err)
+		wsh.sendModerationError(conn, err, "Failed to mute player")
+	}
+}
+
+// KickPlayer プレイヤーをルームから外し、接続を切断してルームに通知する（同じ名前で参加し直せる）
+func (wsh *WSHandler) KickPlayer(roomID, playerID string) (*models.Player, error) {
+	player, err := wsh.roomService.RemovePlayer(roomID, playerID)
+	if err != nil {
+		return nil, err
+	}
+	wsh.afterRemoval(roomID, player, models.ModerationKick)
+	return player, nil
+}
+
+// BanPlayer プレイヤーの名前を参加禁止にしてルームから外し、接続を切断してルームに通知する
+func (wsh *WSHandler) BanPlayer(roomID, playerID string) (*models.Player, error) {
+	player, err := wsh.roomService.BanPlayer(roomID, playerID)
+	if err != nil {
+		return nil, err
+	}
+	wsh.afterRemoval(roomID, player, models.ModerationBan)
+	return player, nil
+}
+
+// BanName 名前を参加禁止にする。その名前のプレイヤーが参加中ならルームから外して返す
+func (wsh *WSHandler) BanName(roomID, name string) (*models.Player, error) {
+	player, err := wsh.roomService.BanName(roomID, name)
+	if err != nil {
+		return nil, err
+	}
+	if player != nil {
+		wsh.afterRemoval(roomID, player, models.ModerationBan)
+	}
+	return player, nil
+}
+
+// MutePlayer プレイヤーのミュートを切り替えてルームに通知する。ミュートした場合は回答キューからも外す
+func (wsh *WSHandler) MutePlayer(roomID, playerID string, muted bool) (*models.Player, error) {
+	player, err := wsh.roomService.SetPlayerMuted(roomID, playerID, muted)
+	if err != nil {
+		return nil, err
+	}
+
+	wsh.hub.SendToRoom(roomID, models.WSMessage{
+		Event: "player-muted",
+		Data: models.PlayerMutedData{
+			PlayerID: player.ID,
+			Muted:    muted,
+		},
+	})
+	if muted {
+		wsh.removeFromQueue(roomID, player.ID)
+	}
+	wsh.broadcastRoomUpdate(roomID)
+	return player, nil
+}
+
+// withdrawPlayer ルームから外すプレイヤーを削除する前に回答キューから外す
+// 判定中の先頭のプレイヤーなら回答権を閉じて次のプレイヤーに移し、ゲームセッションも回答の受付に戻す
+func (wsh *WSHandler) withdrawPlayer(roomID, playerID string) {
+	removed, wasJudging, err := wsh.buzzService.WithdrawPlayer(roomID, playerID)
+	if err != nil {
+		log.Printf("Error withdrawing player from queue: %v", err)
+		return
+	}
+	if wasJudging {
+		session, err := wsh.gameService.GetActiveGameSession(roomID)
+		if err != nil {
+			log.Printf("Error getting active game session: %v", err)
+		} else if err := wsh.gameService.FinishAnswer(session, playerID, false); err != nil {
+			log.Printf("Error finishing answer: %v", err)
+		}
+	}
+	if removed {
+		wsh.broadcastQueueUpdate(roomID)
+	}
+}
+
+// afterRemoval ルームから外したプレイヤーを在席状態から除き、接続を切断してルームに通知する
+// （回答キューからは削除前に withdrawPlayer で外している）
+func (wsh *WSHandler) afterRemoval(roomID string, player *models.Player, action string) {
+	message := "You have been removed from the room"
+	if action == models.ModerationBan {
+		message = "You have been banned from the room"
+	}
+	wsh.hub.DisconnectPlayer(roomID, player.ID, models.WSMessage{
+		Event: "kicked",
+		Data: models.KickedData{
+			Action:  action,
+			Message: message,
+		},
+	})
+	wsh.presence.RemovePlayer(roomID, player.ID)
+
+	wsh.hub.SendToRoom(roomID, models.WSMessage{
+		Event: "player-removed",
+		Data: models.PlayerRemovedData{
+			PlayerID: player.ID,
+			Name:     player.Name,
+			Action:   action,
+		},
+	})
+	wsh.broadcastRoomUpdate(roomID)
+}
+
+// removeFromQueue プレイヤーを回答キューから外し、外した場合はキューを送信する
+func (wsh *WSHandler) removeFromQueue(roomID, playerID string) {
+	removed, err := wsh.buzzService.RemoveFromQueue(roomID, playerID)
+	if err != nil {
+		log.Printf("Error removing player from queue: %v", err)
+		return
+	}
+	if removed {
+		wsh.broadcastQueueUpdate(roomID)
+	}
+}
+
+// sendModerationError キック・参加禁止・ミュートのエラーを送信
+func (wsh *WSHandler) sendModerationError(conn *Connection, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrPlayerNotFound):
+		wsh.sendError(conn, "Player not found")
+	case errors.Is(err, services.ErrCannotModerateAdmin):
+		wsh.sendError(conn, "Cannot moderate a room admin")
+	case errors.Is(err, services.ErrInvalidBanTarget):
+		wsh.sendError(conn, err.Error())
+	default:
+		wsh.sendError(conn, fallback)
+	}
+}
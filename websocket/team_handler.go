@@ -0,0 +1,134 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+
+	"quivra-backend/models"
+	"quivra-backend/services"
+)
+
+// handleCreateTeam チーム作成（管理者のみ）
+func (wsh *WSHandler) handleCreateTeam(conn *Connection, data interface{}) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Error marshaling create team data: %v", err)
+		return
+	}
+
+	var teamData models.CreateTeamData
+	if err := json.Unmarshal(jsonData, &teamData); err != nil {
+		log.Printf("Error unmarshaling create team data: %v", err)
+		return
+	}
+
+	// 管理者権限チェック
+	if !wsh.authorizeAdmin(conn, teamData.RoomID) {
+		return
+	}
+
+	if _, err := wsh.roomService.CreateTeam(teamData.RoomID, teamData.Name); err != nil {
+		log.Printf("Error creating team: %v", err)
+		wsh.sendTeamError(conn, err, "Failed to create team")
+		return
+	}
+
+	wsh.broadcastRoomUpdate(teamData.RoomID)
+}
+
+// handleDeleteTeam チーム削除（管理者のみ）
+func (wsh *WSHandler) handleDeleteTeam(conn *Connection, data interface{}) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Error marshaling delete team data: %v", err)
+		return
+	}
+
+	var teamData models.DeleteTeamData
+	if err := json.Unmarshal(jsonData, &teamData); err != nil {
+		log.Printf("Error unmarshaling delete team data: %v", err)
+		return
+	}
+
+	// 管理者権限チェック
+	if !wsh.authorizeAdmin(conn, teamData.RoomID) {
+		return
+	}
+
+	if err := wsh.roomService.DeleteTeam(teamData.RoomID, teamData.TeamID); err != nil {
+		log.Printf("Error deleting team: %v", err)
+		wsh.sendError(conn, "Failed to delete team")
+		return
+	}
+
+	wsh.broadcastRoomUpdate(teamData.RoomID)
+}
+
+// handleAssignTeam プレイヤーのチーム割り当て（管理者のみ）
+func (wsh *WSHandler) handleAssignTeam(conn *Connection, data interface{}) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Error marshaling assign team data: %v", err)
+		return
+	}
+
+	var assignData models.AssignTeamData
+	if err := json.Unmarshal(jsonData, &assignData); err != nil {
+		log.Printf("Error unmarshaling assign team data: %v", err)
+		return
+	}
+
+	// 管理者権限チェック
+	if !wsh.authorizeAdmin(conn, assignData.RoomID) {
+		return
+	}
+
+	if err := wsh.roomService.AssignPlayerTeam(assignData.RoomID, assignData.PlayerID, assignData.TeamID); err != nil {
+		log.Printf("Error assigning team: %v", err)
+		wsh.sendTeamError(conn, err, "Failed to assign team")
+		return
+	}
+
+	wsh.broadcastRoomUpdate(assignData.RoomID)
+}
+
+// handleAutoBalanceTeams チームの自動振り分け（管理者のみ）
+func (wsh *WSHandler) handleAutoBalanceTeams(conn *Connection, data interface{}) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Error marshaling auto balance teams data: %v", err)
+		return
+	}
+
+	var balanceData models.AutoBalanceTeamsData
+	if err := json.Unmarshal(jsonData, &balanceData); err != nil {
+		log.Printf("Error unmarshaling auto balance teams data: %v", err)
+		return
+	}
+
+	// 管理者権限チェック
+	if !wsh.authorizeAdmin(conn, balanceData.RoomID) {
+		return
+	}
+
+	if err := wsh.roomService.AutoBalanceTeams(balanceData.RoomID, balanceData.TeamCount); err != nil {
+		log.Printf("Error balancing teams: %v", err)
+		wsh.sendTeamError(conn, err, "Failed to balance teams")
+		return
+	}
+
+	wsh.broadcastRoomUpdate(balanceData.RoomID)
+}
+
+// sendTeamError チーム操作のエラーを送信（原因が分かるものはそのまま伝える）
+func (wsh *WSHandler) sendTeamError(conn *Connection, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrTeamModeDisabled):
+		wsh.sendError(conn, "Team mode is not enabled for this room")
+	case errors.Is(err, services.ErrNoTeams):
+		wsh.sendError(conn, "Create teams first or specify team_count")
+	default:
+		wsh.sendError(conn, fallback)
+	}
+}